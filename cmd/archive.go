@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"time"
+)
+
+// archiveOptions controls how writeArchive packages unpacked entries.
+type archiveOptions struct {
+	Force         bool // overwrite an existing output file
+	Manifest      bool // add a SHA256SUMS entry
+	PreserveAttrs bool // use recorded mtimes and modes instead of now and 0644
+}
+
+// writeArchiveOutput writes entries into an archive at outPath, or to out
+// when outPath is "-". The archive is written through replaceFile, so a
+// failed write leaves any existing file at outPath as it was.
+func writeArchiveOutput(entries []unpackEntry, format, outPath string, opts archiveOptions, out io.Writer) error {
+	if outPath == "-" {
+		_, err := writeArchive(entries, format, out, opts)
+		return err
+	}
+
+	if err := checkOverwrite(outPath, opts.Force); err != nil {
+		return err
+	}
+	var n int
+	err := replaceFile(outPath, func(w io.Writer) error {
+		var err error
+		n, err = writeArchive(entries, format, w, opts)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "wrote %s (%d entries)\n", outPath, n)
+	return nil
+}
+
+// writeArchive streams entries into a tar, tgz or zip archive on w and
+// returns the number of entries written. Entry names are re-sanitized, so
+// archives never contain absolute or traversing names.
+func writeArchive(entries []unpackEntry, format string, w io.Writer, opts archiveOptions) (int, error) {
+	for _, e := range entries {
+		if _, err := sanitizeContainerPath(e.Path); err != nil {
+			return 0, err
+		}
+	}
+	entries = append([]unpackEntry(nil), entries...)
+	if opts.Manifest {
+		entries = append(entries, unpackEntry{Kind: "manifest", Path: manifestFileName, Data: buildManifest(entries)})
+	}
+
+	var err error
+	now := time.Now()
+	for i := range entries {
+		if !opts.PreserveAttrs || entries[i].ModTime.IsZero() {
+			entries[i].ModTime = now
+		}
+		if !opts.PreserveAttrs || !entries[i].HasMode {
+			entries[i].Mode = 0o644
+		}
+	}
+
+	switch format {
+	case "tar":
+		err = writeTar(w, entries)
+	case "tgz":
+		gz := gzip.NewWriter(w)
+		if err = writeTar(gz, entries); err == nil {
+			err = gz.Close()
+		}
+	case "zip":
+		err = writeZip(w, entries)
+	default:
+		return 0, fmt.Errorf("unknown archive format: %s", format)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("write %s: %w", format, err)
+	}
+	return len(entries), nil
+}
+
+func writeTar(w io.Writer, entries []unpackEntry) error {
+	tw := tar.NewWriter(w)
+	for _, e := range entries {
+		hdr := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     e.Path,
+			Size:     int64(len(e.Data)),
+			Mode:     int64(e.Mode.Perm()),
+			ModTime:  e.ModTime,
+			Format:   tar.FormatPAX,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(e.Data); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+func writeZip(w io.Writer, entries []unpackEntry) error {
+	zw := zip.NewWriter(w)
+	for _, e := range entries {
+		hdr := &zip.FileHeader{
+			Name:     e.Path,
+			Method:   zip.Deflate,
+			Modified: e.ModTime,
+		}
+		hdr.SetMode(e.Mode.Perm())
+		fw, err := zw.CreateHeader(hdr)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(e.Data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/logicossoftware/go-mdocx"
+)
+
+func readTarNames(t *testing.T, r io.Reader) map[string]string {
+	t.Helper()
+	out := make(map[string]string)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("tar: %v", err)
+		}
+		b, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("tar read: %v", err)
+		}
+		out[hdr.Name] = string(b)
+	}
+	return out
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestUnpackCommand_TarFormat(t *testing.T) {
+	tmp := t.TempDir()
+	mdocxPath := createTestMDOCX(t, filepath.Join(tmp, "test.mdocx"), map[string]any{"k": "v"})
+	outPath := filepath.Join(tmp, "bundle.tar")
+
+	output, err := executeCommand(rootCmd, "unpack", "--format", "tar", "-o", outPath, "--manifest", mdocxPath)
+	if err != nil {
+		t.Fatalf("unpack --format tar failed: %v\noutput: %s", err, output)
+	}
+	f, err := os.Open(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	names := readTarNames(t, f)
+	want := []string{"SHA256SUMS", "assets/logo.png", "docs/guide.md", "metadata.json", "readme.md"}
+	if got := sortedKeys(names); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("unexpected entries: %v", got)
+	}
+	if names["readme.md"] != "# Hello World\n\nThis is a test." {
+		t.Errorf("unexpected readme content: %q", names["readme.md"])
+	}
+	// Nothing should have been unpacked to the default directory.
+	if _, err := os.Stat(filepath.Join(tmp, "out")); err == nil {
+		t.Error("archive output should not touch the filesystem")
+	}
+}
+
+func TestWriteArchive_TgzAndZip(t *testing.T) {
+	entries, err := unpackEntries(limitTestDoc())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tgz bytes.Buffer
+	if _, err := writeArchive(entries, "tgz", &tgz, archiveOptions{}); err != nil {
+		t.Fatalf("tgz: %v", err)
+	}
+	gz, err := gzip.NewReader(&tgz)
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	names := readTarNames(t, gz)
+	if _, ok := names["a/b/c/readme.md"]; !ok {
+		t.Errorf("tgz missing markdown entry: %v", sortedKeys(names))
+	}
+
+	var zbuf bytes.Buffer
+	n, err := writeArchive(entries, "zip", &zbuf, archiveOptions{})
+	if err != nil {
+		t.Fatalf("zip: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("expected 2 entries, got %d", n)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(zbuf.Bytes()), int64(zbuf.Len()))
+	if err != nil {
+		t.Fatalf("zip reader: %v", err)
+	}
+	if len(zr.File) != 2 || zr.File[1].Name != "assets/img.png" {
+		t.Errorf("unexpected zip entries: %v", zr.File)
+	}
+}
+
+func TestWriteArchive_RejectsUnsafePaths(t *testing.T) {
+	doc := &mdocx.Document{
+		Markdown: mdocx.MarkdownBundle{
+			BundleVersion: mdocx.VersionV1,
+			Files:         []mdocx.MarkdownFile{{Path: "../evil.md", Content: []byte("x")}},
+		},
+		Media: mdocx.MediaBundle{BundleVersion: mdocx.VersionV1},
+	}
+	if _, err := unpackEntries(doc); err == nil {
+		t.Fatal("expected error for traversal path")
+	}
+
+	var buf bytes.Buffer
+	entries := []unpackEntry{{Kind: "markdown", Path: "/etc/passwd", Data: []byte("x")}}
+	if _, err := writeArchive(entries, "tar", &buf, archiveOptions{}); err == nil {
+		t.Fatal("expected error for absolute path")
+	}
+}
+
+func TestWriteArchiveOutput_FailureKeepsExistingFile(t *testing.T) {
+	tmp := t.TempDir()
+	outPath := filepath.Join(tmp, "out.tar")
+	if err := os.WriteFile(outPath, []byte("keep"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	entries := []unpackEntry{{Kind: "markdown", Path: "/etc/passwd", Data: []byte("x")}}
+	var out bytes.Buffer
+	if err := writeArchiveOutput(entries, "tar", outPath, archiveOptions{Force: true}, &out); err == nil {
+		t.Fatal("expected error for absolute path")
+	}
+	if b, _ := os.ReadFile(outPath); string(b) != "keep" {
+		t.Errorf("failed --force write should leave the existing file, got %q", b)
+	}
+
+	entries[0].Path = "readme.md"
+	if err := writeArchiveOutput(entries, "tar", outPath, archiveOptions{Force: true}, &out); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if names := readTarNames(t, f); names["readme.md"] != "x" {
+		t.Errorf("expected the archive to replace the file, got %v", sortedKeys(names))
+	}
+	if st, _ := os.Stat(outPath); st.Mode().Perm() != 0o600 {
+		t.Errorf("expected the replaced file's mode to be kept, got %v", st.Mode())
+	}
+	if dir, _ := os.ReadDir(tmp); len(dir) != 1 {
+		t.Errorf("expected no temporary files left behind, got %d entries", len(dir))
+	}
+}
+
+func TestUnpackCommand_ArchiveToStdout(t *testing.T) {
+	tmp := t.TempDir()
+	mdocxPath := createTestMDOCX(t, filepath.Join(tmp, "test.mdocx"), nil)
+
+	output, err := executeCommand(rootCmd, "unpack", "--format", "tar", "-o", "-", mdocxPath)
+	if err != nil {
+		t.Fatalf("unpack to stdout failed: %v", err)
+	}
+	names := readTarNames(t, strings.NewReader(output))
+	if len(names) != 3 {
+		t.Errorf("expected 3 entries, got %v", sortedKeys(names))
+	}
+}
+
+func TestUnpackCommand_ArchiveFormatErrors(t *testing.T) {
+	tmp := t.TempDir()
+	mdocxPath := createTestMDOCX(t, filepath.Join(tmp, "test.mdocx"), nil)
+
+	if _, err := executeCommand(rootCmd, "unpack", "--format", "zip", mdocxPath); err == nil || !strings.Contains(err.Error(), "--output is required") {
+		t.Errorf("expected --output requirement, got %v", err)
+	}
+	if _, err := executeCommand(rootCmd, "unpack", "--format", "rar", "-o", "x", mdocxPath); err == nil {
+		t.Error("expected error for unknown format")
+	}
+
+	outPath := filepath.Join(tmp, "exists.zip")
+	if err := os.WriteFile(outPath, []byte("keep"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := executeCommand(rootCmd, "unpack", "--format", "zip", "-o", outPath, mdocxPath); err == nil {
+		t.Error("expected overwrite protection for archive output")
+	}
+	if b, _ := os.ReadFile(outPath); string(b) != "keep" {
+		t.Error("existing archive should be left untouched")
+	}
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Attribute keys used to carry file metadata on markdown files and media items.
+const (
+	attrModTime = "mtime" // RFC 3339 timestamp with nanoseconds, UTC
+	attrMode    = "mode"  // octal permission bits, e.g. "0644"
+)
+
+// fileAttributes returns the mtime and permission attributes of the file at path.
+func fileAttributes(path string) (map[string]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		attrModTime: info.ModTime().UTC().Format(time.RFC3339Nano),
+		attrMode:    fmt.Sprintf("%04o", info.Mode().Perm()),
+	}, nil
+}
+
+// fileAttrs are the file attributes recorded on an entry.
+type fileAttrs struct {
+	ModTime time.Time
+	Mode    os.FileMode
+	// HasMode is set when a mode was recorded, so 0000 is not taken as unset.
+	HasMode bool
+}
+
+// parseFileAttributes extracts the mtime and permission bits recorded by
+// fileAttributes. Missing attributes yield zero values. Only permission bits
+// are honored; setuid, setgid and sticky bits are never restored.
+func parseFileAttributes(attrs map[string]string) (fileAttrs, error) {
+	var fa fileAttrs
+	if v, ok := attrs[attrModTime]; ok {
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return fileAttrs{}, fmt.Errorf("invalid %s attribute %q", attrModTime, v)
+		}
+		fa.ModTime = t
+	}
+	if v, ok := attrs[attrMode]; ok {
+		n, err := strconv.ParseUint(v, 8, 32)
+		if err != nil {
+			return fileAttrs{}, fmt.Errorf("invalid %s attribute %q", attrMode, v)
+		}
+		fa.Mode = os.FileMode(n) & os.ModePerm
+		fa.HasMode = true
+	}
+	return fa, nil
+}
+
+// resolveAttributes parses the recorded attributes of each entry. It runs
+// only for --preserve-attrs, so containers whose attributes this CLI can't
+// read still unpack without them.
+func resolveAttributes(entries []unpackEntry) error {
+	for i := range entries {
+		fa, err := parseFileAttributes(entries[i].Attributes)
+		if err != nil {
+			return fmt.Errorf("%s %q: %w", entries[i].Kind, entries[i].Path, err)
+		}
+		entries[i].fileAttrs = fa
+	}
+	return nil
+}
+
+// restoreAttributes applies the recorded mtime and permissions of entries to
+// the files unpacked under outDir. Entries without attributes are left alone.
+func restoreAttributes(entries []unpackEntry, outDir string) error {
+	for _, e := range entries {
+		if e.ModTime.IsZero() && !e.HasMode {
+			continue
+		}
+		p, err := safeJoinOutput(outDir, e.Path)
+		if err != nil {
+			return err
+		}
+		if e.HasMode {
+			if err := os.Chmod(p, e.Mode); err != nil {
+				return fmt.Errorf("chmod: %w", err)
+			}
+		}
+		if !e.ModTime.IsZero() {
+			if err := os.Chtimes(p, e.ModTime, e.ModTime); err != nil {
+				return fmt.Errorf("chtimes: %w", err)
+			}
+		}
+	}
+	return nil
+}
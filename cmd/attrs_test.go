@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/logicossoftware/go-mdocx"
+)
+
+func TestParseFileAttributes(t *testing.T) {
+	fa, err := parseFileAttributes(map[string]string{
+		attrModTime: "2024-05-06T07:08:09.123456789Z",
+		attrMode:    "4755",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !fa.ModTime.Equal(time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.UTC)) {
+		t.Errorf("unexpected mtime: %v", fa.ModTime)
+	}
+	if fa.Mode != 0o755 || !fa.HasMode {
+		t.Errorf("expected setuid bit to be dropped, got %04o", fa.Mode)
+	}
+
+	fa, err = parseFileAttributes(nil)
+	if err != nil || !fa.ModTime.IsZero() || fa.Mode != 0 || fa.HasMode {
+		t.Errorf("expected zero values for missing attributes, got %+v %v", fa, err)
+	}
+	if fa, err := parseFileAttributes(map[string]string{attrMode: "0000"}); err != nil || !fa.HasMode || fa.Mode != 0 {
+		t.Errorf("expected mode 0000 to be recorded, got %+v %v", fa, err)
+	}
+
+	for _, attrs := range []map[string]string{
+		{attrModTime: "yesterday"},
+		{attrMode: "rwxr-xr-x"},
+	} {
+		if _, err := parseFileAttributes(attrs); err == nil {
+			t.Errorf("expected error for %v", attrs)
+		}
+	}
+}
+
+func TestUnpack_ForeignAttributesIgnoredWithoutPreserve(t *testing.T) {
+	tmp := t.TempDir()
+	doc := &mdocx.Document{
+		Markdown: mdocx.MarkdownBundle{
+			BundleVersion: mdocx.VersionV1,
+			Files: []mdocx.MarkdownFile{
+				{Path: "readme.md", Content: []byte("# Hi\n"), Attributes: map[string]string{attrModTime: "1714979289", attrMode: "rw-r--r--"}},
+			},
+		},
+		Media: mdocx.MediaBundle{BundleVersion: mdocx.VersionV1},
+	}
+	p := filepath.Join(tmp, "foreign.mdocx")
+	if err := writeContainerFile(p, doc, mdocx.CompNone, mdocx.CompNone); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{
+		{"unpack", p, "-o", filepath.Join(tmp, "dir")},
+		{"unpack", p, "--manifest", "-o", filepath.Join(tmp, "manifest")},
+		{"unpack", p, "--format", "zip", "-o", filepath.Join(tmp, "out.zip")},
+		{"unpack", p, "--layout", "mkdocs", "-o", filepath.Join(tmp, "site")},
+	} {
+		if out, err := executeCommand(rootCmd, args...); err != nil {
+			t.Errorf("%v: unexpected error: %v\n%s", args, err, out)
+		}
+	}
+	if _, err := executeCommand(rootCmd, "unpack", p, "--preserve-attrs", "-o", filepath.Join(tmp, "attrs")); err == nil || !strings.Contains(err.Error(), "invalid mtime attribute") {
+		t.Errorf("expected --preserve-attrs to reject the attributes, got %v", err)
+	}
+}
+
+func TestPackUnpack_PreserveAttrs(t *testing.T) {
+	tmp := t.TempDir()
+	oldCwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(oldCwd) }()
+	if err := os.Chdir(tmp); err != nil {
+		t.Fatal(err)
+	}
+
+	mdFile := filepath.Join(tmp, "doc.md")
+	if err := os.WriteFile(mdFile, []byte("# Doc"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	mediaDir := filepath.Join(tmp, "media")
+	if err := os.MkdirAll(mediaDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	mediaFile := filepath.Join(mediaDir, "data.bin")
+	if err := os.WriteFile(mediaFile, []byte{1, 2, 3}, 0o640); err != nil {
+		t.Fatal(err)
+	}
+	mdTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	mediaTime := time.Date(2021, 6, 7, 8, 9, 10, 0, time.UTC)
+	if err := os.Chtimes(mdFile, mdTime, mdTime); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(mediaFile, mediaTime, mediaTime); err != nil {
+		t.Fatal(err)
+	}
+
+	bundle := filepath.Join(tmp, "attrs.mdocx")
+	if _, err := executeCommand(rootCmd, "pack", "--media-dir", mediaDir, "--preserve-attrs", "-o", bundle, mdFile); err != nil {
+		t.Fatalf("pack failed: %v", err)
+	}
+
+	f, err := os.Open(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := mdocx.Decode(f)
+	f.Close()
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if doc.Markdown.Files[0].Attributes[attrMode] != "0600" {
+		t.Errorf("expected recorded mode 0600, got %v", doc.Markdown.Files[0].Attributes)
+	}
+
+	outDir := filepath.Join(tmp, "out")
+	if _, err := executeCommand(rootCmd, "unpack", "-o", outDir, "--preserve-attrs", bundle); err != nil {
+		t.Fatalf("unpack failed: %v", err)
+	}
+
+	check := func(p string, wantTime time.Time, wantMode os.FileMode) {
+		t.Helper()
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !info.ModTime().Equal(wantTime) {
+			t.Errorf("%s: mtime %v, want %v", p, info.ModTime(), wantTime)
+		}
+		if runtime.GOOS != "windows" && info.Mode().Perm() != wantMode {
+			t.Errorf("%s: mode %04o, want %04o", p, info.Mode().Perm(), wantMode)
+		}
+	}
+	check(filepath.Join(outDir, "doc.md"), mdTime, 0o600)
+	check(filepath.Join(outDir, "data.bin"), mediaTime, 0o640)
+}
+
+func TestPack_AttributesAreOptIn(t *testing.T) {
+	tmp := t.TempDir()
+	mdFile := filepath.Join(tmp, "doc.md")
+	if err := os.WriteFile(mdFile, []byte("# Doc"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	files, err := collectMarkdownFiles([]string{mdFile}, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files[0].Attributes != nil {
+		t.Errorf("expected no attributes without preserveAttrs, got %v", files[0].Attributes)
+	}
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+)
+
+// expandInputs turns command arguments into container paths. Arguments that
+// name an existing file are used as-is; other arguments containing glob
+// characters are expanded; directories are searched for *.mdocx files when
+// recursive is set. batch reports whether the arguments could name more than
+// one file, which callers use to choose between single-file and batch output.
+func expandInputs(args []string, recursive bool) (files []string, batch bool, err error) {
+	seen := make(map[string]bool)
+	add := func(p string) {
+		if !seen[p] {
+			seen[p] = true
+			files = append(files, p)
+		}
+	}
+	batch = len(args) > 1
+
+	for _, arg := range args {
+		info, statErr := os.Stat(arg)
+		switch {
+		case statErr == nil && info.IsDir():
+			if !recursive {
+				return nil, false, fmt.Errorf("%s is a directory (use --recursive)", arg)
+			}
+			batch = true
+			found, err := findContainers(arg)
+			if err != nil {
+				return nil, false, err
+			}
+			for _, p := range found {
+				add(p)
+			}
+		case statErr != nil && strings.ContainsAny(arg, "*?["):
+			batch = true
+			matches, err := filepath.Glob(arg)
+			if err != nil {
+				return nil, false, fmt.Errorf("bad pattern %q: %w", arg, err)
+			}
+			if len(matches) == 0 {
+				return nil, false, fmt.Errorf("no files match %s", arg)
+			}
+			for _, m := range matches {
+				if mi, err := os.Stat(m); err == nil && mi.IsDir() {
+					if !recursive {
+						continue
+					}
+					found, err := findContainers(m)
+					if err != nil {
+						return nil, false, err
+					}
+					for _, p := range found {
+						add(p)
+					}
+					continue
+				}
+				add(m)
+			}
+		default:
+			// Missing files are reported by the command, per file.
+			add(arg)
+		}
+	}
+	return files, batch, nil
+}
+
+// findContainers returns the .mdocx files below dir, sorted.
+func findContainers(dir string) ([]string, error) {
+	var found []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.EqualFold(filepath.Ext(p), ".mdocx") {
+			found = append(found, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk %s: %w", dir, err)
+	}
+	sort.Strings(found)
+	return found, nil
+}
+
+// runBatch calls fn for every input with at most jobs calls running at once,
+// and returns the results in input order.
+func runBatch[T any](inputs []string, jobs int, fn func(input string) T) []T {
+	if jobs < 1 {
+		jobs = 1
+	}
+	results := make([]T, len(inputs))
+	sem := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+	for i, input := range inputs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = fn(input)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// batchSummary aggregates the results of a multi-file run.
+type batchSummary struct {
+	Files         int   `json:"files"`
+	OK            int   `json:"ok"`
+	Failed        int   `json:"failed"`
+	TotalSize     int64 `json:"total_size"`
+	MarkdownFiles int   `json:"markdown_files"`
+	MediaItems    int   `json:"media_items"`
+	MarkdownBytes int   `json:"markdown_bytes"`
+	MediaBytes    int   `json:"media_bytes"`
+}
+
+// add counts one file of size bytes on disk.
+func (s *batchSummary) add(size int64, ok bool, markdownFiles, mediaItems, markdownBytes, mediaBytes int) {
+	s.Files++
+	if ok {
+		s.OK++
+	} else {
+		s.Failed++
+	}
+	s.TotalSize += size
+	s.MarkdownFiles += markdownFiles
+	s.MediaItems += mediaItems
+	s.MarkdownBytes += markdownBytes
+	s.MediaBytes += mediaBytes
+}
+
+func (s batchSummary) write(w io.Writer, okWord, failedWord string) {
+	fmt.Fprintf(w, "%d files: %d %s, %d %s, %s on disk; markdown=%d (%s) media=%d (%s)\n",
+		s.Files, s.OK, okWord, s.Failed, failedWord, humanSize(int(s.TotalSize)),
+		s.MarkdownFiles, humanSize(s.MarkdownBytes), s.MediaItems, humanSize(s.MediaBytes))
+}
+
+// err returns a non-nil error if any file failed.
+func (s batchSummary) err(verb string) error {
+	if s.Failed > 0 {
+		return fmt.Errorf("%s failed for %d of %d files", verb, s.Failed, s.Files)
+	}
+	return nil
+}
+
+// fileSize returns the size of the file at p, or 0 if it can't be read.
+func fileSize(p string) int64 {
+	if info, err := os.Stat(p); err == nil {
+		return info.Size()
+	}
+	return 0
+}
+
+// addBatchFlags registers the multi-file flags shared by inspect and validate.
+func addBatchFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolP("recursive", "r", false, "search directory arguments for .mdocx files")
+	cmd.Flags().IntP("jobs", "j", runtime.NumCPU(), "number of files to process concurrently")
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// batchTestDir creates a.mdocx and sub/b.mdocx (valid) and sub/bad.mdocx
+// (not a container) plus an unrelated text file.
+func batchTestDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	createTestMDOCX(t, filepath.Join(dir, "a.mdocx"), map[string]any{"title": "A"})
+	createTestMDOCX(t, filepath.Join(dir, "sub", "b.mdocx"), nil)
+	if err := os.WriteFile(filepath.Join(dir, "sub", "bad.mdocx"), []byte("not a container"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestExpandInputs(t *testing.T) {
+	dir := batchTestDir(t)
+	a := filepath.Join(dir, "a.mdocx")
+
+	files, batch, err := expandInputs([]string{a}, false)
+	if err != nil || batch || len(files) != 1 {
+		t.Errorf("single file: got %v, batch=%v, err=%v", files, batch, err)
+	}
+
+	if _, _, err := expandInputs([]string{dir}, false); err == nil || !strings.Contains(err.Error(), "--recursive") {
+		t.Errorf("directory without --recursive: expected error, got %v", err)
+	}
+
+	files, batch, err = expandInputs([]string{dir, a}, true)
+	if err != nil || !batch {
+		t.Fatalf("recursive: batch=%v err=%v", batch, err)
+	}
+	want := []string{a, filepath.Join(dir, "sub", "b.mdocx"), filepath.Join(dir, "sub", "bad.mdocx")}
+	if strings.Join(files, ",") != strings.Join(want, ",") {
+		t.Errorf("recursive: got %v, want %v (sorted, deduplicated, .mdocx only)", files, want)
+	}
+
+	files, batch, err = expandInputs([]string{filepath.Join(dir, "*.mdocx")}, false)
+	if err != nil || !batch || len(files) != 1 || files[0] != a {
+		t.Errorf("glob: got %v, batch=%v, err=%v", files, batch, err)
+	}
+	if _, _, err := expandInputs([]string{filepath.Join(dir, "*.zip")}, false); err == nil {
+		t.Error("expected error for glob without matches")
+	}
+
+	missing := filepath.Join(dir, "missing.mdocx")
+	if files, _, err := expandInputs([]string{missing}, false); err != nil || files[0] != missing {
+		t.Errorf("missing files should pass through for the command to report, got %v, %v", files, err)
+	}
+}
+
+func TestRunBatch_KeepsOrder(t *testing.T) {
+	inputs := []string{"a", "bb", "ccc", "dddd", "eeeee"}
+	got := runBatch(inputs, 2, func(s string) int { return len(s) })
+	for i, n := range got {
+		if n != i+1 {
+			t.Fatalf("results out of order: %v", got)
+		}
+	}
+}
+
+func TestValidateCommand_Batch(t *testing.T) {
+	dir := batchTestDir(t)
+
+	out, err := executeCommand(rootCmd, "validate", "-r", "--jobs", "2", dir)
+	if err == nil || !strings.Contains(err.Error(), "1 of 3 files") {
+		t.Fatalf("expected failure for one file, got %v", err)
+	}
+	for _, want := range []string{"OK    " + filepath.Join(dir, "a.mdocx"), "FAIL  " + filepath.Join(dir, "sub", "bad.mdocx"), "3 files: 2 valid, 1 invalid"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+
+	out, _ = executeCommand(rootCmd, "validate", "--json", filepath.Join(dir, "a.mdocx"), filepath.Join(dir, "sub", "b.mdocx"))
+	var result validationBatchResult
+	if err := json.NewDecoder(strings.NewReader(out)).Decode(&result); err != nil {
+		t.Fatalf("parse JSON: %v\n%s", err, out)
+	}
+	if len(result.Files) != 2 || result.Summary.OK != 2 || result.Summary.MarkdownFiles != 4 || result.Summary.TotalSize == 0 {
+		t.Errorf("unexpected batch result: %+v", result)
+	}
+	if result.Files[0].File != filepath.Join(dir, "a.mdocx") {
+		t.Errorf("expected file names in results, got %q", result.Files[0].File)
+	}
+}
+
+func TestInspectCommand_Batch(t *testing.T) {
+	dir := batchTestDir(t)
+	a, b := filepath.Join(dir, "a.mdocx"), filepath.Join(dir, "sub", "b.mdocx")
+
+	out, err := executeCommand(rootCmd, "inspect", a, b)
+	if err != nil {
+		t.Fatalf("inspect batch failed: %v", err)
+	}
+	for _, want := range []string{"==> " + a + " <==", "==> " + b + " <==", "Metadata keys: [title]", "2 files: 2 ok, 0 failed"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+
+	out, err = executeCommand(rootCmd, "inspect", "--format", "csv", a, b)
+	if err != nil {
+		t.Fatalf("inspect batch csv failed: %v", err)
+	}
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 7 || records[0][0] != "file" || records[1][0] != a || records[6][0] != b {
+		t.Errorf("expected a file column and 3 rows per file, got %v", records)
+	}
+
+	out, err = executeCommand(rootCmd, "inspect", "--query", ".file", "-r", dir)
+	if err == nil {
+		t.Error("expected error for the bad container")
+	}
+	if !strings.Contains(out, "ERROR: "+filepath.Join(dir, "sub", "bad.mdocx")) || !strings.Contains(out, a+"\n") {
+		t.Errorf("unexpected query batch output:\n%s", out)
+	}
+}
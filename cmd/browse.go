@@ -4,10 +4,6 @@ Copyright © 2026 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
-	"fmt"
-	"os"
-
-	"github.com/logicossoftware/go-mdocx"
 	"github.com/spf13/cobra"
 )
 
@@ -21,20 +17,14 @@ var browseCmd = &cobra.Command{
 		noImages, _ := cmd.Flags().GetBool("no-images")
 		theme, _ := cmd.Flags().GetString("theme")
 		input := args[0]
-
-		f, err := os.Open(input)
+		limits, err := limitsFromFlags(cmd)
 		if err != nil {
-			return fmt.Errorf("open: %w", err)
+			return err
 		}
-		defer f.Close()
 
-		opts := []mdocx.ReadOption{}
-		if !strict {
-			opts = append(opts, mdocx.WithVerifyHashes(false))
-		}
-		doc, err := mdocx.Decode(f, opts...)
+		doc, err := decodeContainerFile(input, strict, limits)
 		if err != nil {
-			return fmt.Errorf("decode: %w", err)
+			return err
 		}
 		header, _ := readHeaderInfo(input)
 
@@ -48,4 +38,5 @@ func init() {
 	browseCmd.Flags().Bool("strict", true, "fail on any spec violation")
 	browseCmd.Flags().Bool("no-images", false, "disable Sixel rendering")
 	browseCmd.Flags().String("theme", "", "Glamour theme name or path")
+	addLimitFlags(browseCmd)
 }
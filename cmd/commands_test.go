@@ -10,6 +10,7 @@ import (
 
 	"github.com/logicossoftware/go-mdocx"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 // ---------------------------------------------------------------------------
@@ -50,8 +51,11 @@ func createTestMDOCX(t *testing.T, outPath string, meta map[string]any) string {
 	return outPath
 }
 
-// executeCommand runs a cobra command with args, capturing stdout.
+// executeCommand runs a cobra command with args, capturing stdout. Flags on
+// every command are reset to their defaults first, since cobra keeps flag
+// values between executions of the same command tree.
 func executeCommand(root *cobra.Command, args ...string) (string, error) {
+	resetFlags(root)
 	buf := new(bytes.Buffer)
 	root.SetOut(buf)
 	root.SetErr(buf)
@@ -60,6 +64,23 @@ func executeCommand(root *cobra.Command, args ...string) (string, error) {
 	return buf.String(), err
 }
 
+// resetFlags restores every flag on cmd and its subcommands to its default.
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			_ = sv.Replace(nil)
+		} else {
+			_ = f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	for _, c := range cmd.Commands() {
+		resetFlags(c)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // version command
 // ---------------------------------------------------------------------------
@@ -63,6 +63,103 @@ func readHeaderInfo(path string) (*headerInfo, error) {
 	}, nil
 }
 
+// decodeContainerFile decodes the container at input. Declared section sizes
+// are checked against limits before anything is decompressed, and the decoded
+// document is checked again. Hash verification is skipped unless strict is set.
+func decodeContainerFile(input string, strict bool, limits decodeLimits) (*mdocx.Document, error) {
+	f, err := os.Open(input)
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+	defer f.Close()
+
+	opts := []mdocx.ReadOption{mdocx.WithReadLimits(limits.readLimits())}
+	if !strict {
+		opts = append(opts, mdocx.WithVerifyHashes(false))
+	}
+	var doc *mdocx.Document
+	if layout, lerr := readContainerLayout(input); lerr == nil {
+		if err := limits.checkLayout(layout); err != nil {
+			return nil, err
+		}
+		doc, err = decodeFramed(f, layout, opts...)
+	} else {
+		// A layout that can't be read is left for Decode to report.
+		doc, err = mdocx.Decode(f, opts...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+	if err := limits.checkDocument(doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// sectionCompressions returns the compression of the markdown and media
+// sections of the container at input, defaulting to zstd for any it can't read.
+func sectionCompressions(input string) (mdocx.Compression, mdocx.Compression) {
+	markdown, media := mdocx.CompZSTD, mdocx.CompZSTD
+	if layout, err := readContainerLayout(input); err == nil && len(layout.Sections) == 2 {
+		markdown, media = layout.Sections[0].compression(), layout.Sections[1].compression()
+	}
+	return markdown, media
+}
+
+// writeContainerFile encodes doc to outputPath through replaceFile, so an
+// existing file (possibly the input) is replaced only once the new one is
+// complete.
+func writeContainerFile(outputPath string, doc *mdocx.Document, markdownComp, mediaComp mdocx.Compression) error {
+	return replaceFile(outputPath, func(w io.Writer) error {
+		if err := mdocx.Encode(w, doc,
+			mdocx.WithMarkdownCompression(markdownComp),
+			mdocx.WithMediaCompression(mediaComp),
+			mdocx.WithVerifyHashesOnWrite(true),
+		); err != nil {
+			return fmt.Errorf("encode: %w", err)
+		}
+		return nil
+	})
+}
+
+// replaceFile runs write on a temporary file in the directory of
+// outputPath and renames it over outputPath once write succeeds. On failure
+// the temporary file is removed and any existing file is left untouched.
+func replaceFile(outputPath string, write func(w io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(outputPath), "."+filepath.Base(outputPath)+".*")
+	if err != nil {
+		return fmt.Errorf("create output: %w", err)
+	}
+	success := false
+	defer func() {
+		if !success {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if err := write(tmp); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write output: %w", err)
+	}
+	// CreateTemp makes the file private; keep the mode of the file being
+	// replaced instead.
+	mode := os.FileMode(0o644)
+	if st, err := os.Stat(outputPath); err == nil {
+		mode = st.Mode().Perm()
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return fmt.Errorf("write output: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), outputPath); err != nil {
+		return fmt.Errorf("write output: %w", err)
+	}
+	success = true
+	return nil
+}
+
 func parseCompression(value string) (mdocx.Compression, error) {
 	switch strings.ToLower(strings.TrimSpace(value)) {
 	case "", "zstd":
@@ -80,6 +177,24 @@ func parseCompression(value string) (mdocx.Compression, error) {
 	}
 }
 
+// compressionName is the inverse of parseCompression.
+func compressionName(c mdocx.Compression) string {
+	switch c {
+	case mdocx.CompNone:
+		return "none"
+	case mdocx.CompZIP:
+		return "zip"
+	case mdocx.CompZSTD:
+		return "zstd"
+	case mdocx.CompLZ4:
+		return "lz4"
+	case mdocx.CompBR:
+		return "br"
+	default:
+		return fmt.Sprintf("unknown(0x%x)", uint16(c))
+	}
+}
+
 func readMetadataJSON(path string) (map[string]any, error) {
 	b, err := os.ReadFile(path)
 	if err != nil {
@@ -95,7 +210,10 @@ func readMetadataJSON(path string) (map[string]any, error) {
 	return out, nil
 }
 
-func collectMarkdownFiles(inputs []string, baseDir string) ([]mdocx.MarkdownFile, error) {
+// collectMarkdownFiles reads the markdown files named by inputs and found under
+// baseDir. With preserveAttrs, each file's mtime and mode are recorded in its
+// Attributes.
+func collectMarkdownFiles(inputs []string, baseDir string, preserveAttrs bool) ([]mdocx.MarkdownFile, error) {
 	var files []string
 	if baseDir != "" {
 		collected, err := collectFiles(baseDir, func(rel string, info os.DirEntry) bool {
@@ -145,13 +263,21 @@ func collectMarkdownFiles(inputs []string, baseDir string) ([]mdocx.MarkdownFile
 			return nil, fmt.Errorf("duplicate markdown path: %s", containerPath)
 		}
 		seen[containerPath] = struct{}{}
-		out = append(out, mdocx.MarkdownFile{Path: containerPath, Content: b})
+		mf := mdocx.MarkdownFile{Path: containerPath, Content: b}
+		if preserveAttrs {
+			if mf.Attributes, err = fileAttributes(filePath); err != nil {
+				return nil, err
+			}
+		}
+		out = append(out, mf)
 	}
 
 	return out, nil
 }
 
-func collectMediaItems(mediaDir string) ([]mdocx.MediaItem, error) {
+// collectMediaItems reads every file under mediaDir as a media item. With
+// preserveAttrs, each file's mtime and mode are recorded in its Attributes.
+func collectMediaItems(mediaDir string, preserveAttrs bool) ([]mdocx.MediaItem, error) {
 	if strings.TrimSpace(mediaDir) == "" {
 		return nil, nil
 	}
@@ -176,13 +302,19 @@ func collectMediaItems(mediaDir string) ([]mdocx.MediaItem, error) {
 		}
 		seenIDs[id] = containerPath
 		m := detectMimeType(fsPath)
-		out = append(out, mdocx.MediaItem{
+		mi := mdocx.MediaItem{
 			ID:       id,
 			Path:     containerPath,
 			MIMEType: m,
 			Data:     b,
 			SHA256:   sha256.Sum256(b),
-		})
+		}
+		if preserveAttrs {
+			if mi.Attributes, err = fileAttributes(fsPath); err != nil {
+				return nil, err
+			}
+		}
+		out = append(out, mi)
 	}
 	return out, nil
 }
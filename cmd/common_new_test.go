@@ -99,7 +99,7 @@ func TestCollectMarkdownFiles_WithBaseDir(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	files, err := collectMarkdownFiles(nil, baseDir)
+	files, err := collectMarkdownFiles(nil, baseDir, false)
 	if err != nil {
 		t.Fatalf("collectMarkdownFiles: %v", err)
 	}
@@ -127,7 +127,7 @@ func TestCollectMarkdownFiles_DirectoryInArgs(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	files, err := collectMarkdownFiles([]string{mdDir}, "")
+	files, err := collectMarkdownFiles([]string{mdDir}, "", false)
 	if err != nil {
 		t.Fatalf("collectMarkdownFiles: %v", err)
 	}
@@ -139,7 +139,7 @@ func TestCollectMarkdownFiles_DirectoryInArgs(t *testing.T) {
 func TestCollectMarkdownFiles_NoFilesFound(t *testing.T) {
 	tmp := t.TempDir()
 	// Empty directory — no .md files
-	_, err := collectMarkdownFiles(nil, tmp)
+	_, err := collectMarkdownFiles(nil, tmp, false)
 	if err == nil {
 		t.Fatal("expected error for no markdown files")
 	}
@@ -165,7 +165,7 @@ func TestCollectMarkdownFiles_DuplicatePaths(t *testing.T) {
 	}
 
 	// Same file passed twice should produce duplicate error
-	_, err = collectMarkdownFiles([]string{p, p}, "")
+	_, err = collectMarkdownFiles([]string{p, p}, "", false)
 	if err == nil {
 		t.Fatal("expected error for duplicate paths")
 	}
@@ -175,7 +175,7 @@ func TestCollectMarkdownFiles_DuplicatePaths(t *testing.T) {
 }
 
 func TestCollectMarkdownFiles_NonexistentInput(t *testing.T) {
-	_, err := collectMarkdownFiles([]string{filepath.Join(t.TempDir(), "nonexistent.md")}, "")
+	_, err := collectMarkdownFiles([]string{filepath.Join(t.TempDir(), "nonexistent.md")}, "", false)
 	if err == nil {
 		t.Fatal("expected error for nonexistent file")
 	}
@@ -205,7 +205,7 @@ func TestCollectMarkdownFiles_BaseDirAndArgs(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	files, err := collectMarkdownFiles([]string{extraFile}, baseDir)
+	files, err := collectMarkdownFiles([]string{extraFile}, baseDir, false)
 	if err != nil {
 		t.Fatalf("collectMarkdownFiles: %v", err)
 	}
@@ -401,7 +401,7 @@ func TestCollectMediaItems_SHA256Populated(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	items, err := collectMediaItems(tmp)
+	items, err := collectMediaItems(tmp, false)
 	if err != nil {
 		t.Fatalf("collectMediaItems: %v", err)
 	}
@@ -425,7 +425,7 @@ func TestCollectMediaItems_DuplicateIDDetected(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	_, err := collectMediaItems(tmp)
+	_, err := collectMediaItems(tmp, false)
 	if err == nil {
 		t.Fatal("expected error for duplicate media IDs")
 	}
@@ -435,7 +435,7 @@ func TestCollectMediaItems_DuplicateIDDetected(t *testing.T) {
 }
 
 func TestCollectMediaItems_EmptyDir(t *testing.T) {
-	items, err := collectMediaItems("")
+	items, err := collectMediaItems("", false)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
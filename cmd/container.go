@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/logicossoftware/go-mdocx"
+)
+
+const (
+	fixedHeaderSize   = 32
+	sectionHeaderSize = 16
+
+	// sectionFlagCompressionMask extracts the compression algorithm (bits 0-3).
+	sectionFlagCompressionMask uint16 = 0x000F
+	// sectionFlagHasUncompressedLen marks payloads prefixed with an 8-byte uncompressed length.
+	sectionFlagHasUncompressedLen uint16 = 0x0010
+)
+
+// sectionInfo describes one section as framed on disk, without decoding its payload.
+type sectionInfo struct {
+	Type            uint16 `json:"type"`
+	Flags           uint16 `json:"flags"`
+	Offset          int64  `json:"offset"`
+	PayloadOffset   int64  `json:"payload_offset"`
+	PayloadLen      uint64 `json:"payload_length"`
+	UncompressedLen uint64 `json:"uncompressed_length"`
+	Reserved        uint32 `json:"reserved"`
+}
+
+func (s sectionInfo) compression() mdocx.Compression {
+	return mdocx.Compression(s.Flags & sectionFlagCompressionMask)
+}
+
+func (s sectionInfo) hasUncompressedLen() bool {
+	return s.Flags&sectionFlagHasUncompressedLen != 0
+}
+
+// payloadEnd returns the offset just past the payload. A hostile length
+// saturates at math.MaxInt64 instead of wrapping to a negative offset.
+func (s sectionInfo) payloadEnd() int64 {
+	if s.PayloadLen > uint64(math.MaxInt64-s.PayloadOffset) {
+		return math.MaxInt64
+	}
+	return s.PayloadOffset + int64(s.PayloadLen)
+}
+
+// containerLayout is the byte-level framing of an MDOCX file: where the
+// metadata block and each section live and how large they claim to be.
+type containerLayout struct {
+	FileSize       int64         `json:"file_size"`
+	MetadataOffset int64         `json:"metadata_offset"`
+	MetadataLength uint32        `json:"metadata_length"`
+	Sections       []sectionInfo `json:"sections"`
+}
+
+// totalUncompressed returns the sum of the declared uncompressed section sizes.
+func (l *containerLayout) totalUncompressed() uint64 {
+	var total uint64
+	for _, s := range l.Sections {
+		if s.UncompressedLen > math.MaxUint64-total {
+			return math.MaxUint64
+		}
+		total += s.UncompressedLen
+	}
+	return total
+}
+
+// readContainerLayout walks the fixed header and section headers of the file
+// at path. Payloads are skipped, so the cost is independent of content size.
+func readContainerLayout(path string) (*containerLayout, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	var hdr [fixedHeaderSize]byte
+	if _, err := io.ReadFull(f, hdr[:]); err != nil {
+		return nil, fmt.Errorf("fixed header: %w", err)
+	}
+	layout := &containerLayout{
+		FileSize:       stat.Size(),
+		MetadataOffset: fixedHeaderSize,
+		MetadataLength: binary.LittleEndian.Uint32(hdr[16:20]),
+	}
+
+	offset := int64(fixedHeaderSize) + int64(layout.MetadataLength)
+	for i := 0; i < 2; i++ {
+		sec, err := readSectionInfo(f, offset)
+		if err != nil {
+			return nil, fmt.Errorf("section %d: %w", i+1, err)
+		}
+		layout.Sections = append(layout.Sections, sec)
+		offset = sec.payloadEnd()
+	}
+	return layout, nil
+}
+
+// readSectionInfo reads the section header at offset and, for compressed
+// payloads, the 8-byte uncompressed length prefix that follows it.
+func readSectionInfo(r io.ReaderAt, offset int64) (sectionInfo, error) {
+	var buf [sectionHeaderSize]byte
+	if _, err := r.ReadAt(buf[:], offset); err != nil {
+		return sectionInfo{}, fmt.Errorf("header at offset %d: %w", offset, err)
+	}
+	sec := sectionInfo{
+		Type:          binary.LittleEndian.Uint16(buf[0:2]),
+		Flags:         binary.LittleEndian.Uint16(buf[2:4]),
+		Offset:        offset,
+		PayloadOffset: offset + sectionHeaderSize,
+		PayloadLen:    binary.LittleEndian.Uint64(buf[4:12]),
+		Reserved:      binary.LittleEndian.Uint32(buf[12:16]),
+	}
+	sec.UncompressedLen = sec.PayloadLen
+	if sec.hasUncompressedLen() && sec.PayloadLen >= 8 {
+		var prefix [8]byte
+		if _, err := r.ReadAt(prefix[:], sec.PayloadOffset); err != nil {
+			return sectionInfo{}, fmt.Errorf("uncompressed length at offset %d: %w", sec.PayloadOffset, err)
+		}
+		sec.UncompressedLen = binary.LittleEndian.Uint64(prefix[:])
+	}
+	return sec, nil
+}
@@ -0,0 +1,452 @@
+/*
+Copyright © 2026 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/logicossoftware/go-mdocx"
+	"github.com/spf13/cobra"
+)
+
+// dumpCmd represents the dump command
+var dumpCmd = &cobra.Command{
+	Use:   "dump <file>",
+	Short: "Print an annotated hex view of an .mdocx container's structure",
+	Long: `Print every structural field of an .mdocx container (header, metadata,
+section headers and payload boundaries) with its offset, raw bytes and decoded
+value, and point at the first offset where the file diverges from the v1 spec.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jsonOut, _ := cmd.Flags().GetBool("json")
+		maxBytes, _ := cmd.Flags().GetInt("bytes")
+
+		f, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("open: %w", err)
+		}
+		defer f.Close()
+		stat, err := f.Stat()
+		if err != nil {
+			return fmt.Errorf("stat: %w", err)
+		}
+
+		d := dumpContainer(f, stat.Size(), maxBytes)
+		if jsonOut {
+			err = writeDumpJSON(cmd.OutOrStdout(), d, f)
+		} else {
+			err = writeDump(cmd.OutOrStdout(), d, f)
+		}
+		if err != nil {
+			return err
+		}
+		if d.Divergence != nil {
+			return fmt.Errorf("container diverges from the v1 spec at offset %d (0x%x): %s", d.Divergence.Offset, d.Divergence.Offset, d.Divergence.Message)
+		}
+		return nil
+	},
+}
+
+// dumpField is one labelled byte range of a container. Hex holds up to the
+// dump's byte limit of the range, hex encoded; the dumper leaves it empty
+// and writeDumpJSON streams it from the file.
+type dumpField struct {
+	Name     string `json:"name"`
+	Offset   int64  `json:"offset"`
+	Length   int64  `json:"length"`
+	Hex      string `json:"hex"`
+	Value    string `json:"value,omitempty"`
+	Severity string `json:"severity,omitempty"` // "error" or "warning"
+	Problem  string `json:"problem,omitempty"`
+	// ProblemOffset is the exact byte the problem was found at, which may lie
+	// inside the field.
+	ProblemOffset int64 `json:"problem_offset,omitempty"`
+
+	shown int64 // bytes of the range to print, within the byte limit and the file
+}
+
+// dumpDivergence is the first point where parsing fails under the v1 spec.
+type dumpDivergence struct {
+	Offset  int64  `json:"offset"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type containerDump struct {
+	FileSize   int64           `json:"file_size"`
+	Fields     []dumpField     `json:"fields"`
+	Divergence *dumpDivergence `json:"divergence"`
+}
+
+// dumper walks a container field by field. Parsing continues past problems
+// where the framing still makes sense, and stops where it doesn't.
+type dumper struct {
+	r        io.ReaderAt
+	size     int64
+	maxBytes int
+	d        containerDump
+}
+
+// dumpContainer labels the structure of the size-byte container in r.
+// Variable-length fields show at most maxBytes of raw data.
+func dumpContainer(r io.ReaderAt, size int64, maxBytes int) *containerDump {
+	p := &dumper{r: r, size: size, maxBytes: maxBytes, d: containerDump{FileSize: size, Fields: []dumpField{}}}
+	p.walk()
+	return &p.d
+}
+
+// read returns up to n bytes at off, short if the file ends first.
+func (p *dumper) read(off, n int64) []byte {
+	if off >= p.size || n <= 0 {
+		return nil
+	}
+	if off+n > p.size {
+		n = p.size - off
+	}
+	buf := make([]byte, n)
+	k, _ := p.r.ReadAt(buf, off)
+	return buf[:k]
+}
+
+// field records a field over [off, off+length) and returns it for annotation.
+func (p *dumper) field(name string, off, length int64, value string) *dumpField {
+	shown := max(min(length, p.size-off), 0)
+	if p.maxBytes >= 0 && shown > int64(p.maxBytes) {
+		shown = int64(p.maxBytes)
+	}
+	p.d.Fields = append(p.d.Fields, dumpField{
+		Name:   name,
+		Offset: off,
+		Length: length,
+		Value:  value,
+		shown:  shown,
+	})
+	return &p.d.Fields[len(p.d.Fields)-1]
+}
+
+// fail marks f with a spec violation at offset. The first error becomes the
+// dump's divergence point.
+func (p *dumper) fail(f *dumpField, severity string, offset int64, format string, args ...any) {
+	f.Severity = severity
+	f.Problem = fmt.Sprintf(format, args...)
+	f.ProblemOffset = offset
+	if severity == "error" && p.d.Divergence == nil {
+		p.d.Divergence = &dumpDivergence{Offset: offset, Field: f.Name, Message: f.Problem}
+	}
+}
+
+// fixed reads a fixed-size field, failing it if the file is too short.
+func (p *dumper) fixed(name string, off, length int64) ([]byte, *dumpField, bool) {
+	b := p.read(off, length)
+	f := p.field(name, off, length, "")
+	if int64(len(b)) < length {
+		f.Length = int64(len(b))
+		p.fail(f, "error", off+int64(len(b)), "file ends after %d of %d bytes", len(b), length)
+		return nil, f, false
+	}
+	return b, f, true
+}
+
+func (p *dumper) walk() {
+	magic, f, ok := p.fixed("magic", 0, 8)
+	if !ok {
+		return
+	}
+	f.Value = fmt.Sprintf("%q", magic)
+	for i := range magic {
+		if magic[i] != mdocx.Magic[i] {
+			p.fail(f, "error", int64(i), "magic byte %d is 0x%02x, expected 0x%02x (%q)", i, magic[i], mdocx.Magic[i], mdocx.Magic[:])
+			break
+		}
+	}
+
+	b, f, ok := p.fixed("version", 8, 2)
+	if !ok {
+		return
+	}
+	version := binary.LittleEndian.Uint16(b)
+	f.Value = fmt.Sprintf("%d", version)
+	if version != mdocx.VersionV1 {
+		p.fail(f, "error", 8, "version is %d, expected %d", version, mdocx.VersionV1)
+	}
+
+	b, f, ok = p.fixed("header_flags", 10, 2)
+	if !ok {
+		return
+	}
+	headerFlags := binary.LittleEndian.Uint16(b)
+	f.Value = fmt.Sprintf("0x%04x %s", headerFlags, setFlagNames(decodeFlagBits(headerFlags, headerFlagNames, 0)))
+	if unknown := headerFlags &^ mdocx.HeaderFlagMetadataJSON; unknown != 0 {
+		p.fail(f, "warning", 10, "reserved flag bits 0x%04x are set; writers must leave them 0", unknown)
+	}
+
+	b, f, ok = p.fixed("fixed_header_size", 12, 4)
+	if !ok {
+		return
+	}
+	hdrSize := binary.LittleEndian.Uint32(b)
+	f.Value = fmt.Sprintf("%d", hdrSize)
+	if hdrSize != fixedHeaderSize {
+		p.fail(f, "error", 12, "fixed header size is %d, expected %d", hdrSize, fixedHeaderSize)
+	}
+
+	b, f, ok = p.fixed("metadata_length", 16, 4)
+	if !ok {
+		return
+	}
+	metaLen := int64(binary.LittleEndian.Uint32(b))
+	f.Value = fmt.Sprintf("%d", metaLen)
+	if fixedHeaderSize+metaLen > p.size {
+		p.fail(f, "error", 16, "metadata length %d runs past the end of the %d-byte file", metaLen, p.size)
+	}
+
+	b, f, ok = p.fixed("reserved", 20, 12)
+	if !ok {
+		return
+	}
+	for i, c := range b {
+		if c != 0 {
+			p.fail(f, "error", 20+int64(i), "reserved byte at offset %d is 0x%02x, must be 0", 20+i, c)
+			break
+		}
+	}
+
+	off := int64(fixedHeaderSize)
+	if metaLen > 0 {
+		f = p.field("metadata", off, metaLen, "")
+		meta := p.read(off, metaLen)
+		switch {
+		case int64(len(meta)) < metaLen:
+			f.Length = int64(len(meta))
+			p.fail(f, "error", off+int64(len(meta)), "file ends after %d of %d metadata bytes", len(meta), metaLen)
+			return
+		case headerFlags&mdocx.HeaderFlagMetadataJSON == 0:
+			p.fail(f, "error", 10, "metadata is present but the METADATA_JSON header flag is not set")
+		}
+		p.checkMetadata(f, meta)
+		off += metaLen
+	}
+
+	for i, want := range []mdocx.SectionType{mdocx.SectionMarkdown, mdocx.SectionMedia} {
+		next, ok := p.section(i, want, off)
+		if !ok {
+			return
+		}
+		off = next
+	}
+
+	if off < p.size {
+		f = p.field("trailing_data", off, p.size-off, "")
+		p.fail(f, "warning", off, "%d bytes follow the media section", p.size-off)
+	}
+}
+
+// checkMetadata annotates the metadata field with its JSON validity.
+func (p *dumper) checkMetadata(f *dumpField, meta []byte) {
+	var v any
+	err := json.Unmarshal(meta, &v)
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &syntaxErr):
+		// Offset counts the bytes read, so the offending byte is the last one.
+		p.fail(f, "error", f.Offset+max(syntaxErr.Offset-1, 0), "invalid JSON: %v", err)
+	case err != nil:
+		p.fail(f, "error", f.Offset, "invalid JSON: %v", err)
+	default:
+		m, isObject := v.(map[string]any)
+		if !isObject {
+			p.fail(f, "error", f.Offset, "metadata must be a JSON object, got %s", typeName(v))
+			return
+		}
+		f.Value = fmt.Sprintf("JSON object with %d keys", len(m))
+	}
+}
+
+// section dumps the section header at off and the payload that follows it,
+// and returns the offset just past the payload.
+func (p *dumper) section(i int, want mdocx.SectionType, off int64) (int64, bool) {
+	prefix := fmt.Sprintf("section[%d].", i)
+	name := sectionTypeName(uint16(want))
+
+	b, f, ok := p.fixed(prefix+"type", off, 2)
+	if !ok {
+		return 0, false
+	}
+	typ := binary.LittleEndian.Uint16(b)
+	f.Value = fmt.Sprintf("%d (%s)", typ, sectionTypeName(typ))
+	if mdocx.SectionType(typ) != want {
+		p.fail(f, "error", off, "section type is %d, expected %d (%s)", typ, want, name)
+	}
+
+	b, f, ok = p.fixed(prefix+"flags", off+2, 2)
+	if !ok {
+		return 0, false
+	}
+	sec := sectionInfo{Type: typ, Flags: binary.LittleEndian.Uint16(b)}
+	comp := sec.compression()
+	f.Value = fmt.Sprintf("0x%04x compression=%s %s", sec.Flags, compressionName(comp), setFlagNames(decodeFlagBits(sec.Flags, sectionFlagNames, sectionFlagCompressionMask)))
+	switch {
+	case comp > mdocx.CompBR:
+		p.fail(f, "error", off+2, "unknown compression value %d", comp)
+	case comp == mdocx.CompNone && sec.hasUncompressedLen():
+		p.fail(f, "error", off+2, "HAS_UNCOMPRESSED_LEN must be 0 with COMP_NONE")
+	case comp != mdocx.CompNone && !sec.hasUncompressedLen():
+		p.fail(f, "error", off+2, "compressed payloads must set HAS_UNCOMPRESSED_LEN")
+	default:
+		if unknown := sec.Flags &^ (sectionFlagCompressionMask | sectionFlagHasUncompressedLen); unknown != 0 {
+			p.fail(f, "warning", off+2, "reserved flag bits 0x%04x are set; writers must leave them 0", unknown)
+		}
+	}
+
+	b, f, ok = p.fixed(prefix+"payload_length", off+4, 8)
+	if !ok {
+		return 0, false
+	}
+	payloadLen := binary.LittleEndian.Uint64(b)
+	f.Value = fmt.Sprintf("%d", payloadLen)
+	payloadOff := off + sectionHeaderSize
+	// Compare before converting: a hostile length can exceed math.MaxInt64.
+	fits := payloadLen <= uint64(p.size-payloadOff)
+	if !fits {
+		p.fail(f, "error", off+4, "payload length %d runs past the end of the %d-byte file", payloadLen, p.size)
+	}
+
+	b, f, ok = p.fixed(prefix+"reserved", off+12, 4)
+	if !ok {
+		return 0, false
+	}
+	if reserved := binary.LittleEndian.Uint32(b); reserved != 0 {
+		p.fail(f, "error", off+12, "reserved is 0x%08x, must be 0", reserved)
+	}
+
+	dataOff, dataLen := payloadOff, p.size-payloadOff
+	if fits {
+		dataLen = int64(payloadLen)
+	}
+	if sec.hasUncompressedLen() {
+		b, f, ok = p.fixed(prefix+"uncompressed_length", payloadOff, 8)
+		if !ok {
+			return 0, false
+		}
+		f.Value = fmt.Sprintf("%d", binary.LittleEndian.Uint64(b))
+		dataOff += 8
+		dataLen -= 8
+	}
+	if dataLen < 0 {
+		dataLen = 0
+	}
+	f = p.field(prefix+"payload", dataOff, dataLen, fmt.Sprintf("%s bundle, %s", name, compressionName(comp)))
+	if comp == mdocx.CompZIP && !bytes.HasPrefix(p.read(dataOff, 4), []byte("PK\x03\x04")) {
+		p.fail(f, "error", dataOff, "zip payload does not start with a local file header")
+	}
+	if !fits {
+		return 0, false
+	}
+	return payloadOff + int64(payloadLen), true
+}
+
+// setFlagNames lists the names of the set bits, e.g. "[METADATA_JSON]".
+func setFlagNames(bits []flagBit) string {
+	var names []string
+	for _, b := range bits {
+		if b.Set {
+			names = append(names, b.Name)
+		}
+	}
+	return "[" + strings.Join(names, " ") + "]"
+}
+
+// writeDump prints one line per 16 bytes of each field: offset, hex, field
+// name and decoded value. Raw bytes are read from r line by line, so even
+// --bytes -1 never holds a whole payload in memory. Problems are marked with
+// "!!" and the divergence offset is repeated at the end.
+func writeDump(w io.Writer, d *containerDump, r io.ReaderAt) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "File size: %d bytes\n\n", d.FileSize)
+	var chunk [16]byte
+	for _, f := range d.Fields {
+		for line := int64(0); line == 0 || line*16 < f.shown; line++ {
+			n, err := r.ReadAt(chunk[:min(16, max(f.shown-line*16, 0))], f.Offset+line*16)
+			if err != nil && err != io.EOF {
+				return fmt.Errorf("read: %w", err)
+			}
+			hexCol := make([]string, n)
+			for i, c := range chunk[:n] {
+				hexCol[i] = fmt.Sprintf("%02x", c)
+			}
+			label := ""
+			if line == 0 {
+				label = f.Name
+				if f.Value != "" {
+					label += "  " + f.Value
+				}
+				if f.shown < f.Length {
+					label += fmt.Sprintf("  (%d bytes, %d shown)", f.Length, f.shown)
+				}
+			}
+			fmt.Fprintf(bw, "%08x  %-47s  %s\n", f.Offset+line*16, strings.Join(hexCol, " "), label)
+		}
+		if f.Problem != "" {
+			fmt.Fprintf(bw, "%8s  !! %s at offset %d (0x%x): %s\n", "", strings.ToUpper(f.Severity), f.ProblemOffset, f.ProblemOffset, f.Problem)
+		}
+	}
+	fmt.Fprintln(bw)
+	if d.Divergence != nil {
+		fmt.Fprintf(bw, "Parsing diverges from the v1 spec at offset %d (0x%x) in %s: %s\n", d.Divergence.Offset, d.Divergence.Offset, d.Divergence.Field, d.Divergence.Message)
+	} else {
+		fmt.Fprintln(bw, "Structure conforms to the v1 spec (payload contents not decoded)")
+	}
+	return bw.Flush()
+}
+
+// writeDumpJSON writes d as indented JSON, streaming each field's hex from r.
+func writeDumpJSON(w io.Writer, d *containerDump, r io.ReaderAt) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "{\n  \"file_size\": %d,\n  \"fields\": [", d.FileSize)
+	// Other string values are escaped, so the empty hex member is unique in
+	// each marshalled field.
+	const hexMember = `"hex": ""`
+	for i, f := range d.Fields {
+		if i > 0 {
+			bw.WriteString(",")
+		}
+		f.Hex = ""
+		b, err := json.MarshalIndent(f, "    ", "  ")
+		if err != nil {
+			return err
+		}
+		before, after, _ := bytes.Cut(b, []byte(hexMember))
+		bw.WriteString("\n    ")
+		bw.Write(before)
+		bw.WriteString(`"hex": "`)
+		enc := hex.NewEncoder(bw)
+		if _, err := io.Copy(enc, io.NewSectionReader(r, f.Offset, f.shown)); err != nil {
+			return fmt.Errorf("read: %w", err)
+		}
+		bw.WriteString(`"`)
+		bw.Write(after)
+	}
+	div, err := json.MarshalIndent(d.Divergence, "  ", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(bw, "\n  ],\n  \"divergence\": %s\n}\n", div)
+	return bw.Flush()
+}
+
+func init() {
+	rootCmd.AddCommand(dumpCmd)
+
+	dumpCmd.Flags().Bool("json", false, "output machine-readable JSON")
+	dumpCmd.Flags().Int("bytes", 64, "raw bytes to show per variable-length field (-1 for all)")
+}
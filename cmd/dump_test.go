@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// dumpTestFile writes the standard test container, applies corrupt to its
+// bytes and returns the path.
+func dumpTestFile(t *testing.T, corrupt func(b []byte) []byte) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "test.mdocx")
+	createTestMDOCX(t, p, map[string]any{"title": "T"})
+	if corrupt == nil {
+		return p
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, corrupt(b), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func dumpFile(t *testing.T, p string) *containerDump {
+	t.Helper()
+	f, err := os.Open(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	st, _ := f.Stat()
+	return dumpContainer(f, st.Size(), 64)
+}
+
+func TestDumpValidContainer(t *testing.T) {
+	d := dumpFile(t, dumpTestFile(t, nil))
+	if d.Divergence != nil {
+		t.Fatalf("unexpected divergence: %+v", d.Divergence)
+	}
+	var names []string
+	end := int64(0)
+	for _, f := range d.Fields {
+		names = append(names, f.Name)
+		if f.Offset != end {
+			t.Errorf("field %s starts at %d, previous field ended at %d", f.Name, f.Offset, end)
+		}
+		end = f.Offset + f.Length
+	}
+	if end != d.FileSize {
+		t.Errorf("fields cover %d of %d bytes", end, d.FileSize)
+	}
+	want := "magic version header_flags fixed_header_size metadata_length reserved metadata " +
+		"section[0].type section[0].flags section[0].payload_length section[0].reserved section[0].payload " +
+		"section[1].type section[1].flags section[1].payload_length section[1].reserved section[1].payload"
+	if got := strings.Join(names, " "); got != want {
+		t.Errorf("fields = %s", got)
+	}
+}
+
+func TestDumpDivergenceOffsets(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(b []byte) []byte
+		offset  int64
+		field   string
+	}{
+		{"magic", func(b []byte) []byte { b[3] = 'X'; return b }, 3, "magic"},
+		{"version", func(b []byte) []byte { b[8] = 2; return b }, 8, "version"},
+		{"reserved", func(b []byte) []byte { b[27] = 1; return b }, 27, "reserved"},
+		{"metadata json", func(b []byte) []byte { b[32+12] = ']'; return b }, 44, "metadata"},
+		{"section type", func(b []byte) []byte { b[45] = 9; return b }, 45, "section[0].type"},
+		{"truncated", func(b []byte) []byte { return b[:len(b)-2] }, -1, "section[1].payload_length"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := dumpFile(t, dumpTestFile(t, tt.corrupt))
+			if d.Divergence == nil {
+				t.Fatal("expected a divergence")
+			}
+			if tt.offset >= 0 && d.Divergence.Offset != tt.offset {
+				t.Errorf("divergence at %d, want %d (%s)", d.Divergence.Offset, tt.offset, d.Divergence.Message)
+			}
+			if d.Divergence.Field != tt.field {
+				t.Errorf("divergence in %s, want %s", d.Divergence.Field, tt.field)
+			}
+		})
+	}
+}
+
+func TestDumpReservedFlagBitsWarn(t *testing.T) {
+	d := dumpFile(t, dumpTestFile(t, func(b []byte) []byte { b[11] = 0x80; return b }))
+	if d.Divergence != nil {
+		t.Fatalf("reserved flag bits should not be a divergence: %+v", d.Divergence)
+	}
+	if f := d.Fields[2]; f.Severity != "warning" || !strings.Contains(f.Problem, "0x8000") {
+		t.Errorf("expected a warning on header_flags, got %+v", f)
+	}
+}
+
+func TestDumpHugePayloadLength(t *testing.T) {
+	// A length above math.MaxInt64 must not wrap into a bogus next offset.
+	d := dumpFile(t, dumpTestFile(t, func(b []byte) []byte {
+		binary.LittleEndian.PutUint64(b[48:], 0xFFFFFFFFFFFFFFF0)
+		return b
+	}))
+	if d.Divergence == nil || d.Divergence.Field != "section[0].payload_length" {
+		t.Fatalf("unexpected divergence: %+v", d.Divergence)
+	}
+	last := d.Fields[len(d.Fields)-1]
+	if last.Name != "section[0].payload" || last.Offset+last.Length != d.FileSize {
+		t.Errorf("payload should be cut at the end of the file, got %+v", last)
+	}
+}
+
+func TestDumpCommand_AllBytes(t *testing.T) {
+	p := dumpTestFile(t, nil)
+	raw, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := executeCommand(rootCmd, "dump", "--json", "--bytes", "-1", p)
+	if err != nil {
+		t.Fatalf("dump failed: %v\n%s", err, out)
+	}
+	var d containerDump
+	if err := json.Unmarshal([]byte(out), &d); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	for _, f := range d.Fields {
+		if want := hex.EncodeToString(raw[f.Offset : f.Offset+f.Length]); f.Hex != want {
+			t.Errorf("%s hex = %q, want %q", f.Name, f.Hex, want)
+		}
+	}
+}
+
+func TestDumpCommand(t *testing.T) {
+	p := dumpTestFile(t, nil)
+	out, err := executeCommand(rootCmd, "dump", p)
+	if err != nil {
+		t.Fatalf("dump failed: %v\n%s", err, out)
+	}
+	for _, want := range []string{"00000000  4d 44 4f 43 58 0d 0a 1a", "metadata_length", "section[1].payload", "conforms to the v1 spec"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	bad := dumpTestFile(t, func(b []byte) []byte { b[20] = 7; return b })
+	out, err = executeCommand(rootCmd, "dump", "--json", bad)
+	if err == nil {
+		t.Fatal("expected an error for a non-conforming container")
+	}
+	var d containerDump
+	if jerr := json.Unmarshal([]byte(out[:strings.LastIndex(out, "}")+1]), &d); jerr != nil {
+		t.Fatalf("invalid JSON: %v\n%s", jerr, out)
+	}
+	if d.Divergence == nil || d.Divergence.Offset != 20 || d.Divergence.Field != "reserved" {
+		t.Errorf("unexpected divergence: %+v", d.Divergence)
+	}
+}
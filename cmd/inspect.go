@@ -6,8 +6,10 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
-	"os"
+	"io"
+	"slices"
 	"sort"
+	"strings"
 
 	"github.com/logicossoftware/go-mdocx"
 	"github.com/spf13/cobra"
@@ -15,51 +17,332 @@ import (
 
 // inspectCmd represents the inspect command
 var inspectCmd = &cobra.Command{
-	Use:   "inspect <file>",
+	Use:   "inspect <file>...",
 	Short: "Inspect an .mdocx bundle without extracting",
-	Args:  cobra.ExactArgs(1),
+	Args:  cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		jsonOut, _ := cmd.Flags().GetBool("json")
-		input := args[0]
+		opts, err := inspectOptionsFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+		recursive, _ := cmd.Flags().GetBool("recursive")
+		jobs, _ := cmd.Flags().GetInt("jobs")
+		inputs, batch, err := expandInputs(args, recursive)
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		if !batch {
+			summary, err := inspectFile(inputs[0], opts)
+			if err != nil {
+				return err
+			}
+			return writeInspectOutput(out, summary, opts)
+		}
+
+		summaries := runBatch(inputs, jobs, func(input string) inspectSummary {
+			s, err := inspectFile(input, opts)
+			s.File = input
+			if err != nil {
+				s.Error = err.Error()
+			}
+			return s
+		})
+		return writeInspectBatch(out, cmd.ErrOrStderr(), summaries, opts)
+	},
+}
+
+// inspectOptions is the parsed form of the inspect flags.
+type inspectOptions struct {
+	format   string // table, csv, ndjson or json
+	long     bool
+	stats    bool
+	tree     bool
+	treeMode string
+	depth    int
+	layout   bool
+	only     string // "", "metadata" or "markdown"
+	salvage  bool
+	query    string
+	template string
+	limits   decodeLimits
+}
+
+// scripted reports whether output goes through --query or --template.
+func (o inspectOptions) scripted() bool { return o.query != "" || o.template != "" }
+
+func inspectOptionsFromFlags(cmd *cobra.Command) (inspectOptions, error) {
+	var o inspectOptions
+	jsonOut, _ := cmd.Flags().GetBool("json")
+	o.long, _ = cmd.Flags().GetBool("long")
+	o.stats, _ = cmd.Flags().GetBool("stats")
+	o.format, _ = cmd.Flags().GetString("format")
+	o.tree, _ = cmd.Flags().GetBool("tree")
+	o.treeMode, _ = cmd.Flags().GetString("tree-mode")
+	o.depth, _ = cmd.Flags().GetInt("depth")
+	o.layout, _ = cmd.Flags().GetBool("layout")
+	o.only, _ = cmd.Flags().GetString("only")
+	o.salvage, _ = cmd.Flags().GetBool("salvage")
+	o.query, _ = cmd.Flags().GetString("query")
+	o.template, _ = cmd.Flags().GetString("template")
+	limits, err := limitsFromFlags(cmd)
+	if err != nil {
+		return o, err
+	}
+	o.limits = limits
+
+	o.format = strings.ToLower(strings.TrimSpace(o.format))
+	if o.format == "" {
+		o.format = "table"
+		if jsonOut {
+			o.format = "json"
+		}
+	} else if jsonOut && o.format != "json" {
+		return o, fmt.Errorf("--json conflicts with --format %s", o.format)
+	}
+	if !slices.Contains(inspectFormats, o.format) {
+		return o, fmt.Errorf("unknown format: %s (want %s)", o.format, strings.Join(inspectFormats, "|"))
+	}
+	if o.query != "" && o.template != "" {
+		return o, fmt.Errorf("--query and --template cannot be used together")
+	}
+	if cmd.Flags().Changed("format") && o.scripted() {
+		return o, fmt.Errorf("--format cannot be combined with --query or --template")
+	}
+	if o.format == "csv" || o.format == "ndjson" {
+		if o.tree {
+			return o, fmt.Errorf("--tree is not supported with --format %s", o.format)
+		}
+		if o.layout {
+			return o, fmt.Errorf("--layout is not supported with --format %s", o.format)
+		}
+		if o.stats {
+			return o, fmt.Errorf("--stats is not supported with --format %s", o.format)
+		}
+	}
+
+	o.only = strings.ToLower(strings.TrimSpace(o.only))
+	if o.only != "" && o.only != "metadata" && o.only != "markdown" {
+		return o, fmt.Errorf("unknown --only value: %s (want metadata|markdown)", o.only)
+	}
+	if o.salvage && (o.only != "" || o.layout) {
+		return o, fmt.Errorf("--salvage cannot be combined with --only or --layout")
+	}
+	o.treeMode = strings.ToLower(strings.TrimSpace(o.treeMode))
+	return o, nil
+}
+
+// inspectFile builds the summary of the container at input that the
+// options ask for.
+func inspectFile(input string, o inspectOptions) (inspectSummary, error) {
+	// Decode no more of the container than the output needs: nothing for
+	// the layout alone, and no media section for --only.
+	var doc *mdocx.Document
+	var report *salvageReport
+	var err error
+	switch {
+	case o.salvage:
+		doc, report, err = salvageContainer(input, true, o.limits)
+	case o.layout && !o.long && !o.tree && !o.stats && !o.scripted() && o.format == "table":
+	case o.only != "":
+		doc, err = decodeContainerPartial(input, o.only == "markdown", o.limits)
+	default:
+		doc, err = decodeContainerFile(input, true, o.limits)
+	}
+	if err != nil {
+		return inspectSummary{}, err
+	}
 
-		f, err := os.Open(input)
+	header, _ := readHeaderInfo(input)
+	summary := inspectSummary{Header: header}
+	if doc != nil {
+		summary = buildInspectSummary(doc, header)
+		summary.Only = o.only
+		summary.Salvage = report
+		// csv and ndjson are per-entry formats, so they always list entries.
+		if o.long || o.scripted() || o.format == "csv" || o.format == "ndjson" {
+			addInspectDetails(&summary, doc)
+		}
+		if o.scripted() {
+			summary.Metadata = doc.Metadata
+		}
+		if o.stats {
+			summary.Stats = buildContentStats(doc)
+		}
+	}
+	if o.layout {
+		layout, err := readContainerLayout(input)
+		if err != nil {
+			return inspectSummary{}, fmt.Errorf("layout: %w", err)
+		}
+		var headerFlags uint16
+		if header != nil {
+			headerFlags = header.HeaderFlags
+		}
+		summary.Layout = buildLayoutReport(layout, headerFlags)
+	}
+	if o.tree {
+		summary.Tree, err = buildContentTrees(doc, o.treeMode)
 		if err != nil {
-			return fmt.Errorf("open: %w", err)
+			return inspectSummary{}, err
 		}
-		defer f.Close()
+	}
+	return summary, nil
+}
 
-		doc, err := mdocx.Decode(f)
+// writeInspectOutput renders one summary in the requested form.
+func writeInspectOutput(out io.Writer, summary inspectSummary, o inspectOptions) error {
+	if o.scripted() {
+		data, err := queryData(summary)
 		if err != nil {
-			return fmt.Errorf("decode: %w", err)
+			return err
+		}
+		if o.query != "" {
+			return writeQueryResults(out, o.query, data)
+		}
+		return executeTemplate(out, o.template, data)
+	}
+	switch o.format {
+	case "json":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(summary)
+	case "csv":
+		return writeDetailCSV(out, []inspectSummary{summary})
+	case "ndjson":
+		return writeDetailNDJSON(out, summary)
+	}
+
+	switch {
+	case o.layout:
+		if err := writeLayoutReport(out, summary.Layout); err != nil {
+			return err
+		}
+		if o.tree {
+			fmt.Fprintln(out)
+			writeTree(out, summary.Tree, o.depth)
+		}
+	case o.tree:
+		writeTree(out, summary.Tree, o.depth)
+	default:
+		writeInspectText(out, summary)
+	}
+	if o.long {
+		if err := writeDetailTable(out, summary); err != nil {
+			return err
+		}
+	}
+	if o.stats && summary.Stats != nil {
+		if err := writeContentStats(out, summary.Stats); err != nil {
+			return err
 		}
+	}
+	if summary.Salvage != nil {
+		writeSalvageReport(out, summary.Salvage)
+	}
+	return nil
+}
 
-		header, _ := readHeaderInfo(input)
-		summary := buildInspectSummary(doc, header)
+// inspectBatchResult is the JSON output of inspect over several files.
+type inspectBatchResult struct {
+	Files   []inspectSummary `json:"files"`
+	Summary batchSummary     `json:"summary"`
+}
 
-		if jsonOut {
-			enc := json.NewEncoder(cmd.OutOrStdout())
-			enc.SetIndent("", "  ")
-			return enc.Encode(summary)
+// writeInspectBatch renders the summaries of several files followed by
+// their aggregate, and returns an error if any file failed. Text output
+// gives each file a "==> file <==" heading; csv and ndjson rows carry the
+// file name; json wraps everything in one document. Formats with no place
+// for errors report failed files on errOut.
+func writeInspectBatch(out, errOut io.Writer, summaries []inspectSummary, o inspectOptions) error {
+	var agg batchSummary
+	var ok []inspectSummary
+	reportErrors := o.scripted() || o.format == "csv" || o.format == "ndjson"
+	for _, s := range summaries {
+		agg.add(fileSize(s.File), s.Error == "", len(s.MarkdownFiles), len(s.MediaIDs), s.TotalMarkdownBytes, s.TotalMediaBytes)
+		if s.Error == "" {
+			ok = append(ok, s)
+		} else if reportErrors {
+			fmt.Fprintf(errOut, "ERROR: %s: %s\n", s.File, s.Error)
+		}
+	}
+
+	switch {
+	case o.scripted():
+		for _, s := range ok {
+			if err := writeInspectOutput(out, s, o); err != nil {
+				return fmt.Errorf("%s: %w", s.File, err)
+			}
+		}
+	case o.format == "json":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(inspectBatchResult{Files: summaries, Summary: agg}); err != nil {
+			return err
+		}
+	case o.format == "csv":
+		if err := writeDetailCSV(out, ok); err != nil {
+			return err
+		}
+	case o.format == "ndjson":
+		for _, s := range ok {
+			if err := writeDetailNDJSON(out, s); err != nil {
+				return err
+			}
+		}
+	default:
+		for i, s := range summaries {
+			if i > 0 {
+				fmt.Fprintln(out)
+			}
+			fmt.Fprintf(out, "==> %s <==\n", s.File)
+			if s.Error != "" {
+				fmt.Fprintf(out, "ERROR: %s\n", s.Error)
+				continue
+			}
+			if err := writeInspectOutput(out, s, o); err != nil {
+				return err
+			}
 		}
+		fmt.Fprintln(out)
+		agg.write(out, "ok", "failed")
+	}
+	return agg.err("inspect")
+}
 
-		fmt.Fprintf(cmd.OutOrStdout(), "Metadata keys: %v\n", summary.MetadataKeys)
-		fmt.Fprintf(cmd.OutOrStdout(), "Markdown files (%d, %s): %v\n", len(summary.MarkdownFiles), humanSize(summary.TotalMarkdownBytes), summary.MarkdownFiles)
+// writeInspectText prints the summary, leaving out sections that --only
+// skipped.
+func writeInspectText(w io.Writer, summary inspectSummary) {
+	fmt.Fprintf(w, "Metadata keys: %v\n", summary.MetadataKeys)
+	if summary.Only != "metadata" {
+		fmt.Fprintf(w, "Markdown files (%d, %s): %v\n", len(summary.MarkdownFiles), humanSize(summary.TotalMarkdownBytes), summary.MarkdownFiles)
 		if summary.RootPath != "" {
-			fmt.Fprintf(cmd.OutOrStdout(), "Root path: %s\n", summary.RootPath)
+			fmt.Fprintf(w, "Root path: %s\n", summary.RootPath)
 		}
-		fmt.Fprintf(cmd.OutOrStdout(), "Media IDs (%d, %s): %v\n", len(summary.MediaIDs), humanSize(summary.TotalMediaBytes), summary.MediaIDs)
+	}
+	switch summary.Only {
+	case "":
+		fmt.Fprintf(w, "Media IDs (%d, %s): %v\n", len(summary.MediaIDs), humanSize(summary.TotalMediaBytes), summary.MediaIDs)
 		if len(summary.MediaPaths) > 0 {
-			fmt.Fprintf(cmd.OutOrStdout(), "Media paths: %v\n", summary.MediaPaths)
-		}
-		fmt.Fprintf(cmd.OutOrStdout(), "Bundle versions: markdown=%d media=%d\n", summary.MarkdownBundleVersion, summary.MediaBundleVersion)
-		if header != nil {
-			fmt.Fprintf(cmd.OutOrStdout(), "Header: version=%d flags=0x%04x metadata_len=%d\n", header.Version, header.HeaderFlags, header.MetadataLength)
+			fmt.Fprintf(w, "Media paths: %v\n", summary.MediaPaths)
 		}
-		return nil
-	},
+		fmt.Fprintf(w, "Bundle versions: markdown=%d media=%d\n", summary.MarkdownBundleVersion, summary.MediaBundleVersion)
+	case "markdown":
+		fmt.Fprintf(w, "Bundle versions: markdown=%d\n", summary.MarkdownBundleVersion)
+	}
+	if header := summary.Header; header != nil {
+		fmt.Fprintf(w, "Header: version=%d flags=0x%04x metadata_len=%d\n", header.Version, header.HeaderFlags, header.MetadataLength)
+	}
 }
 
 type inspectSummary struct {
+	// File and Error are set when inspecting several files at once.
+	File  string `json:"file,omitempty"`
+	Error string `json:"error,omitempty"`
+
+	// Only names the one part decoded by --only; the rest is left empty.
+	Only                  string      `json:"only,omitempty"`
 	Header                *headerInfo `json:"header,omitempty"`
 	MarkdownBundleVersion uint16      `json:"markdown_bundle_version"`
 	MediaBundleVersion    uint16      `json:"media_bundle_version"`
@@ -70,6 +353,25 @@ type inspectSummary struct {
 	MediaIDs              []string    `json:"media_ids"`
 	MediaPaths            []string    `json:"media_paths"`
 	TotalMediaBytes       int         `json:"total_media_bytes"`
+
+	// Per-entry listings, filled in for --long and the per-entry formats.
+	Markdown []markdownDetail `json:"markdown,omitempty"`
+	Media    []mediaDetail    `json:"media,omitempty"`
+
+	// Tree is the container's directory hierarchy, filled in for --tree.
+	Tree []*treeNode `json:"tree,omitempty"`
+
+	// Stats are markdown content metrics, filled in for --stats.
+	Stats *contentStats `json:"stats,omitempty"`
+
+	// Metadata holds the metadata values for --query and --template.
+	Metadata map[string]any `json:"metadata,omitempty"`
+
+	// Layout is the container's byte layout, filled in for --layout.
+	Layout *layoutReport `json:"layout,omitempty"`
+
+	// Salvage says what was recovered and lost, filled in for --salvage.
+	Salvage *salvageReport `json:"salvage,omitempty"`
 }
 
 func buildInspectSummary(doc *mdocx.Document, header *headerInfo) inspectSummary {
@@ -107,5 +409,18 @@ func buildInspectSummary(doc *mdocx.Document, header *headerInfo) inspectSummary
 func init() {
 	rootCmd.AddCommand(inspectCmd)
 
-	inspectCmd.Flags().Bool("json", false, "output machine-readable JSON")
+	inspectCmd.Flags().Bool("json", false, "output machine-readable JSON (same as --format json)")
+	inspectCmd.Flags().BoolP("long", "l", false, "list each markdown file and media item with sizes, hashes and dimensions")
+	inspectCmd.Flags().String("format", "", "output format (table|csv|ndjson|json)")
+	inspectCmd.Flags().Bool("stats", false, "show word counts, reading time, headings, links, images, code blocks and tables")
+	inspectCmd.Flags().Bool("tree", false, "show the container's directory hierarchy with per-directory totals")
+	inspectCmd.Flags().String("tree-mode", "merged", "show markdown and media in one tree or two (merged|separate)")
+	inspectCmd.Flags().String("only", "", "decode only the metadata, or metadata and markdown, skipping the media section (metadata|markdown)")
+	inspectCmd.Flags().String("query", "", "print the values selected by a jq-style expression over the JSON output, e.g. .metadata.title")
+	inspectCmd.Flags().String("template", "", "format the JSON output with a Go text/template, e.g. '{{.root_path}}'")
+	inspectCmd.Flags().Bool("layout", false, "show section offsets, sizes, compression and decoded flag bits")
+	inspectCmd.Flags().Bool("salvage", false, "decode each part of a damaged bundle independently and report what was lost")
+	inspectCmd.Flags().Int("depth", 0, "limit --tree to this many directory levels (0 for no limit)")
+	addLimitFlags(inspectCmd)
+	addBatchFlags(inspectCmd)
 }
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"math/bits"
+	"text/tabwriter"
+
+	"github.com/logicossoftware/go-mdocx"
+)
+
+// flagBit is one named bit of a flags field and whether it is set.
+type flagBit struct {
+	Bit  int    `json:"bit"`
+	Name string `json:"name"`
+	Set  bool   `json:"set"`
+}
+
+// layoutRegion is a contiguous byte range of the container. For sections,
+// Length covers the on-disk payload (including any uncompressed length
+// prefix) and Ratio is Length divided by UncompressedLength.
+type layoutRegion struct {
+	Name               string    `json:"name"`
+	Offset             int64     `json:"offset"`
+	HeaderLength       int64     `json:"header_length,omitempty"`
+	Length             uint64    `json:"length"`
+	UncompressedLength uint64    `json:"uncompressed_length,omitempty"`
+	Compression        string    `json:"compression,omitempty"`
+	Ratio              float64   `json:"ratio,omitempty"`
+	Flags              uint16    `json:"flags,omitempty"`
+	FlagBits           []flagBit `json:"flag_bits,omitempty"`
+}
+
+// layoutReport is what inspect --layout shows.
+type layoutReport struct {
+	FileSize       int64          `json:"file_size"`
+	HeaderFlags    uint16         `json:"header_flags"`
+	HeaderFlagBits []flagBit      `json:"header_flag_bits"`
+	Regions        []layoutRegion `json:"regions"`
+	TrailingBytes  int64          `json:"trailing_bytes,omitempty"`
+}
+
+// headerFlagNames names the header flag bits, keyed by bit index.
+var headerFlagNames = map[int]string{
+	bits.TrailingZeros16(uint16(mdocx.HeaderFlagMetadataJSON)): "METADATA_JSON",
+}
+
+// sectionFlagNames names the single-bit section flags; bits 0-3 hold the
+// compression algorithm and are reported separately.
+var sectionFlagNames = map[int]string{
+	bits.TrailingZeros16(sectionFlagHasUncompressedLen): "HAS_UNCOMPRESSED_LEN",
+}
+
+// decodeFlagBits lists every named bit of flags, set or not, followed by any
+// set bit that has no name.
+func decodeFlagBits(flags uint16, names map[int]string, skip uint16) []flagBit {
+	var bits []flagBit
+	for bit := 0; bit < 16; bit++ {
+		mask := uint16(1) << bit
+		if skip&mask != 0 {
+			continue
+		}
+		name, known := names[bit]
+		set := flags&mask != 0
+		if !known && !set {
+			continue
+		}
+		if !known {
+			name = fmt.Sprintf("UNKNOWN_BIT_%d", bit)
+		}
+		bits = append(bits, flagBit{Bit: bit, Name: name, Set: set})
+	}
+	return bits
+}
+
+func sectionTypeName(t uint16) string {
+	switch mdocx.SectionType(t) {
+	case mdocx.SectionMarkdown:
+		return "markdown"
+	case mdocx.SectionMedia:
+		return "media"
+	default:
+		return fmt.Sprintf("section type %d", t)
+	}
+}
+
+// buildLayoutReport describes the regions of layout in file order.
+func buildLayoutReport(layout *containerLayout, headerFlags uint16) *layoutReport {
+	r := &layoutReport{
+		FileSize:       layout.FileSize,
+		HeaderFlags:    headerFlags,
+		HeaderFlagBits: decodeFlagBits(headerFlags, headerFlagNames, 0),
+	}
+	r.Regions = append(r.Regions,
+		layoutRegion{Name: "header", Offset: 0, Length: fixedHeaderSize},
+		layoutRegion{Name: "metadata", Offset: layout.MetadataOffset, Length: uint64(layout.MetadataLength)},
+	)
+	end := layout.MetadataOffset + int64(layout.MetadataLength)
+	for _, sec := range layout.Sections {
+		region := layoutRegion{
+			Name:               sectionTypeName(sec.Type),
+			Offset:             sec.Offset,
+			HeaderLength:       sectionHeaderSize,
+			Length:             sec.PayloadLen,
+			UncompressedLength: sec.UncompressedLen,
+			Compression:        compressionName(sec.compression()),
+			Flags:              sec.Flags,
+			FlagBits:           decodeFlagBits(sec.Flags, sectionFlagNames, sectionFlagCompressionMask),
+		}
+		if sec.UncompressedLen > 0 {
+			region.Ratio = float64(sec.PayloadLen) / float64(sec.UncompressedLen)
+		}
+		r.Regions = append(r.Regions, region)
+		end = sec.payloadEnd()
+	}
+	if layout.FileSize > end {
+		r.TrailingBytes = layout.FileSize - end
+	}
+	return r
+}
+
+// writeLayoutReport prints r as a region table followed by the flag bits.
+func writeLayoutReport(w io.Writer, r *layoutReport) error {
+	fmt.Fprintf(w, "File size: %d bytes (%s)\n\n", r.FileSize, humanSize(int(r.FileSize)))
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "REGION\tOFFSET\tLENGTH\tUNCOMPRESSED\tCOMPRESSION\tRATIO")
+	for _, reg := range r.Regions {
+		uncompressed, compression, ratio := "-", "-", "-"
+		if reg.Compression != "" {
+			uncompressed = fmt.Sprintf("%d", reg.UncompressedLength)
+			compression = reg.Compression
+			if reg.UncompressedLength > 0 {
+				ratio = fmt.Sprintf("%.1f%%", reg.Ratio*100)
+			}
+		}
+		length := fmt.Sprintf("%d", reg.Length)
+		if reg.HeaderLength > 0 {
+			length = fmt.Sprintf("%d+%d", reg.HeaderLength, reg.Length)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\n", reg.Name, reg.Offset, length, uncompressed, compression, ratio)
+	}
+	if r.TrailingBytes > 0 {
+		fmt.Fprintf(tw, "trailing\t%d\t%d\t-\t-\t-\n", r.FileSize-r.TrailingBytes, r.TrailingBytes)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\nHeader flags: 0x%04x\n", r.HeaderFlags)
+	writeFlagBits(w, r.HeaderFlagBits)
+	for _, reg := range r.Regions {
+		if reg.Compression == "" {
+			continue
+		}
+		fmt.Fprintf(w, "Section %s flags: 0x%04x (compression=%s)\n", reg.Name, reg.Flags, reg.Compression)
+		writeFlagBits(w, reg.FlagBits)
+	}
+	return nil
+}
+
+func writeFlagBits(w io.Writer, bits []flagBit) {
+	for _, b := range bits {
+		state := "unset"
+		if b.Set {
+			state = "set"
+		}
+		fmt.Fprintf(w, "  bit %-2d %-22s %s\n", b.Bit, b.Name, state)
+	}
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/logicossoftware/go-mdocx"
+)
+
+func TestDecodeFlagBits(t *testing.T) {
+	bits := decodeFlagBits(0x0001|0x0100, headerFlagNames, 0)
+	if len(bits) != 2 {
+		t.Fatalf("expected named bit plus unknown bit, got %+v", bits)
+	}
+	if bits[0] != (flagBit{Bit: 0, Name: "METADATA_JSON", Set: true}) {
+		t.Errorf("unexpected bit 0: %+v", bits[0])
+	}
+	if bits[1] != (flagBit{Bit: 8, Name: "UNKNOWN_BIT_8", Set: true}) {
+		t.Errorf("unexpected bit 8: %+v", bits[1])
+	}
+
+	bits = decodeFlagBits(0x0012, sectionFlagNames, sectionFlagCompressionMask)
+	if len(bits) != 1 || bits[0].Name != "HAS_UNCOMPRESSED_LEN" || !bits[0].Set {
+		t.Errorf("compression bits should be skipped, got %+v", bits)
+	}
+}
+
+func TestBuildLayoutReport(t *testing.T) {
+	tmp := t.TempDir()
+	p := filepath.Join(tmp, "test.mdocx")
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := limitTestDoc()
+	doc.Metadata = map[string]any{"title": "T"}
+	if err := mdocx.Encode(f, doc, mdocx.WithMarkdownCompression(mdocx.CompZSTD), mdocx.WithMediaCompression(mdocx.CompNone)); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	layout, err := readContainerLayout(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := buildLayoutReport(layout, mdocx.HeaderFlagMetadataJSON)
+	if len(r.Regions) != 4 {
+		t.Fatalf("expected header, metadata and two sections, got %+v", r.Regions)
+	}
+	md, media := r.Regions[2], r.Regions[3]
+	if md.Name != "markdown" || md.Compression != "zstd" || md.UncompressedLength == 0 || md.Ratio == 0 {
+		t.Errorf("unexpected markdown region: %+v", md)
+	}
+	if media.Name != "media" || media.Compression != "none" {
+		t.Errorf("unexpected media region: %+v", media)
+	}
+	if md.Offset != r.Regions[1].Offset+int64(r.Regions[1].Length) {
+		t.Errorf("markdown section should follow metadata: %+v", r.Regions)
+	}
+	if !r.HeaderFlagBits[0].Set || r.TrailingBytes != 0 {
+		t.Errorf("unexpected report: %+v", r)
+	}
+
+	// Bytes past the last section are reported.
+	fa, _ := os.OpenFile(p, os.O_APPEND|os.O_WRONLY, 0)
+	fa.Write([]byte("junk"))
+	fa.Close()
+	layout, err = readContainerLayout(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := buildLayoutReport(layout, 0); r.TrailingBytes != 4 {
+		t.Errorf("expected 4 trailing bytes, got %d", r.TrailingBytes)
+	}
+}
+
+func TestInspectCommand_Layout(t *testing.T) {
+	tmp := t.TempDir()
+	p := createTestMDOCX(t, filepath.Join(tmp, "test.mdocx"), map[string]any{"title": "T"})
+
+	out, err := executeCommand(rootCmd, "inspect", "--layout", p)
+	if err != nil {
+		t.Fatalf("inspect --layout failed: %v", err)
+	}
+	for _, want := range []string{"REGION", "metadata  32", "markdown", "media", "none", "Header flags: 0x0001", "METADATA_JSON", "set"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+
+	out, err = executeCommand(rootCmd, "inspect", "--layout", "--json", p)
+	if err != nil {
+		t.Fatalf("inspect --layout --json failed: %v", err)
+	}
+	var summary inspectSummary
+	if err := json.Unmarshal([]byte(out), &summary); err != nil {
+		t.Fatalf("parse JSON: %v", err)
+	}
+	if summary.Layout == nil || len(summary.Layout.Regions) != 4 {
+		t.Errorf("expected layout in JSON, got %+v", summary.Layout)
+	}
+}
+
+func TestFlagNames_MatchLibraryBits(t *testing.T) {
+	bits := decodeFlagBits(uint16(mdocx.HeaderFlagMetadataJSON), headerFlagNames, 0)
+	if len(bits) != 1 || bits[0].Name != "METADATA_JSON" || !bits[0].Set {
+		t.Errorf("unexpected header flag bits: %+v", bits)
+	}
+	bits = decodeFlagBits(sectionFlagHasUncompressedLen, sectionFlagNames, sectionFlagCompressionMask)
+	if len(bits) != 1 || bits[0].Name != "HAS_UNCOMPRESSED_LEN" || !bits[0].Set {
+		t.Errorf("unexpected section flag bits: %+v", bits)
+	}
+}
@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/logicossoftware/go-mdocx"
+)
+
+// markdownDetail is the per-file listing shown by inspect --long.
+type markdownDetail struct {
+	Path  string `json:"path"`
+	Size  int    `json:"size"`
+	Lines int    `json:"lines"`
+	Words int    `json:"words"`
+	Root  bool   `json:"root,omitempty"`
+}
+
+// mediaDetail is the per-item listing shown by inspect --long. Width and
+// Height are set for images whose format can be decoded.
+type mediaDetail struct {
+	ID     string `json:"id"`
+	Path   string `json:"path,omitempty"`
+	MIME   string `json:"mime,omitempty"`
+	Size   int    `json:"size"`
+	SHA256 string `json:"sha256"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+}
+
+// inspectFormats are the values accepted by inspect --format.
+var inspectFormats = []string{"table", "csv", "ndjson", "json"}
+
+// addInspectDetails fills the per-entry listings of s from doc, markdown
+// sorted by path and media by ID.
+func addInspectDetails(s *inspectSummary, doc *mdocx.Document) {
+	s.Markdown = make([]markdownDetail, 0, len(doc.Markdown.Files))
+	for _, mf := range doc.Markdown.Files {
+		s.Markdown = append(s.Markdown, markdownDetail{
+			Path:  mf.Path,
+			Size:  len(mf.Content),
+			Lines: countLines(mf.Content),
+			Words: len(strings.Fields(string(mf.Content))),
+			Root:  mf.Path == doc.Markdown.RootPath,
+		})
+	}
+	sort.SliceStable(s.Markdown, func(i, j int) bool { return s.Markdown[i].Path < s.Markdown[j].Path })
+
+	s.Media = make([]mediaDetail, 0, len(doc.Media.Items))
+	for _, mi := range doc.Media.Items {
+		sum := sha256.Sum256(mi.Data)
+		d := mediaDetail{
+			ID:     mi.ID,
+			Path:   mi.Path,
+			MIME:   mi.MIMEType,
+			Size:   len(mi.Data),
+			SHA256: hex.EncodeToString(sum[:]),
+		}
+		if cfg, _, err := image.DecodeConfig(bytes.NewReader(mi.Data)); err == nil {
+			d.Width, d.Height = cfg.Width, cfg.Height
+		}
+		s.Media = append(s.Media, d)
+	}
+	sort.SliceStable(s.Media, func(i, j int) bool { return s.Media[i].ID < s.Media[j].ID })
+}
+
+// countLines counts lines the way editors do: a trailing newline does not
+// start another line.
+func countLines(b []byte) int {
+	if len(b) == 0 {
+		return 0
+	}
+	n := bytes.Count(b, []byte("\n"))
+	if b[len(b)-1] != '\n' {
+		n++
+	}
+	return n
+}
+
+func (d mediaDetail) dimensions() string {
+	if d.Width == 0 && d.Height == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%dx%d", d.Width, d.Height)
+}
+
+// writeDetailTable prints the per-entry listings as aligned columns.
+func writeDetailTable(w io.Writer, s inspectSummary) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "\nMARKDOWN\tSIZE\tLINES\tWORDS")
+	for _, d := range s.Markdown {
+		p := d.Path
+		if d.Root {
+			p += " (root)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\n", p, humanSize(d.Size), d.Lines, d.Words)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(s.Media) == 0 {
+		return nil
+	}
+	fmt.Fprintln(tw, "\nMEDIA ID\tPATH\tMIME\tSIZE\tDIMENSIONS\tSHA256")
+	for _, d := range s.Media {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", d.ID, orDash(d.Path), orDash(d.MIME), humanSize(d.Size), orDash(d.dimensions()), d.SHA256)
+	}
+	return tw.Flush()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// writeDetailCSV writes one row per markdown file and media item of each
+// summary. Columns that don't apply to an entry's kind are left empty. When
+// there are several summaries, a leading file column names the container.
+func writeDetailCSV(w io.Writer, summaries []inspectSummary) error {
+	cw := csv.NewWriter(w)
+	withFile := len(summaries) > 1
+	row := func(file string, cols ...string) {
+		if withFile {
+			cols = append([]string{file}, cols...)
+		}
+		_ = cw.Write(cols)
+	}
+	row("file", "kind", "path", "id", "mime", "size", "lines", "words", "sha256", "width", "height")
+	for _, s := range summaries {
+		for _, d := range s.Markdown {
+			row(s.File, "markdown", d.Path, "", "", strconv.Itoa(d.Size), strconv.Itoa(d.Lines), strconv.Itoa(d.Words), "", "", "")
+		}
+		for _, d := range s.Media {
+			var width, height string
+			if d.Width != 0 || d.Height != 0 {
+				width, height = strconv.Itoa(d.Width), strconv.Itoa(d.Height)
+			}
+			row(s.File, "media", d.Path, d.ID, d.MIME, strconv.Itoa(d.Size), "", "", d.SHA256, width, height)
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeDetailNDJSON writes one JSON object per markdown file and media item,
+// each tagged with its kind and, when inspecting several files, the file.
+func writeDetailNDJSON(w io.Writer, s inspectSummary) error {
+	enc := json.NewEncoder(w)
+	for _, d := range s.Markdown {
+		if err := enc.Encode(struct {
+			File string `json:"file,omitempty"`
+			Kind string `json:"kind"`
+			markdownDetail
+		}{s.File, "markdown", d}); err != nil {
+			return err
+		}
+	}
+	for _, d := range s.Media {
+		if err := enc.Encode(struct {
+			File string `json:"file,omitempty"`
+			Kind string `json:"kind"`
+			mediaDetail
+		}{s.File, "media", d}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
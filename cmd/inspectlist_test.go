@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"image"
+	"image/png"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/logicossoftware/go-mdocx"
+)
+
+func detailTestDoc(t *testing.T) *mdocx.Document {
+	t.Helper()
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 3, 2))); err != nil {
+		t.Fatal(err)
+	}
+	return &mdocx.Document{
+		Markdown: mdocx.MarkdownBundle{
+			BundleVersion: mdocx.VersionV1,
+			RootPath:      "readme.md",
+			Files: []mdocx.MarkdownFile{
+				{Path: "readme.md", Content: []byte("# Title\n\none two three\n")},
+				{Path: "a.md", Content: []byte("x")},
+			},
+		},
+		Media: mdocx.MediaBundle{
+			BundleVersion: mdocx.VersionV1,
+			Items: []mdocx.MediaItem{
+				{ID: "pic", Path: "assets/pic.png", MIMEType: "image/png", Data: img.Bytes()},
+				{ID: "blob", MIMEType: "application/octet-stream", Data: []byte("abc")},
+			},
+		},
+	}
+}
+
+func TestAddInspectDetails(t *testing.T) {
+	doc := detailTestDoc(t)
+	s := buildInspectSummary(doc, nil)
+	addInspectDetails(&s, doc)
+
+	if len(s.Markdown) != 2 || s.Markdown[0].Path != "a.md" {
+		t.Fatalf("expected markdown sorted by path, got %+v", s.Markdown)
+	}
+	readme := s.Markdown[1]
+	if !readme.Root || readme.Lines != 3 || readme.Words != 5 || readme.Size != 23 {
+		t.Errorf("unexpected readme detail: %+v", readme)
+	}
+	if s.Markdown[0].Lines != 1 || s.Markdown[0].Root {
+		t.Errorf("unexpected a.md detail: %+v", s.Markdown[0])
+	}
+
+	if len(s.Media) != 2 || s.Media[0].ID != "blob" {
+		t.Fatalf("expected media sorted by ID, got %+v", s.Media)
+	}
+	blob, pic := s.Media[0], s.Media[1]
+	if blob.SHA256 != "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad" {
+		t.Errorf("unexpected sha256: %s", blob.SHA256)
+	}
+	if blob.dimensions() != "" {
+		t.Errorf("non-image should have no dimensions, got %q", blob.dimensions())
+	}
+	if pic.Width != 3 || pic.Height != 2 {
+		t.Errorf("expected 3x2 image, got %dx%d", pic.Width, pic.Height)
+	}
+}
+
+func TestCountLines(t *testing.T) {
+	cases := map[string]int{"": 0, "a": 1, "a\n": 1, "a\nb": 2, "\n\n": 2}
+	for in, want := range cases {
+		if got := countLines([]byte(in)); got != want {
+			t.Errorf("countLines(%q) = %d, want %d", in, got, want)
+		}
+	}
+}
+
+func TestInspectCommand_Long(t *testing.T) {
+	tmp := t.TempDir()
+	p := createTestMDOCX(t, filepath.Join(tmp, "test.mdocx"), nil)
+
+	out, err := executeCommand(rootCmd, "inspect", "--long", p)
+	if err != nil {
+		t.Fatalf("inspect --long failed: %v", err)
+	}
+	for _, want := range []string{"Markdown files (2", "MARKDOWN", "readme.md (root)", "docs/guide.md", "MEDIA ID", "logo_png", "image/png"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+
+	out, err = executeCommand(rootCmd, "inspect", "--format", "json", p)
+	if err != nil {
+		t.Fatalf("inspect --format json failed: %v", err)
+	}
+	if strings.Contains(out, `"media":`) {
+		t.Error("plain json should not include per-entry listings")
+	}
+
+	out, err = executeCommand(rootCmd, "inspect", "--json", "--long", p)
+	if err != nil {
+		t.Fatalf("inspect --json --long failed: %v", err)
+	}
+	var summary inspectSummary
+	if err := json.Unmarshal([]byte(out), &summary); err != nil {
+		t.Fatalf("parse JSON: %v", err)
+	}
+	if len(summary.Markdown) != 2 || len(summary.Media) != 1 || summary.Media[0].SHA256 == "" {
+		t.Errorf("expected per-entry listings, got %+v / %+v", summary.Markdown, summary.Media)
+	}
+}
+
+func TestInspectCommand_CSVAndNDJSON(t *testing.T) {
+	tmp := t.TempDir()
+	p := createTestMDOCX(t, filepath.Join(tmp, "test.mdocx"), nil)
+
+	out, err := executeCommand(rootCmd, "inspect", "--format", "csv", p)
+	if err != nil {
+		t.Fatalf("inspect --format csv failed: %v", err)
+	}
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatalf("parse CSV: %v", err)
+	}
+	if len(records) != 4 || records[0][0] != "kind" {
+		t.Fatalf("expected header plus 3 rows, got %v", records)
+	}
+	if last := records[3]; last[0] != "media" || last[2] != "logo_png" || last[4] != "4" {
+		t.Errorf("unexpected media row: %v", last)
+	}
+
+	out, err = executeCommand(rootCmd, "inspect", "--format", "ndjson", p)
+	if err != nil {
+		t.Fatalf("inspect --format ndjson failed: %v", err)
+	}
+	var kinds []string
+	sc := bufio.NewScanner(strings.NewReader(out))
+	for sc.Scan() {
+		var row map[string]any
+		if err := json.Unmarshal(sc.Bytes(), &row); err != nil {
+			t.Fatalf("parse line %q: %v", sc.Text(), err)
+		}
+		kinds = append(kinds, row["kind"].(string))
+	}
+	if strings.Join(kinds, ",") != "markdown,markdown,media" {
+		t.Errorf("unexpected rows: %v", kinds)
+	}
+
+	if _, err := executeCommand(rootCmd, "inspect", "--format", "xml", p); err == nil {
+		t.Error("expected error for unknown format")
+	}
+	if _, err := executeCommand(rootCmd, "inspect", "--json", "--format", "csv", p); err == nil {
+		t.Error("expected error for --json with --format csv")
+	}
+}
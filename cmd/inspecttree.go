@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/logicossoftware/go-mdocx"
+)
+
+// treeNode is a directory or file in the container's path hierarchy.
+// Directories carry the total size and file count of everything below them.
+type treeNode struct {
+	Name     string      `json:"name"`
+	Kind     string      `json:"kind"` // "dir", "markdown" or "media"
+	Size     int         `json:"size"`
+	Files    int         `json:"files"`
+	Root     bool        `json:"root,omitempty"`
+	MIME     string      `json:"mime,omitempty"`
+	Children []*treeNode `json:"children,omitempty"`
+}
+
+// buildContentTrees returns the container's hierarchy, either as a single
+// tree holding markdown and media ("merged") or as separate markdown and
+// media trees ("separate"). Media items are placed where unpack writes them.
+func buildContentTrees(doc *mdocx.Document, mode string) ([]*treeNode, error) {
+	var markdown, media *treeNode
+	switch mode {
+	case "", "merged":
+		markdown = &treeNode{Name: ".", Kind: "dir"}
+		media = markdown
+	case "separate":
+		markdown = &treeNode{Name: "markdown", Kind: "dir"}
+		media = &treeNode{Name: "media", Kind: "dir"}
+	default:
+		return nil, fmt.Errorf("unknown tree mode: %s (want merged|separate)", mode)
+	}
+
+	for _, mf := range doc.Markdown.Files {
+		markdown.insert(mf.Path, &treeNode{Kind: "markdown", Size: len(mf.Content), Files: 1, Root: mf.Path == doc.Markdown.RootPath})
+	}
+	ix := newContainerIndex(doc)
+	for i, mi := range doc.Media.Items {
+		media.insert(ix.mediaPaths[i], &treeNode{Kind: "media", Size: len(mi.Data), Files: 1, MIME: mi.MIMEType})
+	}
+
+	roots := []*treeNode{markdown}
+	if media != markdown {
+		roots = append(roots, media)
+	}
+	for _, r := range roots {
+		r.sortChildren()
+	}
+	return roots, nil
+}
+
+// insert adds leaf at the slash-separated path p below n, creating
+// directories as needed and adding the leaf to each directory's totals.
+func (n *treeNode) insert(p string, leaf *treeNode) {
+	parts := strings.Split(strings.Trim(path.Clean("/"+p), "/"), "/")
+	leaf.Name = parts[len(parts)-1]
+	cur := n
+	for _, part := range parts[:len(parts)-1] {
+		cur.Size += leaf.Size
+		cur.Files++
+		var next *treeNode
+		for _, c := range cur.Children {
+			if c.Kind == "dir" && c.Name == part {
+				next = c
+				break
+			}
+		}
+		if next == nil {
+			next = &treeNode{Name: part, Kind: "dir"}
+			cur.Children = append(cur.Children, next)
+		}
+		cur = next
+	}
+	cur.Size += leaf.Size
+	cur.Files++
+	cur.Children = append(cur.Children, leaf)
+}
+
+// sortChildren orders directories before files, each by name.
+func (n *treeNode) sortChildren() {
+	sort.SliceStable(n.Children, func(i, j int) bool {
+		a, b := n.Children[i], n.Children[j]
+		if (a.Kind == "dir") != (b.Kind == "dir") {
+			return a.Kind == "dir"
+		}
+		return a.Name < b.Name
+	})
+	for _, c := range n.Children {
+		c.sortChildren()
+	}
+}
+
+// writeTree renders roots with box-drawing guides. Directories deeper than
+// maxDepth are shown with their totals but not expanded; 0 means no limit.
+// In a merged tree media files are tagged, and the root markdown file is
+// marked.
+func writeTree(w io.Writer, roots []*treeNode, maxDepth int) {
+	merged := len(roots) == 1
+	for i, r := range roots {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s %s\n", r.Name, dirTotals(r))
+		writeTreeChildren(w, r, "", 1, maxDepth, merged)
+	}
+}
+
+func writeTreeChildren(w io.Writer, n *treeNode, prefix string, depth, maxDepth int, merged bool) {
+	for i, c := range n.Children {
+		branch, indent := "├── ", "│   "
+		if i == len(n.Children)-1 {
+			branch, indent = "└── ", "    "
+		}
+		if c.Kind == "dir" {
+			fmt.Fprintf(w, "%s%s%s/ %s\n", prefix, branch, c.Name, dirTotals(c))
+			if maxDepth <= 0 || depth < maxDepth {
+				writeTreeChildren(w, c, prefix+indent, depth+1, maxDepth, merged)
+			}
+			continue
+		}
+		line := fmt.Sprintf("%s%s%s  %s", prefix, branch, c.Name, humanSize(c.Size))
+		if merged && c.Kind == "media" {
+			line += "  [media]"
+		}
+		if c.Root {
+			line += "  <- root"
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+func dirTotals(n *treeNode) string {
+	noun := "files"
+	if n.Files == 1 {
+		noun = "file"
+	}
+	return fmt.Sprintf("(%d %s, %s)", n.Files, noun, humanSize(n.Size))
+}
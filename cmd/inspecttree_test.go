@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildContentTrees_Merged(t *testing.T) {
+	roots, err := buildContentTrees(siteTestDoc(), "merged")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roots) != 1 {
+		t.Fatalf("expected one tree, got %d", len(roots))
+	}
+	root := roots[0]
+	if root.Files != 4 {
+		t.Errorf("expected 4 files at root, got %d", root.Files)
+	}
+	var names []string
+	for _, c := range root.Children {
+		names = append(names, c.Name)
+	}
+	if got := strings.Join(names, ","); got != "assets,docs,media,readme.md" {
+		t.Errorf("expected directories first, got %s", got)
+	}
+	if docs := root.Children[1]; docs.Files != 1 || docs.Size != len(siteTestDoc().Markdown.Files[1].Content) {
+		t.Errorf("unexpected docs totals: %+v", docs)
+	}
+
+	var buf bytes.Buffer
+	writeTree(&buf, roots, 0)
+	out := buf.String()
+	for _, want := range []string{". (4 files", "├── assets/ (1 file, 1 B)", "│   └── logo.png  1 B  [media]", "└── readme.md", "<- root"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in tree:\n%s", want, out)
+		}
+	}
+}
+
+func TestBuildContentTrees_SeparateAndDepth(t *testing.T) {
+	roots, err := buildContentTrees(siteTestDoc(), "separate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roots) != 2 || roots[0].Files != 2 || roots[1].Files != 2 {
+		t.Fatalf("expected markdown and media trees with 2 files each, got %+v", roots)
+	}
+
+	var buf bytes.Buffer
+	writeTree(&buf, roots, 1)
+	out := buf.String()
+	if strings.Contains(out, "guide.md") || !strings.Contains(out, "docs/ (1 file") {
+		t.Errorf("depth 1 should collapse docs/:\n%s", out)
+	}
+	if strings.Contains(out, "[media]") {
+		t.Errorf("separate trees should not tag media:\n%s", out)
+	}
+
+	if _, err := buildContentTrees(siteTestDoc(), "sideways"); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
+
+func TestInspectCommand_Tree(t *testing.T) {
+	tmp := t.TempDir()
+	p := createTestMDOCX(t, filepath.Join(tmp, "test.mdocx"), nil)
+
+	out, err := executeCommand(rootCmd, "inspect", "--tree", p)
+	if err != nil {
+		t.Fatalf("inspect --tree failed: %v", err)
+	}
+	if !strings.Contains(out, "readme.md") || !strings.Contains(out, "docs/ (1 file") || strings.Contains(out, "Metadata keys") {
+		t.Errorf("unexpected tree output:\n%s", out)
+	}
+
+	out, err = executeCommand(rootCmd, "inspect", "--tree", "--json", p)
+	if err != nil {
+		t.Fatalf("inspect --tree --json failed: %v", err)
+	}
+	var summary inspectSummary
+	if err := json.Unmarshal([]byte(out), &summary); err != nil {
+		t.Fatalf("parse JSON: %v", err)
+	}
+	if len(summary.Tree) != 1 || summary.Tree[0].Files != 3 {
+		t.Errorf("expected merged tree with 3 files, got %+v", summary.Tree)
+	}
+
+	if _, err := executeCommand(rootCmd, "inspect", "--tree", "--format", "csv", p); err == nil {
+		t.Error("expected error for --tree with csv")
+	}
+}
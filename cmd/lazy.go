@@ -0,0 +1,264 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/logicossoftware/go-mdocx"
+	"github.com/pierrec/lz4/v4"
+)
+
+// lazyContainer gives access to the parts of an MDOCX file one at a time.
+// Opening it reads only the fixed header, the metadata block and the section
+// headers; a section's payload is read and decoded only when asked for, so
+// looking at metadata or markdown never touches the media section.
+type lazyContainer struct {
+	f        *os.File
+	header   *headerInfo
+	layout   *containerLayout
+	metadata map[string]any
+}
+
+// openLazyContainer opens the container at path and reads its header and
+// metadata. The caller must Close it.
+func openLazyContainer(path string) (*lazyContainer, error) {
+	header, err := readHeaderInfo(path)
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+	switch {
+	case !header.MagicValid:
+		return nil, fmt.Errorf("decode: %w", mdocx.ErrInvalidMagic)
+	case header.Version != mdocx.VersionV1:
+		return nil, fmt.Errorf("decode: %w", mdocx.ErrUnsupportedVersion)
+	case header.FixedHdrSize != fixedHeaderSize:
+		return nil, fmt.Errorf("decode: %w: fixed header size %d", mdocx.ErrInvalidHeader, header.FixedHdrSize)
+	}
+	layout, err := readContainerLayout(path)
+	if err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+	c := &lazyContainer{f: f, header: header, layout: layout}
+	if layout.MetadataLength > 0 {
+		if header.HeaderFlags&mdocx.HeaderFlagMetadataJSON == 0 {
+			f.Close()
+			return nil, fmt.Errorf("decode: %w: metadata present but METADATA_JSON flag not set", mdocx.ErrInvalidHeader)
+		}
+		mr := io.NewSectionReader(f, layout.MetadataOffset, int64(layout.MetadataLength))
+		if err := json.NewDecoder(mr).Decode(&c.metadata); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("decode: metadata: %w", err)
+		}
+	}
+	return c, nil
+}
+
+func (c *lazyContainer) Close() error { return c.f.Close() }
+
+// markdown decodes the markdown section.
+func (c *lazyContainer) markdown() (mdocx.MarkdownBundle, error) {
+	var b mdocx.MarkdownBundle
+	err := c.decodeSection(0, mdocx.SectionMarkdown, &b)
+	return b, err
+}
+
+// media decodes the media section. An empty section is an empty bundle.
+func (c *lazyContainer) media() (mdocx.MediaBundle, error) {
+	b := mdocx.MediaBundle{BundleVersion: mdocx.VersionV1}
+	if c.layout.Sections[1].PayloadLen == 0 {
+		return b, nil
+	}
+	err := c.decodeSection(1, mdocx.SectionMedia, &b)
+	return b, err
+}
+
+// decodeSection streams section i through its decompressor into a gob
+// decoder, so the compressed payload is never held in memory whole.
+func (c *lazyContainer) decodeSection(i int, want mdocx.SectionType, out any) error {
+	sec := c.layout.Sections[i]
+	name := sectionTypeName(uint16(want))
+	if mdocx.SectionType(sec.Type) != want {
+		return fmt.Errorf("decode: %w: expected %s section, got type %d", mdocx.ErrInvalidSection, name, sec.Type)
+	}
+	r, err := sectionPayloadReader(c.f, sec)
+	if err != nil {
+		return fmt.Errorf("decode %s: %w", name, err)
+	}
+	defer r.Close()
+	if err := gob.NewDecoder(r).Decode(out); err != nil {
+		return fmt.Errorf("decode %s: %w", name, err)
+	}
+	if err := r.checkEnd(); err != nil {
+		return fmt.Errorf("decode %s: %w", name, err)
+	}
+	return nil
+}
+
+// sectionPayloadReader returns the decompressed payload of sec, read from ra
+// on demand. Output is cut off at the declared uncompressed length. It is the
+// CLI's only decompressor: decodeContainerFile, the lazy container, salvage
+// and repair all read sections through it.
+func sectionPayloadReader(ra io.ReaderAt, sec sectionInfo) (*payloadReader, error) {
+	comp := sec.compression()
+	payload := io.NewSectionReader(ra, sec.PayloadOffset, clampInt64(sec.PayloadLen))
+	if comp == mdocx.CompNone {
+		if sec.hasUncompressedLen() {
+			return nil, fmt.Errorf("%w: COMP_NONE must not set HAS_UNCOMPRESSED_LEN", mdocx.ErrInvalidSection)
+		}
+		return newPayloadReader(payload, sec.PayloadLen, nil), nil
+	}
+	if !sec.hasUncompressedLen() || sec.PayloadLen < 8 {
+		return nil, fmt.Errorf("%w: compressed payload must set HAS_UNCOMPRESSED_LEN", mdocx.ErrInvalidSection)
+	}
+	data := io.NewSectionReader(ra, sec.PayloadOffset+8, clampInt64(sec.PayloadLen-8))
+
+	switch comp {
+	case mdocx.CompZSTD:
+		zr, err := zstd.NewReader(data)
+		if err != nil {
+			return nil, err
+		}
+		return newPayloadReader(zr, sec.UncompressedLen, func() error { zr.Close(); return nil }), nil
+	case mdocx.CompLZ4:
+		return newPayloadReader(lz4.NewReader(data), sec.UncompressedLen, nil), nil
+	case mdocx.CompBR:
+		return newPayloadReader(brotli.NewReader(data), sec.UncompressedLen, nil), nil
+	case mdocx.CompZIP:
+		zr, err := zip.NewReader(data, data.Size())
+		if err != nil {
+			return nil, err
+		}
+		if len(zr.File) != 1 || zr.File[0].Name != "payload.gob" {
+			return nil, fmt.Errorf("%w: zip must contain exactly one payload.gob entry", mdocx.ErrInvalidPayload)
+		}
+		rc, err := zr.File[0].Open()
+		if err != nil {
+			return nil, err
+		}
+		return newPayloadReader(rc, sec.UncompressedLen, rc.Close), nil
+	default:
+		return nil, fmt.Errorf("%w: unknown compression %d", mdocx.ErrInvalidSection, comp)
+	}
+}
+
+// payloadReader is a decompressed section payload, limited to its declared
+// length.
+type payloadReader struct {
+	io.Reader
+	src   io.Reader
+	close func() error
+}
+
+func newPayloadReader(src io.Reader, n uint64, close func() error) *payloadReader {
+	if close == nil {
+		close = func() error { return nil }
+	}
+	return &payloadReader{Reader: io.LimitReader(src, clampInt64(n)), src: src, close: close}
+}
+
+func (r *payloadReader) Close() error { return r.close() }
+
+// checkEnd reads what is left of the declared length and fails if the
+// payload decompresses to more than that, as the library's decoder does.
+func (r *payloadReader) checkEnd() error {
+	if _, err := io.Copy(io.Discard, r.Reader); err != nil {
+		return err
+	}
+	var probe [1]byte
+	if n, _ := r.src.Read(probe[:]); n > 0 {
+		return fmt.Errorf("%w: payload expands beyond its declared length", mdocx.ErrInvalidPayload)
+	}
+	return nil
+}
+
+// decodeFramed decodes the container in f with mdocx.Decode, after
+// re-framing it as an uncompressed one: the header and metadata pass through
+// unchanged and each section's payload is replaced by its stream from
+// sectionPayloadReader. The library keeps doing all framing and document
+// checks, while decompression happens once, here.
+func decodeFramed(f *os.File, layout *containerLayout, opts ...mdocx.ReadOption) (*mdocx.Document, error) {
+	parts := []io.Reader{io.NewSectionReader(f, 0, layout.MetadataOffset+int64(layout.MetadataLength))}
+	var payloads []*payloadReader
+	defer func() {
+		for _, r := range payloads {
+			r.Close()
+		}
+	}()
+	for _, sec := range layout.Sections {
+		// An empty section keeps its flags for the library to check.
+		length, flags := sec.PayloadLen, sec.Flags
+		if sec.PayloadLen > 0 && sec.compression() != mdocx.CompNone {
+			length = sec.UncompressedLen
+			flags &^= sectionFlagCompressionMask | sectionFlagHasUncompressedLen
+		}
+		var hdr [sectionHeaderSize]byte
+		binary.LittleEndian.PutUint16(hdr[0:2], sec.Type)
+		binary.LittleEndian.PutUint16(hdr[2:4], flags)
+		binary.LittleEndian.PutUint64(hdr[4:12], length)
+		binary.LittleEndian.PutUint32(hdr[12:16], sec.Reserved)
+		parts = append(parts, bytes.NewReader(hdr[:]))
+		if sec.PayloadLen == 0 {
+			continue
+		}
+		r, err := sectionPayloadReader(f, sec)
+		if err != nil {
+			return nil, fmt.Errorf("%s section: %w", sectionTypeName(sec.Type), err)
+		}
+		payloads = append(payloads, r)
+		parts = append(parts, r)
+	}
+	doc, err := mdocx.Decode(io.MultiReader(parts...), opts...)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range payloads {
+		if err := r.checkEnd(); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+// decodeContainerPartial decodes the metadata of the container at input and,
+// if withMarkdown is set, its markdown section. The media section is never
+// read, so the returned document has an empty media bundle. Limits apply to
+// what is decoded.
+func decodeContainerPartial(input string, withMarkdown bool, limits decodeLimits) (*mdocx.Document, error) {
+	c, err := openLazyContainer(input)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	doc := &mdocx.Document{
+		Metadata: c.metadata,
+		Media:    mdocx.MediaBundle{BundleVersion: mdocx.VersionV1},
+	}
+	if !withMarkdown {
+		return doc, nil
+	}
+	declared := c.layout.Sections[0].UncompressedLen
+	if limits.MaxTotalSize > 0 && declared > uint64(limits.MaxTotalSize) {
+		return nil, &limitError{Flag: "max-total-size", Subject: "declared uncompressed markdown size", Actual: clampInt64(declared), Max: limits.MaxTotalSize}
+	}
+	if doc.Markdown, err = c.markdown(); err != nil {
+		return nil, err
+	}
+	if err := limits.checkDocument(doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
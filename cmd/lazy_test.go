@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/logicossoftware/go-mdocx"
+)
+
+func writeLazyTestMDOCX(t *testing.T, p string, comp mdocx.Compression) *mdocx.Document {
+	t.Helper()
+	doc := siteTestDoc()
+	for i := range doc.Media.Items {
+		doc.Media.Items[i].Data = bytes.Repeat([]byte{byte(i + 1)}, 4096)
+	}
+	var buf bytes.Buffer
+	if err := mdocx.Encode(&buf, doc, mdocx.WithMarkdownCompression(comp), mdocx.WithMediaCompression(comp)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestLazyContainer_MatchesDecode(t *testing.T) {
+	for _, comp := range []mdocx.Compression{mdocx.CompNone, mdocx.CompZIP, mdocx.CompZSTD, mdocx.CompLZ4, mdocx.CompBR} {
+		t.Run(compressionName(comp), func(t *testing.T) {
+			p := filepath.Join(t.TempDir(), "test.mdocx")
+			writeLazyTestMDOCX(t, p, comp)
+			// Compare with the library's own decoder, so sectionPayloadReader
+			// can't drift from it unnoticed.
+			f, err := os.Open(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			want, err := mdocx.Decode(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			framed, err := decodeContainerFile(p, true, defaultDecodeLimits())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(framed, want) {
+				t.Errorf("decodeContainerFile differs from Decode")
+			}
+
+			c, err := openLazyContainer(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+			if !reflect.DeepEqual(c.metadata, want.Metadata) {
+				t.Errorf("metadata: got %v, want %v", c.metadata, want.Metadata)
+			}
+			md, err := c.markdown()
+			if err != nil {
+				t.Fatalf("markdown: %v", err)
+			}
+			if !reflect.DeepEqual(md, want.Markdown) {
+				t.Errorf("markdown bundle differs from Decode")
+			}
+			media, err := c.media()
+			if err != nil {
+				t.Fatalf("media: %v", err)
+			}
+			if !reflect.DeepEqual(media, want.Media) {
+				t.Errorf("media bundle differs from Decode")
+			}
+		})
+	}
+}
+
+// corruptMedia overwrites the middle of the media payload so that any attempt
+// to decode it fails.
+func corruptMedia(t *testing.T, p string) {
+	t.Helper()
+	layout, err := readContainerLayout(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	media := layout.Sections[1]
+	b, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := media.PayloadOffset + 8
+	for i := start; i < start+64 && i < int64(len(b)); i++ {
+		b[i] = 0xFF
+	}
+	if err := os.WriteFile(p, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInspectCommand_OnlySkipsMedia(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "test.mdocx")
+	writeLazyTestMDOCX(t, p, mdocx.CompZSTD)
+	corruptMedia(t, p)
+
+	if _, err := executeCommand(rootCmd, "inspect", p); err == nil {
+		t.Fatal("full inspect should fail on a corrupt media section")
+	}
+
+	out, err := executeCommand(rootCmd, "inspect", "--only", "metadata", p)
+	if err != nil {
+		t.Fatalf("inspect --only metadata: %v", err)
+	}
+	if !strings.Contains(out, "Metadata keys: [author title]") || strings.Contains(out, "Markdown files") {
+		t.Errorf("unexpected metadata-only output:\n%s", out)
+	}
+
+	out, err = executeCommand(rootCmd, "inspect", "--only", "markdown", "--long", p)
+	if err != nil {
+		t.Fatalf("inspect --only markdown: %v", err)
+	}
+	if !strings.Contains(out, "Markdown files (2") || !strings.Contains(out, "docs/guide.md") || strings.Contains(out, "Media IDs") {
+		t.Errorf("unexpected markdown-only output:\n%s", out)
+	}
+
+	if _, err := executeCommand(rootCmd, "inspect", "--layout", p); err != nil {
+		t.Errorf("--layout alone should not decode sections: %v", err)
+	}
+	if _, err := executeCommand(rootCmd, "inspect", "--only", "media", p); err == nil {
+		t.Error("expected error for unknown --only value")
+	}
+}
+
+func TestDecodeContainerPartial_Limits(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "test.mdocx")
+	writeLazyTestMDOCX(t, p, mdocx.CompZSTD)
+
+	// The media section alone exceeds this, but it is never decoded.
+	doc, err := decodeContainerPartial(p, true, decodeLimits{MaxTotalSize: 2048})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(doc.Markdown.Files) != 2 || len(doc.Media.Items) != 0 {
+		t.Errorf("expected markdown only, got %d files and %d media", len(doc.Markdown.Files), len(doc.Media.Items))
+	}
+
+	if _, err := decodeContainerPartial(p, true, decodeLimits{MaxEntries: 1}); err == nil {
+		t.Error("expected entry limit error")
+	}
+}
+
+func TestPayloadReader_CheckEnd(t *testing.T) {
+	r := newPayloadReader(strings.NewReader("abcd"), 4, nil)
+	if err := r.checkEnd(); err != nil {
+		t.Errorf("exact payload: unexpected error %v", err)
+	}
+	r = newPayloadReader(strings.NewReader("abcdef"), 4, nil)
+	if b, _ := io.ReadAll(r); string(b) != "abcd" {
+		t.Errorf("expected output cut off at the declared length, got %q", b)
+	}
+	if err := r.checkEnd(); !errors.Is(err, mdocx.ErrInvalidPayload) {
+		t.Errorf("expected an overrun error, got %v", err)
+	}
+}
@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/logicossoftware/go-mdocx"
+	"github.com/spf13/cobra"
+)
+
+// decodeLimits bounds the resources a container may consume when it is
+// decoded. A zero value for any field disables that limit.
+type decodeLimits struct {
+	MaxTotalSize  int64 // total uncompressed bytes across markdown and media
+	MaxEntries    int   // markdown files plus media items
+	MaxEntrySize  int64 // bytes in a single markdown file or media item
+	MaxPathDepth  int   // path segments in a container path
+	MaxPathLength int   // bytes in a container path
+}
+
+// defaultDecodeLimits returns limits suitable for containers from untrusted sources.
+func defaultDecodeLimits() decodeLimits {
+	return decodeLimits{
+		MaxTotalSize:  1 << 30, // 1 GiB
+		MaxEntries:    10_000,
+		MaxEntrySize:  256 << 20, // 256 MiB
+		MaxPathDepth:  32,
+		MaxPathLength: 1024,
+	}
+}
+
+// limitError reports which limit a container exceeded. It wraps
+// mdocx.ErrLimitExceeded so callers can match it with errors.Is.
+type limitError struct {
+	Flag    string
+	Subject string
+	Actual  int64
+	Max     int64
+}
+
+func (e *limitError) Error() string {
+	return fmt.Sprintf("limit --%s exceeded: %s is %d, limit is %d", e.Flag, e.Subject, e.Actual, e.Max)
+}
+
+func (e *limitError) Unwrap() error { return mdocx.ErrLimitExceeded }
+
+// readLimits maps l onto the library's decode limits. Only the section
+// sizes are delegated, so nothing larger than --max-total-size is read into
+// memory; decodeFramed hands the library uncompressed sections, so the
+// section length caps carry the uncompressed size limits. These never
+// exceed the library defaults unless --max-total-size is 0, and even then a
+// section can't exceed the library's stored section caps. The library
+// checks entry counts and sizes only once the whole document is decoded, so
+// those are left to checkDocument, whose errors name the flag.
+func (l decodeLimits) readLimits() mdocx.Limits {
+	lim := mdocx.DefaultLimits()
+	if l.MaxTotalSize > 0 {
+		lim.MaxMarkdownSectionLen = min(lim.MaxMarkdownUncompressed, uint64(l.MaxTotalSize))
+		lim.MaxMediaSectionLen = min(lim.MaxMediaUncompressed, uint64(l.MaxTotalSize))
+	}
+	lim.MaxMarkdownUncompressed, lim.MaxMediaUncompressed = lim.MaxMarkdownSectionLen, lim.MaxMediaSectionLen
+	lim.MaxMarkdownFiles, lim.MaxMediaItems = math.MaxInt, math.MaxInt
+	lim.MaxSingleMarkdownFileSize, lim.MaxSingleMediaSize = math.MaxUint64, math.MaxUint64
+	return lim
+}
+
+// checkLayout rejects containers whose section headers declare more
+// uncompressed data than allowed, before any payload is decompressed.
+func (l decodeLimits) checkLayout(layout *containerLayout) error {
+	if l.MaxTotalSize <= 0 {
+		return nil
+	}
+	total := layout.totalUncompressed()
+	if total > uint64(l.MaxTotalSize) {
+		return &limitError{Flag: "max-total-size", Subject: "declared uncompressed section size", Actual: clampInt64(total), Max: l.MaxTotalSize}
+	}
+	return nil
+}
+
+// checkDocument enforces entry count, entry size, total size and path limits
+// on a decoded document.
+func (l decodeLimits) checkDocument(doc *mdocx.Document) error {
+	entries := len(doc.Markdown.Files) + len(doc.Media.Items)
+	if l.MaxEntries > 0 && entries > l.MaxEntries {
+		return &limitError{Flag: "max-entries", Subject: "entry count", Actual: int64(entries), Max: int64(l.MaxEntries)}
+	}
+
+	var total int64
+	checkEntry := func(kind, name, p string, size int) error {
+		total += int64(size)
+		if l.MaxEntrySize > 0 && int64(size) > l.MaxEntrySize {
+			return &limitError{Flag: "max-entry-size", Subject: fmt.Sprintf("size of %s %q", kind, name), Actual: int64(size), Max: l.MaxEntrySize}
+		}
+		if p == "" {
+			return nil
+		}
+		if l.MaxPathLength > 0 && len(p) > l.MaxPathLength {
+			return &limitError{Flag: "max-path-length", Subject: fmt.Sprintf("length of %s path %q", kind, p), Actual: int64(len(p)), Max: int64(l.MaxPathLength)}
+		}
+		if depth := pathDepth(p); l.MaxPathDepth > 0 && depth > l.MaxPathDepth {
+			return &limitError{Flag: "max-path-depth", Subject: fmt.Sprintf("depth of %s path %q", kind, p), Actual: int64(depth), Max: int64(l.MaxPathDepth)}
+		}
+		return nil
+	}
+	for _, mf := range doc.Markdown.Files {
+		if err := checkEntry("markdown file", mf.Path, mf.Path, len(mf.Content)); err != nil {
+			return err
+		}
+	}
+	for _, mi := range doc.Media.Items {
+		if err := checkEntry("media item", mi.ID, mi.Path, len(mi.Data)); err != nil {
+			return err
+		}
+	}
+	if l.MaxTotalSize > 0 && total > l.MaxTotalSize {
+		return &limitError{Flag: "max-total-size", Subject: "total uncompressed content size", Actual: total, Max: l.MaxTotalSize}
+	}
+	return nil
+}
+
+// pathDepth counts the non-empty segments of a slash-separated path.
+func pathDepth(p string) int {
+	depth := 0
+	for _, seg := range strings.Split(p, "/") {
+		if seg != "" {
+			depth++
+		}
+	}
+	return depth
+}
+
+func clampInt64(v uint64) int64 {
+	if v > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return int64(v)
+}
+
+// addLimitFlags registers the resource limit flags on cmd.
+func addLimitFlags(cmd *cobra.Command) {
+	d := defaultDecodeLimits()
+	cmd.Flags().String("max-total-size", formatByteSize(d.MaxTotalSize), "maximum total uncompressed size (0 = unlimited)")
+	cmd.Flags().Int("max-entries", d.MaxEntries, "maximum number of markdown files plus media items (0 = unlimited)")
+	cmd.Flags().String("max-entry-size", formatByteSize(d.MaxEntrySize), "maximum size of a single entry (0 = unlimited)")
+	cmd.Flags().Int("max-path-depth", d.MaxPathDepth, "maximum number of segments in a container path (0 = unlimited)")
+	cmd.Flags().Int("max-path-length", d.MaxPathLength, "maximum length of a container path in bytes (0 = unlimited)")
+}
+
+// limitsFromFlags reads the flags registered by addLimitFlags.
+func limitsFromFlags(cmd *cobra.Command) (decodeLimits, error) {
+	var l decodeLimits
+	var err error
+	totalSize, _ := cmd.Flags().GetString("max-total-size")
+	if l.MaxTotalSize, err = parseByteSize(totalSize); err != nil {
+		return l, fmt.Errorf("--max-total-size: %w", err)
+	}
+	entrySize, _ := cmd.Flags().GetString("max-entry-size")
+	if l.MaxEntrySize, err = parseByteSize(entrySize); err != nil {
+		return l, fmt.Errorf("--max-entry-size: %w", err)
+	}
+	l.MaxEntries, _ = cmd.Flags().GetInt("max-entries")
+	l.MaxPathDepth, _ = cmd.Flags().GetInt("max-path-depth")
+	l.MaxPathLength, _ = cmd.Flags().GetInt("max-path-length")
+	if l.MaxEntries < 0 || l.MaxPathDepth < 0 || l.MaxPathLength < 0 {
+		return l, fmt.Errorf("limits must not be negative")
+	}
+	return l, nil
+}
+
+// parseByteSize parses sizes such as "1024", "64KiB", "512MiB" or "2GiB".
+// Decimal suffixes (KB, MB, GB) are accepted as aliases for binary units.
+func parseByteSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	upper := strings.ToUpper(s)
+	multipliers := []struct {
+		suffix string
+		mult   int64
+	}{
+		{"GIB", 1 << 30}, {"MIB", 1 << 20}, {"KIB", 1 << 10},
+		{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+		{"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10},
+		{"B", 1},
+	}
+	mult := int64(1)
+	for _, m := range multipliers {
+		if strings.HasSuffix(upper, m.suffix) {
+			mult = m.mult
+			upper = strings.TrimSpace(strings.TrimSuffix(upper, m.suffix))
+			break
+		}
+	}
+	n, err := strconv.ParseInt(upper, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	if n > math.MaxInt64/mult {
+		return 0, fmt.Errorf("size %q is too large", s)
+	}
+	return n * mult, nil
+}
+
+// formatByteSize renders n using the largest binary unit that divides it evenly.
+func formatByteSize(n int64) string {
+	switch {
+	case n == 0:
+		return "0"
+	case n%(1<<30) == 0:
+		return fmt.Sprintf("%dGiB", n/(1<<30))
+	case n%(1<<20) == 0:
+		return fmt.Sprintf("%dMiB", n/(1<<20))
+	case n%(1<<10) == 0:
+		return fmt.Sprintf("%dKiB", n/(1<<10))
+	default:
+		return strconv.FormatInt(n, 10)
+	}
+}
@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/logicossoftware/go-mdocx"
+)
+
+func TestParseByteSize(t *testing.T) {
+	cases := []struct {
+		in   string
+		want int64
+	}{
+		{"", 0},
+		{"0", 0},
+		{"1024", 1024},
+		{"64KiB", 64 << 10},
+		{"512MiB", 512 << 20},
+		{"2GiB", 2 << 30},
+		{"10mb", 10 << 20},
+		{"1 G", 1 << 30},
+		{"7B", 7},
+	}
+	for _, tc := range cases {
+		got, err := parseByteSize(tc.in)
+		if err != nil {
+			t.Errorf("parseByteSize(%q): unexpected error: %v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("parseByteSize(%q) = %d, want %d", tc.in, got, tc.want)
+		}
+	}
+
+	for _, bad := range []string{"abc", "-1", "1.5GiB", "99999999999GiB"} {
+		if _, err := parseByteSize(bad); err == nil {
+			t.Errorf("parseByteSize(%q): expected error", bad)
+		}
+	}
+}
+
+func TestFormatByteSize_RoundTrip(t *testing.T) {
+	for _, n := range []int64{0, 1000, 4 << 10, 256 << 20, 1 << 30} {
+		got, err := parseByteSize(formatByteSize(n))
+		if err != nil || got != n {
+			t.Errorf("round trip of %d: got %d, err %v", n, got, err)
+		}
+	}
+}
+
+func limitTestDoc() *mdocx.Document {
+	return &mdocx.Document{
+		Markdown: mdocx.MarkdownBundle{
+			BundleVersion: mdocx.VersionV1,
+			Files: []mdocx.MarkdownFile{
+				{Path: "a/b/c/readme.md", Content: []byte("0123456789")},
+			},
+		},
+		Media: mdocx.MediaBundle{
+			BundleVersion: mdocx.VersionV1,
+			Items: []mdocx.MediaItem{
+				{ID: "img", Path: "assets/img.png", Data: make([]byte, 100)},
+			},
+		},
+	}
+}
+
+func TestCheckDocument_WithinDefaults(t *testing.T) {
+	if err := defaultDecodeLimits().checkDocument(limitTestDoc()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := (decodeLimits{}).checkDocument(limitTestDoc()); err != nil {
+		t.Fatalf("zero limits should be unlimited, got: %v", err)
+	}
+}
+
+func TestCheckDocument_IdentifiesLimit(t *testing.T) {
+	cases := []struct {
+		name   string
+		limits decodeLimits
+		flag   string
+	}{
+		{"entries", decodeLimits{MaxEntries: 1}, "max-entries"},
+		{"entry size", decodeLimits{MaxEntrySize: 50}, "max-entry-size"},
+		{"total size", decodeLimits{MaxTotalSize: 105}, "max-total-size"},
+		{"path depth", decodeLimits{MaxPathDepth: 3}, "max-path-depth"},
+		{"path length", decodeLimits{MaxPathLength: 10}, "max-path-length"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.limits.checkDocument(limitTestDoc())
+			if err == nil {
+				t.Fatal("expected limit error")
+			}
+			var le *limitError
+			if !errors.As(err, &le) {
+				t.Fatalf("expected *limitError, got %T", err)
+			}
+			if le.Flag != tc.flag {
+				t.Errorf("expected flag %q, got %q", tc.flag, le.Flag)
+			}
+			if !errors.Is(err, mdocx.ErrLimitExceeded) {
+				t.Error("expected error to match mdocx.ErrLimitExceeded")
+			}
+			if !strings.Contains(err.Error(), "--"+tc.flag) {
+				t.Errorf("expected flag name in message, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestReadContainerLayout(t *testing.T) {
+	tmp := t.TempDir()
+	p := createTestMDOCX(t, filepath.Join(tmp, "test.mdocx"), map[string]any{"title": "T"})
+
+	layout, err := readContainerLayout(p)
+	if err != nil {
+		t.Fatalf("readContainerLayout: %v", err)
+	}
+	if len(layout.Sections) != 2 {
+		t.Fatalf("expected 2 sections, got %d", len(layout.Sections))
+	}
+	if layout.Sections[0].Type != uint16(mdocx.SectionMarkdown) || layout.Sections[1].Type != uint16(mdocx.SectionMedia) {
+		t.Errorf("unexpected section types: %d, %d", layout.Sections[0].Type, layout.Sections[1].Type)
+	}
+	end := layout.Sections[1].PayloadOffset + int64(layout.Sections[1].PayloadLen)
+	if end != layout.FileSize {
+		t.Errorf("sections end at %d, file size %d", end, layout.FileSize)
+	}
+	if layout.totalUncompressed() == 0 {
+		t.Error("expected non-zero uncompressed total")
+	}
+}
+
+func TestDecodeContainerFile_RejectsDeclaredSizeBeforeDecode(t *testing.T) {
+	tmp := t.TempDir()
+	p := createTestMDOCX(t, filepath.Join(tmp, "test.mdocx"), nil)
+
+	_, err := decodeContainerFile(p, true, decodeLimits{MaxTotalSize: 16})
+	var le *limitError
+	if !errors.As(err, &le) {
+		t.Fatalf("expected *limitError, got %v", err)
+	}
+	if le.Flag != "max-total-size" || !strings.Contains(le.Subject, "declared") {
+		t.Errorf("expected declared size error, got: %v", err)
+	}
+}
+
+func TestLimitFlags_Commands(t *testing.T) {
+	tmp := t.TempDir()
+	p := createTestMDOCX(t, filepath.Join(tmp, "test.mdocx"), nil)
+
+	for _, sub := range []string{"inspect", "validate"} {
+		_, err := executeCommand(rootCmd, sub, "--max-entries", "2", p)
+		if err == nil || !strings.Contains(err.Error(), "--max-entries") {
+			t.Errorf("%s: expected max-entries error, got %v", sub, err)
+		}
+	}
+
+	outDir := filepath.Join(tmp, "out")
+	_, err := executeCommand(rootCmd, "unpack", "-o", outDir, "--max-path-depth", "1", p)
+	if err == nil || !strings.Contains(err.Error(), "--max-path-depth") {
+		t.Fatalf("unpack: expected max-path-depth error, got %v", err)
+	}
+	if _, statErr := os.Stat(filepath.Join(outDir, "readme.md")); statErr == nil {
+		t.Error("unpack should not write anything when a limit is exceeded")
+	}
+
+	if _, err := executeCommand(rootCmd, "inspect", "--max-total-size", "lots", p); err == nil {
+		t.Error("expected error for invalid --max-total-size")
+	}
+	if _, err := executeCommand(rootCmd, "inspect", "--max-entries", "0", p); err != nil {
+		t.Errorf("zero should disable the limit, got %v", err)
+	}
+}
+
+func TestReadLimits(t *testing.T) {
+	def := mdocx.DefaultLimits()
+	lim := defaultDecodeLimits().readLimits()
+	if lim.MaxMarkdownUncompressed != def.MaxMarkdownUncompressed || lim.MaxMediaUncompressed != 1<<30 {
+		t.Errorf("uncompressed limits should not exceed the library defaults: %+v", lim)
+	}
+	if lim.MaxMarkdownFiles != math.MaxInt || lim.MaxSingleMediaSize != math.MaxUint64 {
+		t.Errorf("entry limits should be left to checkDocument: %+v", lim)
+	}
+	if lim.MaxMarkdownSectionLen != lim.MaxMarkdownUncompressed || lim.MaxMediaSectionLen != lim.MaxMediaUncompressed {
+		t.Errorf("decoded sections are uncompressed, so both caps should match: %+v", lim)
+	}
+	lim = (decodeLimits{}).readLimits()
+	if lim.MaxMarkdownFiles != math.MaxInt || lim.MaxSingleMediaSize != math.MaxUint64 {
+		t.Errorf("zero limits should be unlimited: %+v", lim)
+	}
+	if lim.MaxMarkdownUncompressed != def.MaxMarkdownSectionLen || lim.MaxMediaUncompressed != def.MaxMediaSectionLen {
+		t.Errorf("zero limits should fall back to the library's section caps: %+v", lim)
+	}
+}
+
+func TestDecodeContainerFile_OversizedEntryNamesFlag(t *testing.T) {
+	tmp := t.TempDir()
+	p := createTestMDOCX(t, filepath.Join(tmp, "test.mdocx"), nil)
+
+	for _, tc := range []struct {
+		limits decodeLimits
+		flag   string
+	}{
+		{decodeLimits{MaxEntrySize: 3}, "max-entry-size"},
+		{decodeLimits{MaxEntries: 1}, "max-entries"},
+	} {
+		_, err := decodeContainerFile(p, true, tc.limits)
+		var le *limitError
+		if !errors.As(err, &le) || le.Flag != tc.flag {
+			t.Errorf("expected a --%s limit error, got %v", tc.flag, err)
+		}
+	}
+}
+
+func TestSectionInfoPayloadEnd_Saturates(t *testing.T) {
+	sec := sectionInfo{PayloadOffset: 100, PayloadLen: math.MaxUint64 - 10}
+	if end := sec.payloadEnd(); end != math.MaxInt64 {
+		t.Errorf("payloadEnd = %d, want math.MaxInt64", end)
+	}
+	if end := (sectionInfo{PayloadOffset: 100, PayloadLen: 50}).payloadEnd(); end != 150 {
+		t.Errorf("payloadEnd = %d, want 150", end)
+	}
+	layout := &containerLayout{Sections: []sectionInfo{{UncompressedLen: math.MaxUint64}, {UncompressedLen: 2}}}
+	if layout.totalUncompressed() != math.MaxUint64 {
+		t.Error("totalUncompressed should saturate")
+	}
+}
@@ -0,0 +1,332 @@
+/*
+Copyright © 2026 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/logicossoftware/go-mdocx"
+	"github.com/spf13/cobra"
+)
+
+// lintCmd represents the lint command
+var lintCmd = &cobra.Command{
+	Use:   "lint <file>",
+	Short: "Check the style of every markdown file in an .mdocx bundle",
+	Long: `Check every markdown file in an .mdocx bundle against the markdown style
+rules (MDX024-MDX029): a single H1, no skipped heading levels, no trailing
+whitespace, fenced code blocks with a language, images with alt text and no
+bare URLs. Rules are configured like validate's.
+
+With --fix, trailing whitespace is removed and bare URLs are wrapped in <>,
+and the bundle is written to --output, or replaced with --in-place.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if listRules, _ := cmd.Flags().GetBool("list-rules"); listRules {
+			return nil
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fix, _ := cmd.Flags().GetBool("fix")
+		outputPath, _ := cmd.Flags().GetString("output")
+		inPlace, _ := cmd.Flags().GetBool("in-place")
+		jsonOut, _ := cmd.Flags().GetBool("json")
+		strict, _ := cmd.Flags().GetBool("strict")
+		rules, err := rulesFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+		if listRules, _ := cmd.Flags().GetBool("list-rules"); listRules {
+			writeRuleList(cmd.OutOrStdout(), rules, lintRules())
+			return nil
+		}
+		limits, err := limitsFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+		switch {
+		case (outputPath != "" || inPlace) && !fix:
+			return fmt.Errorf("--output and --in-place require --fix")
+		case inPlace && outputPath != "":
+			return fmt.Errorf("--in-place and --output are mutually exclusive")
+		case inPlace:
+			outputPath = args[0]
+		case fix && outputPath == "":
+			return fmt.Errorf("--fix requires --output (or --in-place)")
+		}
+
+		doc, err := decodeContainerFile(args[0], strict, limits)
+		if err != nil {
+			return err
+		}
+		result := lintResult{File: args[0]}
+		if fix {
+			fixed := *doc
+			fixed.Markdown.Files, result.Fixed = fixMarkdownStyle(doc.Markdown.Files, rules)
+			if result.Fixed > 0 || outputPath != args[0] {
+				markdownComp, mediaComp := sectionCompressions(args[0])
+				if err := writeContainerFile(outputPath, &fixed, markdownComp, mediaComp); err != nil {
+					return err
+				}
+				result.Output = outputPath
+			}
+			doc = &fixed
+		}
+		r := &findingReporter{rules: rules}
+		checkMarkdownStyle(doc, r)
+		result.Findings = r.findings
+
+		out := cmd.OutOrStdout()
+		if jsonOut {
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(result); err != nil {
+				return err
+			}
+		} else {
+			writeLintResult(out, result, len(doc.Markdown.Files))
+		}
+		if r.hasErrors() {
+			return fmt.Errorf("lint failed: %s", validationResult{Findings: r.findings}.findingCounts())
+		}
+		return nil
+	},
+}
+
+// lintResult is the JSON output of lint.
+type lintResult struct {
+	File     string    `json:"file"`
+	Findings []finding `json:"findings"`
+	Fixed    int       `json:"fixed,omitempty"`
+	Output   string    `json:"output,omitempty"`
+}
+
+func writeLintResult(w io.Writer, result lintResult, files int) {
+	for _, f := range result.Findings {
+		fmt.Fprintf(w, "%s:%d:%d: %s\n", f.Path, f.Line, f.Column, f)
+	}
+	if result.Output != "" {
+		fmt.Fprintf(w, "Fixed %d issues; wrote %s\n", result.Fixed, result.Output)
+	}
+	if len(result.Findings) == 0 {
+		fmt.Fprintf(w, "No style issues in %d markdown files\n", files)
+		return
+	}
+	fmt.Fprintf(w, "%s in %d markdown files\n", validationResult{Findings: result.Findings}.findingCounts(), files)
+}
+
+// lintRuleIDs are the markdown style rules. validate only runs them with --lint.
+var lintRuleIDs = []ruleID{ruleMultipleH1, ruleHeadingIncrement, ruleTrailingWhitespace, ruleFencedCodeLanguage, ruleImageAltText, ruleBareURL}
+
+// lintRules returns the markdown style rules.
+func lintRules() []validationRule {
+	var out []validationRule
+	for _, r := range validationRules {
+		if slices.Contains(lintRuleIDs, r.ID) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// textEdit replaces content[Start:End] with Text.
+type textEdit struct {
+	Start, End int
+	Text       string
+}
+
+// lintIssue is a style problem in one markdown file. Fix is nil when the
+// problem can't be fixed mechanically.
+type lintIssue struct {
+	Rule         ruleID
+	Offset       int
+	Line, Column int
+	Message      string
+	Fix          *textEdit
+}
+
+var (
+	bareURLPattern     = regexp.MustCompile(`https?://[^\s<>"'` + "`" + `]+`)
+	bracketTextPattern = regexp.MustCompile(`\[[^\[\]\n]*\]`)
+	htmlTagSpanPattern = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+)
+
+// lintMarkdownFile finds the style problems in content, ordered by offset.
+func lintMarkdownFile(content []byte) []lintIssue {
+	d := parseMarkdown(content)
+	var issues []lintIssue
+	add := func(rule ruleID, offset int, format string, args ...any) *lintIssue {
+		issues = append(issues, lintIssue{Rule: rule, Offset: offset, Message: fmt.Sprintf(format, args...)})
+		return &issues[len(issues)-1]
+	}
+
+	h1s, prev := 0, 0
+	for _, h := range d.Headings {
+		offset := d.lineStarts[h.Line-1]
+		if h.Level == 1 {
+			if h1s++; h1s > 1 {
+				add(ruleMultipleH1, offset, "more than one level-1 heading (%q)", h.Text)
+			}
+		}
+		if prev > 0 && h.Level > prev+1 {
+			add(ruleHeadingIncrement, offset, "heading level jumps from %d to %d (%q)", prev, h.Level, h.Text)
+		}
+		prev = h.Level
+	}
+
+	inFence := make(map[int]bool)
+	for _, f := range d.Fences {
+		end := f.EndLine
+		if end == 0 {
+			end = d.lineCount()
+		}
+		for n := f.StartLine; n <= end; n++ {
+			inFence[n] = true
+		}
+		if f.Info == "" {
+			add(ruleFencedCodeLanguage, d.lineStarts[f.StartLine-1], "fenced code block has no language tag")
+		}
+	}
+
+	for n := 1; n <= d.lineCount(); n++ {
+		if inFence[n] {
+			continue
+		}
+		l := d.line(d.content, n)
+		text := bytes.TrimRight(l, " \t")
+		ws := len(l) - len(text)
+		// Two or more trailing spaces after text are a markdown hard line
+		// break. line drops the \r of a CRLF ending, so it never counts.
+		if ws == 0 || (len(text) > 0 && ws >= 2 && bytes.Count(l[len(text):], []byte(" ")) == ws) {
+			continue
+		}
+		start := d.lineStarts[n-1] + len(text)
+		add(ruleTrailingWhitespace, start, "trailing whitespace").Fix = &textEdit{Start: start, End: start + ws}
+	}
+
+	for _, link := range d.Links {
+		switch {
+		case link.Kind == "inline" && link.Image && strings.TrimSpace(link.Text) == "":
+			add(ruleImageAltText, link.Start, "image %q has no alt text", link.Target)
+		case link.Kind == "html" && link.Image:
+			lt := bytes.LastIndexByte(content[:link.Start], '<')
+			gt := bytes.IndexByte(content[link.Start:], '>')
+			if lt < 0 || gt < 0 {
+				continue
+			}
+			tag := strings.ToLower(string(content[lt+1 : link.Start+gt]))
+			if strings.HasPrefix(tag, "img") && len(findHTMLAttribute(tag, "alt")) == 0 {
+				add(ruleImageAltText, lt, "image %q has no alt attribute", link.Target)
+			}
+		}
+	}
+
+	for _, url := range bareURLs(d) {
+		target := string(content[url[0]:url[1]])
+		add(ruleBareURL, url[0], "bare URL %s; wrap it in <> or make it a link", target).Fix =
+			&textEdit{Start: url[0], End: url[1], Text: "<" + target + ">"}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Offset < issues[j].Offset })
+	for i := range issues {
+		issues[i].Line, issues[i].Column = d.position(issues[i].Offset)
+	}
+	return issues
+}
+
+// bareURLs returns the bounds of http(s) URLs in text that aren't already a
+// link target, autolink, link text or part of an HTML tag.
+func bareURLs(d *markdownDoc) [][2]int {
+	var skip [][2]int
+	for _, link := range d.Links {
+		skip = append(skip, [2]int{link.Start, link.End})
+	}
+	for _, m := range bracketTextPattern.FindAllIndex(d.masked, -1) {
+		skip = append(skip, [2]int{m[0], m[1]})
+	}
+	for _, m := range htmlTagSpanPattern.FindAllIndex(d.masked, -1) {
+		skip = append(skip, [2]int{m[0], m[1]})
+	}
+	var out [][2]int
+	for _, m := range bareURLPattern.FindAllIndex(d.masked, -1) {
+		start, end := m[0], m[1]
+		// Trailing punctuation and an unbalanced ')' end the sentence, not the URL.
+		for end > start {
+			c := d.masked[end-1]
+			if strings.IndexByte(".,;:!?*_~", c) >= 0 ||
+				(c == ')' && bytes.Count(d.masked[start:end], []byte("(")) < bytes.Count(d.masked[start:end], []byte(")"))) {
+				end--
+				continue
+			}
+			break
+		}
+		if !slices.ContainsFunc(skip, func(s [2]int) bool { return start < s[1] && end > s[0] }) {
+			out = append(out, [2]int{start, end})
+		}
+	}
+	return out
+}
+
+// checkMarkdownStyle reports the style problems in every markdown file.
+func checkMarkdownStyle(doc *mdocx.Document, r *findingReporter) {
+	for _, mf := range doc.Markdown.Files {
+		for _, is := range lintMarkdownFile(mf.Content) {
+			r.report(is.Rule, finding{Message: is.Message, Path: mf.Path, Offset: offsetPtr(int64(is.Offset)), Line: is.Line, Column: is.Column})
+		}
+	}
+}
+
+// fixMarkdownStyle applies the mechanical fixes for every enabled rule and
+// returns the fixed files and the number of fixes applied.
+func fixMarkdownStyle(files []mdocx.MarkdownFile, rules ruleSet) ([]mdocx.MarkdownFile, int) {
+	out := make([]mdocx.MarkdownFile, len(files))
+	total := 0
+	for i, mf := range files {
+		out[i] = mf
+		var edits []textEdit
+		for _, is := range lintMarkdownFile(mf.Content) {
+			if rule, _ := lookupRule(string(is.Rule)); is.Fix != nil && rules.severity(rule) != severityOff {
+				edits = append(edits, *is.Fix)
+			}
+		}
+		if len(edits) == 0 {
+			continue
+		}
+		out[i].Content = applyTextEdits(mf.Content, edits)
+		total += len(edits)
+	}
+	return out, total
+}
+
+// applyTextEdits applies non-overlapping edits, sorted by Start.
+func applyTextEdits(content []byte, edits []textEdit) []byte {
+	var buf bytes.Buffer
+	last := 0
+	for _, e := range edits {
+		buf.Write(content[last:e.Start])
+		buf.WriteString(e.Text)
+		last = e.End
+	}
+	buf.Write(content[last:])
+	return buf.Bytes()
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+
+	lintCmd.Flags().Bool("fix", false, "remove trailing whitespace and wrap bare URLs in <>, rewriting the bundle")
+	lintCmd.Flags().StringP("output", "o", "", "with --fix, write the fixed bundle here")
+	lintCmd.Flags().Bool("in-place", false, "with --fix, replace the input bundle")
+	lintCmd.Flags().Bool("json", false, "output machine-readable JSON")
+	lintCmd.Flags().Bool("strict", true, "fail on any spec violation")
+	addLimitFlags(lintCmd)
+	addRuleFlags(lintCmd)
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/logicossoftware/go-mdocx"
+)
+
+const lintTestMarkdown = "# Title\n" +
+	"\n" +
+	"Some text with a break  \n" +
+	"and trailing spaces \n" +
+	"\n" +
+	"### Skipped\n" +
+	"\n" +
+	"See https://example.com/docs. Or [https://example.com](https://example.com) or <https://example.com>.\n" +
+	"\n" +
+	"![](img.png) ![ok](img.png) <img src=\"img.png\"> <img alt=\"x\" src=\"img.png\">\n" +
+	"\n" +
+	"```\n" +
+	"code with trailing space \n" +
+	"https://example.com/in-code\n" +
+	"```\n" +
+	"\n" +
+	"# Second\n"
+
+func TestLintMarkdownFile(t *testing.T) {
+	var got []string
+	for _, is := range lintMarkdownFile([]byte(lintTestMarkdown)) {
+		got = append(got, fmt.Sprintf("%s %d:%d %s", is.Rule, is.Line, is.Column, is.Message))
+	}
+	want := []string{
+		`MDX026 4:20 trailing whitespace`,
+		`MDX025 6:1 heading level jumps from 1 to 3 ("Skipped")`,
+		`MDX029 8:5 bare URL https://example.com/docs; wrap it in <> or make it a link`,
+		`MDX028 10:5 image "img.png" has no alt text`,
+		`MDX028 10:29 image "img.png" has no alt attribute`,
+		`MDX027 12:1 fenced code block has no language tag`,
+		`MDX024 17:1 more than one level-1 heading ("Second")`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("issues:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestFixMarkdownStyle(t *testing.T) {
+	files := []mdocx.MarkdownFile{{Path: "a.md", Content: []byte(lintTestMarkdown)}}
+	fixed, n := fixMarkdownStyle(files, nil)
+	if n != 2 {
+		t.Fatalf("expected 2 fixes, got %d", n)
+	}
+	content := string(fixed[0].Content)
+	for _, want := range []string{
+		"Some text with a break  \nand trailing spaces\n",
+		"See <https://example.com/docs>. Or [https://example.com]",
+		"code with trailing space \n",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("fixed content missing %q:\n%s", want, content)
+		}
+	}
+	if string(files[0].Content) != lintTestMarkdown {
+		t.Error("fixMarkdownStyle modified its input")
+	}
+
+	if _, n := fixMarkdownStyle(files, ruleSet{"MDX029": severityOff}); n != 1 {
+		t.Errorf("disabled rules should not be fixed, got %d fixes", n)
+	}
+}
+
+func TestLintTrailingWhitespace(t *testing.T) {
+	content := "hard break   \n" +
+		"tab \t\n" +
+		"   \n" +
+		"crlf break  \r\n" +
+		"crlf space \r\n"
+	var got []string
+	for _, is := range lintMarkdownFile([]byte(content)) {
+		got = append(got, fmt.Sprintf("%s %d:%d", is.Rule, is.Line, is.Column))
+	}
+	want := []string{"MDX026 2:4", "MDX026 3:1", "MDX026 5:11"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("issues:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	fixed, _ := fixMarkdownStyle([]mdocx.MarkdownFile{{Path: "a.md", Content: []byte(content)}}, nil)
+	if want := "hard break   \ntab\n\ncrlf break  \r\ncrlf space\r\n"; string(fixed[0].Content) != want {
+		t.Errorf("fixed content = %q, want %q", fixed[0].Content, want)
+	}
+}
+
+func writeLintTestFile(t *testing.T) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "lint.mdocx")
+	doc := &mdocx.Document{
+		Markdown: mdocx.MarkdownBundle{
+			BundleVersion: mdocx.VersionV1,
+			Files: []mdocx.MarkdownFile{
+				{Path: "readme.md", Content: []byte(lintTestMarkdown)},
+				{Path: "clean.md", Content: []byte("# Clean\n\nNothing to see.\n")},
+			},
+		},
+		Media: mdocx.MediaBundle{BundleVersion: mdocx.VersionV1},
+	}
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := mdocx.Encode(f, doc, mdocx.WithMarkdownCompression(mdocx.CompZSTD)); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestLintCommand(t *testing.T) {
+	p := writeLintTestFile(t)
+	out, err := executeCommand(rootCmd, "lint", p)
+	if err != nil {
+		t.Fatalf("warnings should not fail lint: %v", err)
+	}
+	if !strings.Contains(out, "readme.md:4:20: WARNING: trailing whitespace [MDX026 trailing-whitespace]") ||
+		!strings.Contains(out, "7 warnings in 2 markdown files") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	if _, err := executeCommand(rootCmd, "lint", "--rule-severity", "multiple-h1=error", p); err == nil || !strings.Contains(err.Error(), "1 error") {
+		t.Errorf("expected an error-level finding to fail lint, got %v", err)
+	}
+	if _, err := executeCommand(rootCmd, "lint", "-o", filepath.Join(t.TempDir(), "x.mdocx"), p); err == nil {
+		t.Error("expected --output without --fix to fail")
+	}
+	before, _ := os.ReadFile(p)
+	if out, err := executeCommand(rootCmd, "lint", "--fix", p); err == nil || !strings.Contains(out, "--fix requires --output") {
+		t.Errorf("expected --fix without --output or --in-place to fail, got %v:\n%s", err, out)
+	}
+	if after, _ := os.ReadFile(p); string(before) != string(after) {
+		t.Error("lint --fix without --output modified the input")
+	}
+
+	fixedPath := filepath.Join(t.TempDir(), "fixed.mdocx")
+	out, err = executeCommand(rootCmd, "lint", "--fix", "--json", "-o", fixedPath, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result lintResult
+	if err := json.NewDecoder(strings.NewReader(out)).Decode(&result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if result.Fixed != 2 || len(result.Findings) != 5 || result.Output != fixedPath {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if mdComp, _ := sectionCompressions(fixedPath); mdComp != mdocx.CompZSTD {
+		t.Errorf("expected the markdown section to stay zstd, got %v", mdComp)
+	}
+
+	if out, err := executeCommand(rootCmd, "lint", "--fix", "--in-place", p); err != nil || !strings.Contains(out, "Fixed 2 issues; wrote "+p) {
+		t.Errorf("lint --fix --in-place: %v\n%s", err, out)
+	}
+
+	out, _ = executeCommand(rootCmd, "lint", "--list-rules")
+	if !strings.Contains(out, "MDX029") || strings.Contains(out, "MDX001") {
+		t.Errorf("expected only the lint rules:\n%s", out)
+	}
+}
+
+func TestValidateCommand_Lint(t *testing.T) {
+	p := writeLintTestFile(t)
+	// img.png isn't in the bundle.
+	out, err := executeCommand(rootCmd, "validate", "--disable-rule", "MDX010", p)
+	if err != nil || strings.Contains(out, "MDX026") {
+		t.Errorf("validate should not lint by default: %v\n%s", err, out)
+	}
+	out, err = executeCommand(rootCmd, "validate", "--lint", "--disable-rule", "MDX010", p)
+	if err != nil || !strings.Contains(out, "WARNING: trailing whitespace [MDX026 trailing-whitespace]") {
+		t.Errorf("expected lint findings with --lint: %v\n%s", err, out)
+	}
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/logicossoftware/go-mdocx"
+)
+
+// manifestFileName is the checksum manifest written by unpack --manifest.
+const manifestFileName = "SHA256SUMS"
+
+// buildManifest renders a SHA256SUMS-compatible manifest ("<hex>  <path>")
+// covering entries, sorted by path.
+func buildManifest(entries []unpackEntry) []byte {
+	sorted := append([]unpackEntry(nil), entries...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Path < sorted[j].Path })
+
+	var b strings.Builder
+	for _, e := range sorted {
+		sum := sha256.Sum256(e.Data)
+		fmt.Fprintf(&b, "%s  %s\n", hex.EncodeToString(sum[:]), e.Path)
+	}
+	return []byte(b.String())
+}
+
+// writeManifest writes the manifest for entries into outDir.
+func writeManifest(entries []unpackEntry, outDir string, force bool, out io.Writer) error {
+	b := buildManifest(entries)
+	p := filepath.Join(outDir, manifestFileName)
+	if err := checkOverwrite(p, force); err != nil {
+		return err
+	}
+	if err := os.WriteFile(p, b, 0o644); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	fmt.Fprintf(out, "wrote %s\n", p)
+	return nil
+}
+
+// dirVerification compares an unpacked directory against its container.
+// Paths are slash-separated and relative to the directory.
+type dirVerification struct {
+	OK       bool     `json:"ok"`
+	Matched  int      `json:"matched"`
+	Missing  []string `json:"missing"`
+	Modified []string `json:"modified"`
+	Extra    []string `json:"extra"`
+}
+
+// verifyUnpackedDir reports which files that unpacking doc would produce are
+// missing from dir or differ in content, and which files in dir the container
+// doesn't account for. A manifest at the top of dir is not counted as extra.
+func verifyUnpackedDir(doc *mdocx.Document, dir string) (dirVerification, error) {
+	v := dirVerification{
+		Missing:  make([]string, 0),
+		Modified: make([]string, 0),
+		Extra:    make([]string, 0),
+	}
+	stat, err := os.Stat(dir)
+	if err != nil {
+		return v, err
+	}
+	if !stat.IsDir() {
+		return v, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	entries, err := unpackEntries(doc)
+	if err != nil {
+		return v, err
+	}
+	expected := make(map[string]struct{}, len(entries))
+	for _, e := range entries {
+		expected[e.Path] = struct{}{}
+		p, err := safeJoinOutput(dir, e.Path)
+		if err != nil {
+			return v, err
+		}
+		b, err := os.ReadFile(p)
+		if err != nil {
+			if os.IsNotExist(err) {
+				v.Missing = append(v.Missing, e.Path)
+				continue
+			}
+			return v, err
+		}
+		if !bytes.Equal(b, e.Data) {
+			v.Modified = append(v.Modified, e.Path)
+			continue
+		}
+		v.Matched++
+	}
+
+	onDisk, err := collectFiles(dir, func(rel string, info os.DirEntry) bool {
+		return !info.IsDir()
+	})
+	if err != nil {
+		return v, err
+	}
+	for _, rel := range onDisk {
+		rel = filepath.ToSlash(rel)
+		if rel == manifestFileName {
+			continue
+		}
+		if _, ok := expected[rel]; !ok {
+			v.Extra = append(v.Extra, rel)
+		}
+	}
+
+	sort.Strings(v.Missing)
+	sort.Strings(v.Modified)
+	sort.Strings(v.Extra)
+	v.OK = len(v.Missing) == 0 && len(v.Modified) == 0 && len(v.Extra) == 0
+	return v, nil
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnpackCommand_Manifest(t *testing.T) {
+	tmp := t.TempDir()
+	mdocxPath := createTestMDOCX(t, filepath.Join(tmp, "test.mdocx"), map[string]any{"k": "v"})
+	outDir := filepath.Join(tmp, "out")
+
+	if _, err := executeCommand(rootCmd, "unpack", "-o", outDir, "--manifest", mdocxPath); err != nil {
+		t.Fatalf("unpack --manifest failed: %v", err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(outDir, manifestFileName))
+	if err != nil {
+		t.Fatalf("read manifest: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	// metadata.json, two markdown files and one media item.
+	if len(lines) != 4 {
+		t.Fatalf("expected 4 manifest lines, got %d:\n%s", len(lines), b)
+	}
+	for _, line := range lines {
+		sum, rel, ok := strings.Cut(line, "  ")
+		if !ok {
+			t.Fatalf("malformed manifest line %q", line)
+		}
+		data, err := os.ReadFile(filepath.Join(outDir, filepath.FromSlash(rel)))
+		if err != nil {
+			t.Fatalf("manifest lists %s: %v", rel, err)
+		}
+		want := sha256.Sum256(data)
+		if sum != hex.EncodeToString(want[:]) {
+			t.Errorf("checksum mismatch for %s", rel)
+		}
+	}
+	if !strings.Contains(string(b), "  readme.md\n") {
+		t.Errorf("expected markdown to be covered, got:\n%s", b)
+	}
+}
+
+func TestVerifyDirCommand_Matches(t *testing.T) {
+	tmp := t.TempDir()
+	mdocxPath := createTestMDOCX(t, filepath.Join(tmp, "test.mdocx"), map[string]any{"k": "v"})
+	outDir := filepath.Join(tmp, "out")
+	if _, err := executeCommand(rootCmd, "unpack", "-o", outDir, "--manifest", mdocxPath); err != nil {
+		t.Fatalf("unpack failed: %v", err)
+	}
+
+	output, err := executeCommand(rootCmd, "verify-dir", mdocxPath, outDir)
+	if err != nil {
+		t.Fatalf("verify-dir failed: %v\noutput: %s", err, output)
+	}
+	if !strings.Contains(output, "4 files verified") {
+		t.Errorf("unexpected output: %s", output)
+	}
+}
+
+func TestVerifyDirCommand_ReportsDifferences(t *testing.T) {
+	tmp := t.TempDir()
+	mdocxPath := createTestMDOCX(t, filepath.Join(tmp, "test.mdocx"), nil)
+	outDir := filepath.Join(tmp, "out")
+	if _, err := executeCommand(rootCmd, "unpack", "-o", outDir, mdocxPath); err != nil {
+		t.Fatalf("unpack failed: %v", err)
+	}
+
+	if err := os.Remove(filepath.Join(outDir, "docs", "guide.md")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outDir, "readme.md"), []byte("changed"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outDir, "notes.txt"), []byte("extra"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := executeCommand(rootCmd, "verify-dir", mdocxPath, outDir)
+	if err == nil {
+		t.Fatal("expected verify-dir to fail")
+	}
+	for _, want := range []string{"MISSING  docs/guide.md", "MODIFIED readme.md", "EXTRA    notes.txt"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, output)
+		}
+	}
+
+	// The command error and usage follow the JSON document in the output.
+	output, _ = executeCommand(rootCmd, "verify-dir", "--json", mdocxPath, outDir)
+	var result dirVerification
+	if err := json.NewDecoder(strings.NewReader(output)).Decode(&result); err != nil {
+		t.Fatalf("parse JSON: %v\noutput: %s", err, output)
+	}
+	if result.OK || result.Matched != 1 || len(result.Missing) != 1 || len(result.Modified) != 1 || len(result.Extra) != 1 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestVerifyUnpackedDir_NotADirectory(t *testing.T) {
+	tmp := t.TempDir()
+	f := filepath.Join(tmp, "file")
+	if err := os.WriteFile(f, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifyUnpackedDir(limitTestDoc(), f); err == nil {
+		t.Fatal("expected error for non-directory")
+	}
+}
@@ -0,0 +1,670 @@
+package cmd
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// markdownDoc is a lightweight, position-preserving view of a markdown file.
+// It is not a full CommonMark parser: it recognizes fenced code blocks,
+// inline code spans, headings, links, images, reference definitions,
+// autolinks and HTML src/href/srcset attributes, which is what the CLI needs
+// to inspect and rewrite references.
+type markdownDoc struct {
+	content    []byte
+	masked     []byte // content with code blocks, code spans and front matter blanked out
+	lineStarts []int
+	Fences     []codeFence
+	Headings   []markdownHeading
+	Links      []markdownLink
+}
+
+// codeFence is a fenced code block. Lines are 1-based.
+type codeFence struct {
+	StartLine int
+	EndLine   int // 0 when the block is never closed
+	Info      string
+}
+
+// markdownHeading is an ATX or setext heading.
+type markdownHeading struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+	Line  int    `json:"line"`
+}
+
+// markdownLink is a reference to another resource found in markdown.
+// Start and End delimit Target in the original content, so it can be
+// rewritten in place.
+type markdownLink struct {
+	Image  bool
+	Kind   string // "inline", "definition", "autolink" or "html"
+	Text   string
+	Target string
+	Start  int
+	End    int
+	Line   int
+	Col    int
+}
+
+// parseMarkdown scans content for the structures described on markdownDoc.
+func parseMarkdown(content []byte) *markdownDoc {
+	d := &markdownDoc{content: content}
+	d.lineStarts = append(d.lineStarts, 0)
+	for i, b := range content {
+		if b == '\n' {
+			d.lineStarts = append(d.lineStarts, i+1)
+		}
+	}
+	d.masked = make([]byte, len(content))
+	copy(d.masked, content)
+	d.maskBlocks()
+	d.maskCodeSpans()
+	d.scanHeadings()
+	d.scanLinks()
+	sort.SliceStable(d.Links, func(i, j int) bool { return d.Links[i].Start < d.Links[j].Start })
+	return d
+}
+
+// line returns the bytes of 1-based line n without its line ending.
+func (d *markdownDoc) line(buf []byte, n int) []byte {
+	start := d.lineStarts[n-1]
+	end := len(buf)
+	if n < len(d.lineStarts) {
+		end = d.lineStarts[n] - 1
+	}
+	return bytes.TrimSuffix(buf[start:end], []byte("\r"))
+}
+
+// lineCount returns the number of lines, not counting a trailing empty line.
+func (d *markdownDoc) lineCount() int {
+	n := len(d.lineStarts)
+	if n > 1 && d.lineStarts[n-1] == len(d.content) {
+		n--
+	}
+	return n
+}
+
+// position converts a byte offset into a 1-based line and rune column.
+func (d *markdownDoc) position(offset int) (int, int) {
+	i := sort.Search(len(d.lineStarts), func(i int) bool { return d.lineStarts[i] > offset }) - 1
+	if i < 0 {
+		i = 0
+	}
+	col := utf8.RuneCount(d.content[d.lineStarts[i]:offset]) + 1
+	return i + 1, col
+}
+
+// blankLine replaces line n in the masked copy with spaces.
+func (d *markdownDoc) blankLine(n int) {
+	start := d.lineStarts[n-1]
+	end := len(d.masked)
+	if n < len(d.lineStarts) {
+		end = d.lineStarts[n] - 1
+	}
+	for i := start; i < end; i++ {
+		if d.masked[i] != '\r' {
+			d.masked[i] = ' '
+		}
+	}
+}
+
+// maskBlocks blanks YAML/TOML front matter and fenced code blocks.
+func (d *markdownDoc) maskBlocks() {
+	lines := d.lineCount()
+	n := 1
+	if lines > 0 {
+		first := string(d.line(d.content, 1))
+		if first == "---" || first == "+++" {
+			for end := 2; end <= lines; end++ {
+				if string(d.line(d.content, end)) == first {
+					for i := 1; i <= end; i++ {
+						d.blankLine(i)
+					}
+					n = end + 1
+					break
+				}
+			}
+		}
+	}
+
+	for ; n <= lines; n++ {
+		char, length, info, ok := parseFenceOpen(d.line(d.content, n))
+		if !ok {
+			continue
+		}
+		fence := codeFence{StartLine: n, Info: info}
+		d.blankLine(n)
+		for n++; n <= lines; n++ {
+			l := d.line(d.content, n)
+			d.blankLine(n)
+			if isFenceClose(l, char, length) {
+				fence.EndLine = n
+				break
+			}
+		}
+		d.Fences = append(d.Fences, fence)
+	}
+}
+
+// parseFenceOpen reports whether l opens a fenced code block.
+func parseFenceOpen(l []byte) (byte, int, string, bool) {
+	s := strings.TrimLeft(string(l), " ")
+	if len(string(l))-len(s) > 3 || len(s) < 3 {
+		return 0, 0, "", false
+	}
+	char := s[0]
+	if char != '`' && char != '~' {
+		return 0, 0, "", false
+	}
+	length := 0
+	for length < len(s) && s[length] == char {
+		length++
+	}
+	if length < 3 {
+		return 0, 0, "", false
+	}
+	info := strings.TrimSpace(s[length:])
+	if char == '`' && strings.Contains(info, "`") {
+		return 0, 0, "", false
+	}
+	return char, length, info, true
+}
+
+// isFenceClose reports whether l closes a fence opened with length chars.
+func isFenceClose(l []byte, char byte, length int) bool {
+	s := strings.TrimLeft(string(l), " ")
+	if len(string(l))-len(s) > 3 {
+		return false
+	}
+	s = strings.TrimRight(s, " \t")
+	if len(s) < length {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] != char {
+			return false
+		}
+	}
+	return true
+}
+
+// maskCodeSpans blanks inline code spans (including their backticks) on
+// each line outside code blocks.
+func (d *markdownDoc) maskCodeSpans() {
+	m := d.masked
+	for i := 0; i < len(m); i++ {
+		if m[i] == '\\' {
+			i++
+			continue
+		}
+		if m[i] != '`' {
+			continue
+		}
+		run := 1
+		for i+run < len(m) && m[i+run] == '`' {
+			run++
+		}
+		// Find a closing run of the same length before the end of the line.
+		end := -1
+		for j := i + run; j < len(m) && m[j] != '\n'; j++ {
+			if m[j] != '`' {
+				continue
+			}
+			k := 1
+			for j+k < len(m) && m[j+k] == '`' {
+				k++
+			}
+			if k == run {
+				end = j + k
+				break
+			}
+			j += k - 1
+		}
+		if end < 0 {
+			i += run - 1
+			continue
+		}
+		for k := i; k < end; k++ {
+			m[k] = ' '
+		}
+		i = end - 1
+	}
+}
+
+// scanHeadings finds ATX and setext headings outside code blocks.
+func (d *markdownDoc) scanHeadings() {
+	lines := d.lineCount()
+	for n := 1; n <= lines; n++ {
+		l := string(d.line(d.masked, n))
+		trimmed := strings.TrimLeft(l, " ")
+		if len(l)-len(trimmed) <= 3 && strings.HasPrefix(trimmed, "#") {
+			level := 0
+			for level < len(trimmed) && trimmed[level] == '#' {
+				level++
+			}
+			if level <= 6 && (level == len(trimmed) || trimmed[level] == ' ' || trimmed[level] == '\t') {
+				text := strings.TrimSpace(strings.TrimLeft(d.headingLine(n), " ")[level:])
+				// Drop an optional closing sequence of #s.
+				if stripped := strings.TrimRight(text, "#"); stripped == "" || strings.HasSuffix(stripped, " ") {
+					text = strings.TrimSpace(stripped)
+				}
+				d.Headings = append(d.Headings, markdownHeading{Level: level, Text: text, Line: n})
+				continue
+			}
+		}
+		if n == 1 || strings.TrimSpace(l) == "" {
+			continue
+		}
+		underline := strings.TrimSpace(l)
+		level := 0
+		switch {
+		case strings.Trim(underline, "=") == "":
+			level = 1
+		case strings.Trim(underline, "-") == "" && len(underline) >= 2:
+			level = 2
+		default:
+			continue
+		}
+		prev := string(d.line(d.masked, n-1))
+		prevTrimmed := strings.TrimSpace(prev)
+		if prevTrimmed == "" || strings.HasPrefix(strings.TrimLeft(prev, " "), "#") || isListOrQuote(prevTrimmed) {
+			continue
+		}
+		if len(d.Headings) > 0 && d.Headings[len(d.Headings)-1].Line == n-1 {
+			continue
+		}
+		d.Headings = append(d.Headings, markdownHeading{Level: level, Text: strings.TrimSpace(d.headingLine(n - 1)), Line: n - 1})
+	}
+}
+
+// headingLine returns line n as heading text: the original content, with
+// the text of code spans kept and only their backticks dropped.
+func (d *markdownDoc) headingLine(n int) string {
+	orig, masked := d.line(d.content, n), d.line(d.masked, n)
+	var b strings.Builder
+	for i, c := range orig {
+		if c == '`' && masked[i] == ' ' {
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// isListOrQuote reports whether a trimmed line starts a list item or quote,
+// which can't be the text of a setext heading.
+func isListOrQuote(s string) bool {
+	if strings.HasPrefix(s, ">") || strings.HasPrefix(s, "- ") || strings.HasPrefix(s, "* ") || strings.HasPrefix(s, "+ ") {
+		return true
+	}
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return i > 0 && i < len(s) && (s[i] == '.' || s[i] == ')')
+}
+
+// scanLinks finds inline links and images, reference definitions, autolinks
+// and HTML src/href attributes.
+func (d *markdownDoc) scanLinks() {
+	m := d.masked
+	for i := 0; i < len(m); i++ {
+		switch m[i] {
+		case '\\':
+			i++
+		case '[':
+			if next, ok := d.scanDefinition(i); ok {
+				i = next - 1
+				continue
+			}
+			if next, ok := d.scanInlineLink(i); ok {
+				i = next - 1
+			}
+		case '<':
+			if next, ok := d.scanAngle(i); ok {
+				i = next - 1
+			}
+		}
+	}
+}
+
+func (d *markdownDoc) addLink(link markdownLink) {
+	link.Target = string(d.content[link.Start:link.End])
+	link.Line, link.Col = d.position(link.Start)
+	d.Links = append(d.Links, link)
+}
+
+// findBracketClose returns the index of the ']' matching the '[' at open,
+// or -1. Link text may not contain a blank line.
+func (d *markdownDoc) findBracketClose(open int) int {
+	m := d.masked
+	depth := 0
+	for i := open; i < len(m); i++ {
+		switch m[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		case '\n':
+			if j := i + 1; j < len(m) && (m[j] == '\n' || (m[j] == '\r' && j+1 < len(m) && m[j+1] == '\n')) {
+				return -1
+			}
+		}
+	}
+	return -1
+}
+
+// scanDefinition parses a reference definition "[label]: target" at the
+// start of a line.
+func (d *markdownDoc) scanDefinition(open int) (int, bool) {
+	m := d.masked
+	lineStart := open
+	for lineStart > 0 && m[lineStart-1] != '\n' {
+		lineStart--
+	}
+	if open-lineStart > 3 || strings.TrimSpace(string(m[lineStart:open])) != "" {
+		return 0, false
+	}
+	if open > 0 && m[open-1] == '!' {
+		return 0, false
+	}
+	closeIdx := d.findBracketClose(open)
+	if closeIdx < 0 || closeIdx+1 >= len(m) || m[closeIdx+1] != ':' {
+		return 0, false
+	}
+	i := closeIdx + 2
+	for i < len(m) && (m[i] == ' ' || m[i] == '\t') {
+		i++
+	}
+	start, end, next, ok := scanDestination(m, i)
+	if !ok || start == end {
+		return 0, false
+	}
+	d.addLink(markdownLink{Kind: "definition", Text: string(d.content[open+1 : closeIdx]), Start: start, End: end})
+	return next, true
+}
+
+// scanInlineLink parses "[text](target ...)" or "![alt](target ...)".
+func (d *markdownDoc) scanInlineLink(open int) (int, bool) {
+	m := d.masked
+	closeIdx := d.findBracketClose(open)
+	if closeIdx < 0 || closeIdx+1 >= len(m) || m[closeIdx+1] != '(' {
+		return 0, false
+	}
+	i := closeIdx + 2
+	for i < len(m) && (m[i] == ' ' || m[i] == '\t' || m[i] == '\n') {
+		i++
+	}
+	start, end, next, ok := scanDestination(m, i)
+	if !ok {
+		return 0, false
+	}
+	// Skip an optional title up to the closing parenthesis.
+	depth := 0
+	for next < len(m) {
+		c := m[next]
+		if c == '\\' {
+			next += 2
+			continue
+		}
+		if c == '(' {
+			depth++
+		}
+		if c == ')' {
+			if depth == 0 {
+				break
+			}
+			depth--
+		}
+		next++
+	}
+	if next >= len(m) {
+		return 0, false
+	}
+	image := open > 0 && m[open-1] == '!' && (open < 2 || m[open-2] != '\\')
+	link := markdownLink{Image: image, Kind: "inline", Text: string(d.content[open+1 : closeIdx]), Start: start, End: end}
+	if start < end {
+		d.addLink(link)
+	}
+	// Continue inside the link text so nested images (badges) are found too.
+	return open + 1, true
+}
+
+// scanDestination reads a link destination starting at i, either "<...>" or
+// a run of non-space characters with balanced parentheses. It returns the
+// destination's bounds and the index after it.
+func scanDestination(m []byte, i int) (int, int, int, bool) {
+	if i < len(m) && m[i] == '<' {
+		for j := i + 1; j < len(m); j++ {
+			switch m[j] {
+			case '\n', '<':
+				return 0, 0, 0, false
+			case '\\':
+				j++
+			case '>':
+				return i + 1, j, j + 1, true
+			}
+		}
+		return 0, 0, 0, false
+	}
+	depth := 0
+	j := i
+	for ; j < len(m); j++ {
+		c := m[j]
+		if c == '\\' {
+			j++
+			continue
+		}
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c < 0x20 {
+			break
+		}
+		if c == '(' {
+			depth++
+		}
+		if c == ')' {
+			if depth == 0 {
+				break
+			}
+			depth--
+		}
+	}
+	if j > len(m) {
+		j = len(m)
+	}
+	return i, j, j, true
+}
+
+// htmlLinkAttributes are the attributes whose values reference resources.
+// srcset holds a comma-separated list of them.
+var htmlLinkAttributes = []string{"src", "href", "poster", "data", "srcset"}
+
+// scanAngle parses an autolink "<scheme:...>" or an HTML tag, recording any
+// resource-referencing attributes.
+func (d *markdownDoc) scanAngle(open int) (int, bool) {
+	m := d.masked
+	end := bytes.IndexByte(m[open:], '>')
+	if end < 0 {
+		return 0, false
+	}
+	end += open
+	inner := string(m[open+1 : end])
+	if inner == "" || strings.ContainsAny(inner, "\n<") && !isHTMLTagStart(inner) {
+		return 0, false
+	}
+
+	if isAutolink(inner) {
+		d.addLink(markdownLink{Kind: "autolink", Start: open + 1, End: end})
+		return end + 1, true
+	}
+	if !isHTMLTagStart(inner) {
+		return 0, false
+	}
+
+	tag := strings.ToLower(inner)
+	image := strings.HasPrefix(tag, "img") || strings.HasPrefix(tag, "source") || strings.HasPrefix(tag, "video") || strings.HasPrefix(tag, "audio")
+	for _, attr := range htmlLinkAttributes {
+		for _, idx := range findHTMLAttribute(tag, attr) {
+			start, stop, ok := attributeValueBounds(inner, idx)
+			if !ok || start == stop {
+				continue
+			}
+			if attr != "srcset" {
+				d.addLink(markdownLink{Image: image && attr != "href", Kind: "html", Start: open + 1 + start, End: open + 1 + stop})
+				continue
+			}
+			for _, c := range srcsetCandidates(inner[start:stop]) {
+				d.addLink(markdownLink{Image: image, Kind: "html", Start: open + 1 + start + c[0], End: open + 1 + start + c[1]})
+			}
+		}
+	}
+	return end + 1, true
+}
+
+// isAutolink reports whether s (the text between angle brackets) is an
+// absolute URI or email autolink.
+func isAutolink(s string) bool {
+	if strings.ContainsAny(s, " \t\n<>") {
+		return false
+	}
+	if colon := strings.Index(s, ":"); colon >= 2 && colon <= 32 {
+		for i := 0; i < colon; i++ {
+			c := s[i]
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && (c >= '0' && c <= '9' || c == '+' || c == '.' || c == '-')) {
+				return false
+			}
+		}
+		return true
+	}
+	at := strings.Index(s, "@")
+	return at > 0 && strings.Contains(s[at:], ".")
+}
+
+// isHTMLTagStart reports whether s begins like an HTML open tag.
+func isHTMLTagStart(s string) bool {
+	if s == "" {
+		return false
+	}
+	c := s[0]
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// findHTMLAttribute returns the indexes in tag where attr begins as an
+// attribute name followed by '='.
+func findHTMLAttribute(tag, attr string) []int {
+	var out []int
+	for i := 0; ; {
+		idx := strings.Index(tag[i:], attr)
+		if idx < 0 {
+			return out
+		}
+		idx += i
+		before := byte(' ')
+		if idx > 0 {
+			before = tag[idx-1]
+		}
+		rest := strings.TrimLeft(tag[idx+len(attr):], " \t\n")
+		if (before == ' ' || before == '\t' || before == '\n') && strings.HasPrefix(rest, "=") {
+			out = append(out, idx)
+		}
+		i = idx + len(attr)
+	}
+}
+
+// attributeValueBounds returns the bounds of the value of the attribute
+// whose name starts at idx in tag.
+func attributeValueBounds(tag string, idx int) (int, int, bool) {
+	eq := strings.IndexByte(tag[idx:], '=')
+	if eq < 0 {
+		return 0, 0, false
+	}
+	i := idx + eq + 1
+	for i < len(tag) && (tag[i] == ' ' || tag[i] == '\t' || tag[i] == '\n') {
+		i++
+	}
+	if i >= len(tag) {
+		return 0, 0, false
+	}
+	if q := tag[i]; q == '"' || q == '\'' {
+		end := strings.IndexByte(tag[i+1:], q)
+		if end < 0 {
+			return 0, 0, false
+		}
+		return i + 1, i + 1 + end, true
+	}
+	// An unquoted value runs to whitespace or the end of the tag; '/' is
+	// part of it, as in src=assets/logo.png.
+	end := i
+	for end < len(tag) && !isHTMLSpace(tag[end]) && tag[end] != '>' {
+		end++
+	}
+	return i, end, true
+}
+
+func isHTMLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// srcsetCandidates returns the bounds of each URL in a srcset value such as
+// "a.png 1x, b.png 2x", skipping the width and density descriptors.
+func srcsetCandidates(v string) [][2]int {
+	var out [][2]int
+	for i := 0; i < len(v); {
+		for i < len(v) && (isHTMLSpace(v[i]) || v[i] == ',') {
+			i++
+		}
+		start := i
+		for i < len(v) && !isHTMLSpace(v[i]) {
+			i++
+		}
+		end := i
+		for end > start && v[end-1] == ',' {
+			end--
+		}
+		if end > start {
+			out = append(out, [2]int{start, end})
+		}
+		if end < i {
+			// The URL ended in a comma, so it had no descriptors.
+			continue
+		}
+		for i < len(v) && v[i] != ',' {
+			i++
+		}
+	}
+	return out
+}
+
+// splitLinkTarget separates a link target into its path and the fragment
+// after '#'. Any query string is dropped from the path.
+func splitLinkTarget(target string) (string, string) {
+	p, fragment, _ := strings.Cut(target, "#")
+	p, _, _ = strings.Cut(p, "?")
+	return p, fragment
+}
+
+// isExternalTarget reports whether target has a URI scheme (other than
+// mdocx:) or is protocol-relative, so it can't point into the container.
+func isExternalTarget(target string) bool {
+	if strings.HasPrefix(target, "//") {
+		return true
+	}
+	colon := strings.Index(target, ":")
+	if colon <= 0 {
+		return false
+	}
+	if slash := strings.IndexAny(target, "/?#"); slash >= 0 && slash < colon {
+		return false
+	}
+	return !strings.EqualFold(target[:colon], "mdocx")
+}
+
+// mdocxMediaPrefix is the URI prefix for references to media by ID.
+const mdocxMediaPrefix = "mdocx://media/"
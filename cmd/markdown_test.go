@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+const sampleMarkdown = `---
+title: "[not](a-link.md)"
+---
+# Title
+
+Intro with a [link](docs/guide.md#setup "Guide") and ![logo](assets/logo.png).
+Inline ` + "`[code](ignored.md)`" + ` is skipped.
+
+Setext Heading
+--------------
+
+` + "```go" + `
+fmt.Println("[x](inside-fence.md)")
+` + "```" + `
+
+[![badge](img/badge.svg)](https://example.com)
+<img src="assets/photo.jpg" alt="photo"> and <https://example.org/auto>
+
+[ref]: <refs/target.md>
+## Closing ##
+`
+
+func TestParseMarkdown_Links(t *testing.T) {
+	d := parseMarkdown([]byte(sampleMarkdown))
+	var targets []string
+	for _, l := range d.Links {
+		targets = append(targets, l.Kind+":"+l.Target)
+	}
+	want := []string{
+		"inline:docs/guide.md#setup",
+		"inline:assets/logo.png",
+		"inline:img/badge.svg",
+		"inline:https://example.com",
+		"html:assets/photo.jpg",
+		"autolink:https://example.org/auto",
+		"definition:refs/target.md",
+	}
+	if strings.Join(targets, " ") != strings.Join(want, " ") {
+		t.Fatalf("unexpected links:\n got %v\nwant %v", targets, want)
+	}
+
+	logo := d.Links[1]
+	if !logo.Image || logo.Text != "logo" {
+		t.Errorf("expected image with alt text, got %+v", logo)
+	}
+	if logo.Line != 6 || sampleMarkdown[logo.Start:logo.End] != "assets/logo.png" {
+		t.Errorf("unexpected position: line %d col %d", logo.Line, logo.Col)
+	}
+	if d.Links[4].Image != true {
+		t.Error("expected <img src> to be an image")
+	}
+}
+
+func TestParseMarkdown_HTMLAttributeValues(t *testing.T) {
+	content := "<img src=assets/logo.png alt=x/> <img src='a b.png'>\n" +
+		"<picture><source srcset=\"img/small.png 480w, img/large.png 2x,img/plain.png\"><img srcset=one.png></picture>\n"
+	d := parseMarkdown([]byte(content))
+	var got []string
+	for _, l := range d.Links {
+		if content[l.Start:l.End] != l.Target {
+			t.Errorf("bounds of %q cover %q", l.Target, content[l.Start:l.End])
+		}
+		got = append(got, l.Target)
+		if !l.Image {
+			t.Errorf("expected %q to be an image", l.Target)
+		}
+	}
+	want := []string{"assets/logo.png", "a b.png", "img/small.png", "img/large.png", "img/plain.png", "one.png"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("unexpected targets:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestParseMarkdown_HeadingsAndFences(t *testing.T) {
+	d := parseMarkdown([]byte(sampleMarkdown))
+	var got []string
+	for _, h := range d.Headings {
+		got = append(got, strings.Repeat("#", h.Level)+" "+h.Text)
+	}
+	want := []string{"# Title", "## Setext Heading", "## Closing"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("unexpected headings: %v", got)
+	}
+	if len(d.Fences) != 1 || d.Fences[0].Info != "go" || d.Fences[0].EndLine == 0 {
+		t.Fatalf("unexpected fences: %+v", d.Fences)
+	}
+}
+
+func TestParseMarkdown_Position(t *testing.T) {
+	d := parseMarkdown([]byte("héllo\n[x](y.md)"))
+	if len(d.Links) != 1 {
+		t.Fatalf("expected 1 link, got %d", len(d.Links))
+	}
+	if d.Links[0].Line != 2 || d.Links[0].Col != 5 {
+		t.Errorf("expected 2:5, got %d:%d", d.Links[0].Line, d.Links[0].Col)
+	}
+	line, col := d.position(3)
+	if line != 1 || col != 3 {
+		t.Errorf("expected rune column 3 after multibyte char, got %d:%d", line, col)
+	}
+}
+
+func TestIsExternalTarget(t *testing.T) {
+	cases := map[string]bool{
+		"https://example.com": true,
+		"mailto:a@b.c":        true,
+		"//cdn.example.com/x": true,
+		"mdocx://media/logo":  false,
+		"docs/readme.md":      false,
+		"#anchor":             false,
+		"a/b:c.md":            false,
+		"../up.md":            false,
+	}
+	for in, want := range cases {
+		if got := isExternalTarget(in); got != want {
+			t.Errorf("isExternalTarget(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
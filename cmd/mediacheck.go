@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/logicossoftware/go-mdocx"
+)
+
+// decodableImageTypes are the image formats with a registered decoder.
+var decodableImageTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+}
+
+// mimeAliases maps alternative spellings of a MIME type to the one
+// http.DetectContentType reports.
+var mimeAliases = map[string]string{
+	"image/jpg":                "image/jpeg",
+	"image/pjpeg":              "image/jpeg",
+	"image/vnd.microsoft.icon": "image/x-icon",
+	"audio/wav":                "audio/wave",
+	"audio/x-wav":              "audio/wave",
+	"audio/mp3":                "audio/mpeg",
+	"application/gzip":         "application/x-gzip",
+	"audio/ogg":                "application/ogg",
+	"video/ogg":                "application/ogg",
+	"audio/webm":               "video/webm",
+	"audio/mp4":                "video/mp4",
+}
+
+// baseMIME returns the lower-cased type/subtype of m without parameters,
+// with aliases resolved.
+func baseMIME(m string) string {
+	if t, _, err := mime.ParseMediaType(m); err == nil {
+		m = t
+	}
+	m = strings.ToLower(strings.TrimSpace(m))
+	if alias, ok := mimeAliases[m]; ok {
+		return alias
+	}
+	return m
+}
+
+// isTextualMIME reports whether content of type m is text that sniffing
+// would only see as text/plain, text/xml or text/html.
+func isTextualMIME(m string) bool {
+	switch {
+	case strings.HasPrefix(m, "text/"), strings.HasSuffix(m, "+xml"), strings.HasSuffix(m, "+json"):
+		return true
+	}
+	switch m {
+	case "application/json", "application/xml", "application/javascript", "application/x-yaml", "application/yaml", "application/toml":
+		return true
+	}
+	return false
+}
+
+// mimeMatchesContent reports whether declared is consistent with the type
+// sniffed from the content. Sniffing recognizes only a fixed set of
+// signatures, so an unrecognized binary never counts as a mismatch, and a
+// zip signature fits any zip-based format.
+func mimeMatchesContent(declared, sniffed string) bool {
+	d, s := baseMIME(declared), baseMIME(sniffed)
+	switch {
+	case d == s, s == "application/octet-stream":
+		return true
+	case s == "text/plain" || s == "text/xml" || s == "text/html":
+		return isTextualMIME(d)
+	case s == "application/zip":
+		return strings.HasSuffix(d, "+zip") || strings.HasPrefix(d, "application/vnd.openxmlformats") || strings.HasPrefix(d, "application/vnd.oasis")
+	}
+	return false
+}
+
+// checkMedia reports media items whose declared MIME type doesn't match
+// their content, images that don't decode, and stored SHA-256 hashes that
+// don't match the data. Each item is reported on its own, so one bad item
+// doesn't hide the rest.
+func checkMedia(doc *mdocx.Document, r *findingReporter) {
+	for _, mi := range doc.Media.Items {
+		sniffed := http.DetectContentType(mi.Data)
+		declared := baseMIME(mi.MIMEType)
+		switch {
+		case declared == "":
+			r.report(ruleMIMEMismatch, finding{Message: fmt.Sprintf("media item %q declares no MIME type; content looks like %s", mi.ID, baseMIME(sniffed)), Path: mi.Path})
+		case !mimeMatchesContent(declared, sniffed):
+			r.report(ruleMIMEMismatch, finding{Message: fmt.Sprintf("media item %q is declared %s but its content looks like %s", mi.ID, mi.MIMEType, baseMIME(sniffed)), Path: mi.Path})
+		}
+
+		if decodableImageTypes[declared] || decodableImageTypes[baseMIME(sniffed)] {
+			if _, _, err := image.DecodeConfig(bytes.NewReader(mi.Data)); err != nil {
+				r.report(ruleImageUndecodable, finding{Message: fmt.Sprintf("media item %q (%s) does not decode as an image: %v", mi.ID, mi.MIMEType, err), Path: mi.Path})
+			}
+		}
+
+		if mi.SHA256 != ([32]byte{}) {
+			if sum := sha256.Sum256(mi.Data); sum != mi.SHA256 {
+				r.report(ruleSHA256Mismatch, finding{Message: fmt.Sprintf("media item %q SHA-256 mismatch: stored %s, data hashes to %s", mi.ID, hex.EncodeToString(mi.SHA256[:]), hex.EncodeToString(sum[:])), Path: mi.Path})
+			}
+		}
+	}
+}
+
+// checkConventions reports IDs and paths that pack would not have produced:
+// media IDs not derived from the path (or not lower-case [a-z0-9_] when there
+// is no path) and container paths that aren't in canonical form.
+func checkConventions(doc *mdocx.Document, r *findingReporter) {
+	checkPath := func(kind, p string) {
+		if clean, err := sanitizeContainerPath(p); err != nil {
+			r.report(rulePathConvention, finding{Message: fmt.Sprintf("%s path %q: %v", kind, p, err), Path: p})
+		} else if clean != p {
+			r.report(rulePathConvention, finding{Message: fmt.Sprintf("%s path %q is not in canonical form (%q)", kind, p, clean), Path: p})
+		}
+	}
+	for _, mf := range doc.Markdown.Files {
+		checkPath("markdown", mf.Path)
+	}
+	for _, mi := range doc.Media.Items {
+		if mi.Path != "" {
+			checkPath("media", mi.Path)
+			if want := makeIDFromPath(mi.Path); mi.ID != want {
+				r.report(ruleMediaIDConvention, finding{Message: fmt.Sprintf("media ID %q does not match the ID derived from its path %q (%q)", mi.ID, mi.Path, want), Path: mi.Path})
+			}
+		} else if makeIDFromPath(mi.ID) != mi.ID {
+			r.report(ruleMediaIDConvention, finding{Message: fmt.Sprintf("media ID %q should contain only lower-case letters, digits and underscores", mi.ID)})
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/logicossoftware/go-mdocx"
+)
+
+// testPNG returns a valid 1x1 PNG, zero-padded to size bytes if larger.
+func testPNG(size int) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1))); err != nil {
+		panic(err)
+	}
+	if pad := size - buf.Len(); pad > 0 {
+		buf.Write(make([]byte, pad))
+	}
+	return buf.Bytes()
+}
+
+func TestMIMEMatchesContent(t *testing.T) {
+	cases := []struct {
+		declared, sniffed string
+		want              bool
+	}{
+		{"image/png", "image/png", true},
+		{"image/jpg", "image/jpeg", true},
+		{"image/png", "image/jpeg", false},
+		{"image/png", "application/octet-stream", true},
+		{"image/svg+xml", "text/xml; charset=utf-8", true},
+		{"text/markdown; charset=utf-8", "text/plain; charset=utf-8", true},
+		{"application/json", "text/plain; charset=utf-8", true},
+		{"image/png", "text/plain; charset=utf-8", false},
+		{"application/epub+zip", "application/zip", true},
+		{"application/pdf", "application/zip", false},
+		{"audio/wav", "audio/wave", true},
+	}
+	for _, c := range cases {
+		if got := mimeMatchesContent(c.declared, c.sniffed); got != c.want {
+			t.Errorf("mimeMatchesContent(%q, %q) = %v, want %v", c.declared, c.sniffed, got, c.want)
+		}
+	}
+}
+
+func mediaCheckDoc() *mdocx.Document {
+	good := testPNG(0)
+	tampered := mdocx.MediaItem{ID: "assets_tampered_png", Path: "assets/tampered.png", MIMEType: "image/png", Data: testPNG(0), SHA256: sha256.Sum256([]byte("other"))}
+	return &mdocx.Document{
+		Markdown: mdocx.MarkdownBundle{
+			BundleVersion: mdocx.VersionV1,
+			Files: []mdocx.MarkdownFile{
+				{Path: "readme.md", Content: []byte("# Media\n")},
+				{Path: "docs//guide.md", Content: []byte("# Guide\n")},
+			},
+		},
+		Media: mdocx.MediaBundle{
+			BundleVersion: mdocx.VersionV1,
+			Items: []mdocx.MediaItem{
+				{ID: "assets_good_png", Path: "assets/good.png", MIMEType: "image/png", Data: good, SHA256: sha256.Sum256(good)},
+				{ID: "assets_photo_jpg", Path: "assets/photo.jpg", MIMEType: "image/jpeg", Data: good},
+				{ID: "assets_broken_gif", Path: "assets/broken.gif", MIMEType: "image/gif", Data: []byte("GIF89a\x01")},
+				{ID: "notes", MIMEType: "", Data: []byte("plain notes")},
+				tampered,
+				{ID: "Logo", Path: "img/logo.svg", MIMEType: "image/svg+xml", Data: []byte("<svg xmlns=\"http://www.w3.org/2000/svg\"/>")},
+				{ID: "Bad ID", MIMEType: "text/plain", Data: []byte("x")},
+			},
+		},
+	}
+}
+
+func TestCheckMedia(t *testing.T) {
+	r := &findingReporter{}
+	checkMedia(mediaCheckDoc(), r)
+	var got []string
+	for _, f := range r.findings {
+		got = append(got, string(f.Rule)+" "+f.Path+" "+f.Message)
+	}
+	want := []string{
+		`MDX013 assets/photo.jpg media item "assets_photo_jpg" is declared image/jpeg but its content looks like image/png`,
+		`MDX014 assets/broken.gif media item "assets_broken_gif" (image/gif) does not decode as an image: gif:`,
+		`MDX013  media item "notes" declares no MIME type; content looks like text/plain`,
+		`MDX015 assets/tampered.png media item "assets_tampered_png" SHA-256 mismatch`,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("findings:\n%s", strings.Join(got, "\n"))
+	}
+	for i := range want {
+		if !strings.HasPrefix(got[i], want[i]) {
+			t.Errorf("finding %d = %q, want prefix %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestCheckConventions(t *testing.T) {
+	r := &findingReporter{rules: ruleSet{ruleMediaIDConvention: severityWarning}}
+	checkConventions(mediaCheckDoc(), r)
+	var got []string
+	for _, f := range r.findings {
+		got = append(got, string(f.Rule)+" "+f.Message)
+	}
+	want := []string{
+		`MDX017 markdown path "docs//guide.md" is not in canonical form ("docs/guide.md")`,
+		`MDX016 media ID "Logo" does not match the ID derived from its path "img/logo.svg" ("img_logo_svg")`,
+		`MDX016 media ID "Bad ID" should contain only lower-case letters, digits and underscores`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("findings:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Hand-picked IDs are common, so the ID rule is off unless enabled.
+	r = &findingReporter{}
+	checkConventions(mediaCheckDoc(), r)
+	if len(r.findings) != 1 || r.findings[0].Rule != rulePathConvention {
+		t.Errorf("expected only the path finding by default, got %+v", r.findings)
+	}
+}
+
+func TestValidateReportsHashMismatchPerItem(t *testing.T) {
+	good := testPNG(0)
+	doc := &mdocx.Document{
+		Markdown: mdocx.MarkdownBundle{
+			BundleVersion: mdocx.VersionV1,
+			Files:         []mdocx.MarkdownFile{{Path: "readme.md", Content: []byte("![a](a.png) ![b](b.png)\n")}},
+		},
+		Media: mdocx.MediaBundle{
+			BundleVersion: mdocx.VersionV1,
+			Items: []mdocx.MediaItem{
+				{ID: "a_png", Path: "a.png", MIMEType: "image/png", Data: good, SHA256: sha256.Sum256([]byte("tampered"))},
+				{ID: "b_png", Path: "b.png", MIMEType: "image/png", Data: good},
+			},
+		},
+	}
+	p := filepath.Join(t.TempDir(), "hash.mdocx")
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mdocx.Encode(f, doc, mdocx.WithVerifyHashesOnWrite(false)); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	out, err := executeCommand(rootCmd, "validate", p)
+	if err == nil {
+		t.Fatal("expected a hash mismatch to fail validation")
+	}
+	if !strings.Contains(out, `ERROR: media item "a_png" SHA-256 mismatch`) || strings.Contains(out, `"b_png" SHA-256`) {
+		t.Errorf("expected a per-item hash finding for a_png only:\n%s", out)
+	}
+
+	if out, err := executeCommand(rootCmd, "validate", "--strict=false", p); err != nil {
+		t.Errorf("--strict=false should skip hash checks: %v\n%s", err, out)
+	}
+}
@@ -0,0 +1,609 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"math/big"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/logicossoftware/go-mdocx"
+)
+
+// schemaAssertions are the JSON Schema keywords metadataSchema checks.
+var schemaAssertions = map[string]bool{
+	"type": true, "enum": true, "const": true,
+	"properties": true, "required": true, "additionalProperties": true, "patternProperties": true,
+	"minProperties": true, "maxProperties": true, "dependentRequired": true,
+	"items": true, "prefixItems": true, "minItems": true, "maxItems": true, "uniqueItems": true,
+	"minLength": true, "maxLength": true, "pattern": true,
+	"minimum": true, "maximum": true, "exclusiveMinimum": true, "exclusiveMaximum": true, "multipleOf": true,
+	"allOf": true, "anyOf": true, "oneOf": true, "not": true, "if": true, "then": true, "else": true,
+	"$ref": true, "$defs": true,
+}
+
+// schemaAnnotations are keywords that never affect validation. format is an
+// annotation by default in draft 2020-12.
+var schemaAnnotations = map[string]bool{
+	"$schema": true, "$id": true, "$comment": true, "title": true, "description": true,
+	"default": true, "examples": true, "deprecated": true, "readOnly": true, "writeOnly": true, "format": true,
+}
+
+// maxSchemaDepth bounds schema recursion. checkRefCycles rejects $ref loops
+// that don't descend into the instance, so only deeply nested values reach it.
+const maxSchemaDepth = 256
+
+// metadataSchema validates container metadata against a JSON Schema. It
+// supports the draft 2020-12 keywords in schemaAssertions; $ref must point
+// into the same document ("#/$defs/name"), and pattern uses Go's RE2 syntax.
+type metadataSchema struct {
+	root     any
+	patterns map[string]*regexp.Regexp
+	refs     map[string]bool // $refs already checked by compile
+	// inPlace maps the JSON pointer of each subschema to the subschemas it
+	// applies to the same value ($ref, allOf, not, if, ...), for cycle checks.
+	inPlace map[string][]string
+}
+
+// schemaViolation is one way an instance fails a schema. Pointer is the JSON
+// pointer of the offending value, "" for the whole instance.
+type schemaViolation struct {
+	Pointer string
+	Message string
+}
+
+func (v schemaViolation) String() string {
+	if v.Pointer == "" {
+		return "metadata: " + v.Message
+	}
+	return fmt.Sprintf("metadata %s: %s", v.Pointer, v.Message)
+}
+
+// loadMetadataSchema reads and checks a schema file. Keywords outside the
+// supported subset are an error, so a schema is never silently half-applied.
+func loadMetadataSchema(path string) (*metadataSchema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read metadata schema: %w", err)
+	}
+	var root any
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("parse metadata schema %s: %w", path, err)
+	}
+	s := &metadataSchema{root: root, patterns: map[string]*regexp.Regexp{}, refs: map[string]bool{}, inPlace: map[string][]string{}}
+	if err := s.compile(root, ""); err != nil {
+		return nil, fmt.Errorf("metadata schema %s: %w", path, err)
+	}
+	if err := s.checkRefCycles(); err != nil {
+		return nil, fmt.Errorf("metadata schema %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// compile checks the keywords of the subschema at ptr and everything below
+// it, and compiles its patterns.
+func (s *metadataSchema) compile(schema any, ptr string) error {
+	obj, ok := schema.(map[string]any)
+	if !ok {
+		if _, ok := schema.(bool); ok {
+			return nil
+		}
+		return fmt.Errorf("%s: a schema must be an object or a boolean", schemaPtr(ptr))
+	}
+	for _, kw := range slices.Sorted(maps.Keys(obj)) {
+		if !schemaAssertions[kw] && !schemaAnnotations[kw] {
+			return fmt.Errorf("%s: unsupported keyword %q", schemaPtr(ptr), kw)
+		}
+		v := obj[kw]
+		at := ptr + "/" + escapePointer(kw)
+		switch kw {
+		case "properties", "patternProperties", "$defs":
+			m, ok := v.(map[string]any)
+			if !ok {
+				return fmt.Errorf("%s: must be an object", schemaPtr(at))
+			}
+			for _, name := range slices.Sorted(maps.Keys(m)) {
+				if kw == "patternProperties" {
+					if err := s.compilePattern(name, at); err != nil {
+						return err
+					}
+				}
+				if err := s.compile(m[name], at+"/"+escapePointer(name)); err != nil {
+					return err
+				}
+			}
+		case "additionalProperties", "items", "not", "if", "then", "else":
+			if kw != "additionalProperties" && kw != "items" {
+				s.inPlace[ptr] = append(s.inPlace[ptr], at)
+			}
+			if err := s.compile(v, at); err != nil {
+				return err
+			}
+		case "allOf", "anyOf", "oneOf", "prefixItems":
+			list, ok := v.([]any)
+			if !ok || len(list) == 0 {
+				return fmt.Errorf("%s: must be a non-empty array", schemaPtr(at))
+			}
+			for i, sub := range list {
+				if kw != "prefixItems" {
+					s.inPlace[ptr] = append(s.inPlace[ptr], at+"/"+strconv.Itoa(i))
+				}
+				if err := s.compile(sub, at+"/"+strconv.Itoa(i)); err != nil {
+					return err
+				}
+			}
+		case "pattern":
+			p, ok := v.(string)
+			if !ok {
+				return fmt.Errorf("%s: must be a string", schemaPtr(at))
+			}
+			if err := s.compilePattern(p, at); err != nil {
+				return err
+			}
+		case "$ref":
+			ref, ok := v.(string)
+			if !ok {
+				return fmt.Errorf("%s: must be a string", schemaPtr(at))
+			}
+			target, err := s.resolve(ref)
+			if err != nil {
+				return fmt.Errorf("%s: %w", schemaPtr(at), err)
+			}
+			s.inPlace[ptr] = append(s.inPlace[ptr], strings.TrimPrefix(ref, "#"))
+			// A $ref may point anywhere in the document, so check its target
+			// as a schema too; each ref is checked once to allow recursion.
+			if !s.refs[ref] {
+				s.refs[ref] = true
+				if err := s.compile(target, strings.TrimPrefix(ref, "#")); err != nil {
+					return err
+				}
+			}
+		case "type":
+			if err := checkSchemaTypes(v); err != nil {
+				return fmt.Errorf("%s: %w", schemaPtr(at), err)
+			}
+		case "required":
+			if _, ok := stringList(v); !ok {
+				return fmt.Errorf("%s: must be an array of strings", schemaPtr(at))
+			}
+		case "enum":
+			if _, ok := v.([]any); !ok {
+				return fmt.Errorf("%s: must be an array", schemaPtr(at))
+			}
+		case "minProperties", "maxProperties", "minItems", "maxItems", "minLength", "maxLength":
+			if n, ok := v.(float64); !ok || n < 0 || n != math.Trunc(n) {
+				return fmt.Errorf("%s: must be a non-negative integer", schemaPtr(at))
+			}
+		case "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum":
+			if _, ok := v.(float64); !ok {
+				return fmt.Errorf("%s: must be a number", schemaPtr(at))
+			}
+		case "multipleOf":
+			if n, ok := v.(float64); !ok || n <= 0 {
+				return fmt.Errorf("%s: must be a positive number", schemaPtr(at))
+			}
+		case "uniqueItems":
+			if _, ok := v.(bool); !ok {
+				return fmt.Errorf("%s: must be a boolean", schemaPtr(at))
+			}
+		case "dependentRequired":
+			m, ok := v.(map[string]any)
+			if !ok {
+				return fmt.Errorf("%s: must be an object", schemaPtr(at))
+			}
+			for name, deps := range m {
+				if _, ok := stringList(deps); !ok {
+					return fmt.Errorf("%s/%s: must be an array of strings", schemaPtr(at), escapePointer(name))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkRefCycles rejects a schema whose $refs loop back to a subschema
+// without descending into the value, like {"allOf": [{"$ref": "#"}]}.
+// Validating against such a schema would never terminate.
+func (s *metadataSchema) checkRefCycles() error {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := map[string]int{}
+	var visit func(ptr string) error
+	visit = func(ptr string) error {
+		state[ptr] = visiting
+		for _, next := range s.inPlace[ptr] {
+			switch state[next] {
+			case visiting:
+				return fmt.Errorf("%s: $ref cycle back to %s that never descends into the value", schemaPtr(ptr), schemaPtr(next))
+			case 0:
+				if err := visit(next); err != nil {
+					return err
+				}
+			}
+		}
+		state[ptr] = done
+		return nil
+	}
+	for _, ptr := range slices.Sorted(maps.Keys(s.inPlace)) {
+		if state[ptr] == 0 {
+			if err := visit(ptr); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *metadataSchema) compilePattern(p, at string) error {
+	re, err := regexp.Compile(p)
+	if err != nil {
+		return fmt.Errorf("%s: invalid pattern %q: %w", schemaPtr(at), p, err)
+	}
+	s.patterns[p] = re
+	return nil
+}
+
+// resolve returns the subschema a local $ref points at.
+func (s *metadataSchema) resolve(ref string) (any, error) {
+	if ref != "#" && !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("$ref %q: only references within the schema (#/...) are supported", ref)
+	}
+	cur := s.root
+	for _, tok := range strings.Split(strings.TrimPrefix(ref, "#"), "/")[1:] {
+		tok = strings.NewReplacer("~1", "/", "~0", "~").Replace(tok)
+		switch c := cur.(type) {
+		case map[string]any:
+			next, ok := c[tok]
+			if !ok {
+				return nil, fmt.Errorf("$ref %q does not resolve", ref)
+			}
+			cur = next
+		case []any:
+			i, err := strconv.Atoi(tok)
+			if err != nil || i < 0 || i >= len(c) {
+				return nil, fmt.Errorf("$ref %q does not resolve", ref)
+			}
+			cur = c[i]
+		default:
+			return nil, fmt.Errorf("$ref %q does not resolve", ref)
+		}
+	}
+	return cur, nil
+}
+
+// validate checks metadata against the schema. Missing metadata is checked
+// as an empty object, so required properties are still enforced.
+func (s *metadataSchema) validate(metadata map[string]any) []schemaViolation {
+	var instance any = map[string]any{}
+	if metadata != nil {
+		instance = metadata
+	}
+	var out []schemaViolation
+	s.check(s.root, instance, "", 0, &out)
+	return out
+}
+
+// checkMetadataSchema reports each way doc's metadata fails schema.
+func checkMetadataSchema(doc *mdocx.Document, schema *metadataSchema, r *findingReporter) {
+	for _, v := range schema.validate(doc.Metadata) {
+		r.report(ruleMetadataSchema, finding{Message: v.String(), Pointer: v.Pointer})
+	}
+}
+
+// requireMetadataSchema returns an error listing every way metadata fails
+// schema, for pack --metadata-schema.
+func requireMetadataSchema(metadata map[string]any, schema *metadataSchema) error {
+	violations := schema.validate(metadata)
+	if len(violations) == 0 {
+		return nil
+	}
+	msgs := make([]string, len(violations))
+	for i, v := range violations {
+		msgs[i] = "  " + v.String()
+	}
+	return fmt.Errorf("metadata does not match the schema:\n%s", strings.Join(msgs, "\n"))
+}
+
+// matches reports whether instance is valid against schema, without
+// recording violations.
+func (s *metadataSchema) matches(schema, instance any, ptr string, depth int) bool {
+	var out []schemaViolation
+	s.check(schema, instance, ptr, depth, &out)
+	return len(out) == 0
+}
+
+func (s *metadataSchema) check(schema, instance any, ptr string, depth int, out *[]schemaViolation) {
+	fail := func(at, format string, args ...any) {
+		*out = append(*out, schemaViolation{Pointer: at, Message: fmt.Sprintf(format, args...)})
+	}
+	if depth > maxSchemaDepth {
+		fail(ptr, "schema nesting is too deep (recursive $ref?)")
+		return
+	}
+	if b, ok := schema.(bool); ok {
+		if !b {
+			fail(ptr, "no value is allowed here")
+		}
+		return
+	}
+	obj := schema.(map[string]any)
+
+	if ref, ok := obj["$ref"].(string); ok {
+		target, _ := s.resolve(ref)
+		s.check(target, instance, ptr, depth+1, out)
+	}
+	if t, ok := obj["type"]; ok {
+		types, _ := stringList(t)
+		if s, ok := t.(string); ok {
+			types = []string{s}
+		}
+		if !slices.ContainsFunc(types, func(want string) bool { return jsonTypeMatches(instance, want) }) {
+			fail(ptr, "expected %s, got %s", strings.Join(types, " or "), jsonType(instance))
+			return
+		}
+	}
+	if enum, ok := obj["enum"].([]any); ok && !slices.ContainsFunc(enum, func(v any) bool { return reflect.DeepEqual(v, instance) }) {
+		vals := make([]string, len(enum))
+		for i, v := range enum {
+			vals[i] = jsonText(v)
+		}
+		fail(ptr, "value must be one of %s", strings.Join(vals, ", "))
+	}
+	if c, ok := obj["const"]; ok && !reflect.DeepEqual(c, instance) {
+		fail(ptr, "value must be %s", jsonText(c))
+	}
+
+	switch v := instance.(type) {
+	case map[string]any:
+		s.checkObject(obj, v, ptr, depth, out)
+	case []any:
+		s.checkArray(obj, v, ptr, depth, out)
+	case string:
+		n := utf8.RuneCountInString(v)
+		if min, ok := obj["minLength"].(float64); ok && float64(n) < min {
+			fail(ptr, "string is shorter than %s characters", jsonText(min))
+		}
+		if max, ok := obj["maxLength"].(float64); ok && float64(n) > max {
+			fail(ptr, "string is longer than %s characters", jsonText(max))
+		}
+		if p, ok := obj["pattern"].(string); ok && !s.patterns[p].MatchString(v) {
+			fail(ptr, "string does not match pattern %q", p)
+		}
+	case float64:
+		if min, ok := obj["minimum"].(float64); ok && v < min {
+			fail(ptr, "value is less than %s", jsonText(min))
+		}
+		if max, ok := obj["maximum"].(float64); ok && v > max {
+			fail(ptr, "value is greater than %s", jsonText(max))
+		}
+		if min, ok := obj["exclusiveMinimum"].(float64); ok && v <= min {
+			fail(ptr, "value must be greater than %s", jsonText(min))
+		}
+		if max, ok := obj["exclusiveMaximum"].(float64); ok && v >= max {
+			fail(ptr, "value must be less than %s", jsonText(max))
+		}
+		if m, ok := obj["multipleOf"].(float64); ok && !isMultipleOf(v, m) {
+			fail(ptr, "value is not a multiple of %s", jsonText(m))
+		}
+	}
+
+	if all, ok := obj["allOf"].([]any); ok {
+		for _, sub := range all {
+			s.check(sub, instance, ptr, depth+1, out)
+		}
+	}
+	if anyOf, ok := obj["anyOf"].([]any); ok && !slices.ContainsFunc(anyOf, func(sub any) bool { return s.matches(sub, instance, ptr, depth+1) }) {
+		fail(ptr, "value does not match any anyOf schema")
+	}
+	if oneOf, ok := obj["oneOf"].([]any); ok {
+		n := 0
+		for _, sub := range oneOf {
+			if s.matches(sub, instance, ptr, depth+1) {
+				n++
+			}
+		}
+		if n != 1 {
+			fail(ptr, "value matches %d oneOf schemas, expected exactly 1", n)
+		}
+	}
+	if not, ok := obj["not"]; ok && s.matches(not, instance, ptr, depth+1) {
+		fail(ptr, "value must not match the \"not\" schema")
+	}
+	if cond, ok := obj["if"]; ok {
+		branch := "else"
+		if s.matches(cond, instance, ptr, depth+1) {
+			branch = "then"
+		}
+		if sub, ok := obj[branch]; ok {
+			s.check(sub, instance, ptr, depth+1, out)
+		}
+	}
+}
+
+func (s *metadataSchema) checkObject(schema, obj map[string]any, ptr string, depth int, out *[]schemaViolation) {
+	fail := func(at, format string, args ...any) {
+		*out = append(*out, schemaViolation{Pointer: at, Message: fmt.Sprintf(format, args...)})
+	}
+	if required, ok := stringList(schema["required"]); ok {
+		for _, name := range required {
+			if _, ok := obj[name]; !ok {
+				fail(ptr+"/"+escapePointer(name), "missing required property")
+			}
+		}
+	}
+	if deps, ok := schema["dependentRequired"].(map[string]any); ok {
+		for _, name := range slices.Sorted(maps.Keys(deps)) {
+			if _, ok := obj[name]; !ok {
+				continue
+			}
+			required, _ := stringList(deps[name])
+			for _, dep := range required {
+				if _, ok := obj[dep]; !ok {
+					fail(ptr+"/"+escapePointer(dep), "missing property required by %q", name)
+				}
+			}
+		}
+	}
+	if min, ok := schema["minProperties"].(float64); ok && float64(len(obj)) < min {
+		fail(ptr, "object has fewer than %s properties", jsonText(min))
+	}
+	if max, ok := schema["maxProperties"].(float64); ok && float64(len(obj)) > max {
+		fail(ptr, "object has more than %s properties", jsonText(max))
+	}
+
+	props, _ := schema["properties"].(map[string]any)
+	patternProps, _ := schema["patternProperties"].(map[string]any)
+	additional, hasAdditional := schema["additionalProperties"]
+	for _, name := range slices.Sorted(maps.Keys(obj)) {
+		at := ptr + "/" + escapePointer(name)
+		matched := false
+		if sub, ok := props[name]; ok {
+			matched = true
+			s.check(sub, obj[name], at, depth+1, out)
+		}
+		for _, p := range slices.Sorted(maps.Keys(patternProps)) {
+			if s.patterns[p].MatchString(name) {
+				matched = true
+				s.check(patternProps[p], obj[name], at, depth+1, out)
+			}
+		}
+		if !matched && hasAdditional {
+			if b, ok := additional.(bool); ok && !b {
+				fail(at, "property is not allowed")
+			} else {
+				s.check(additional, obj[name], at, depth+1, out)
+			}
+		}
+	}
+}
+
+func (s *metadataSchema) checkArray(schema map[string]any, arr []any, ptr string, depth int, out *[]schemaViolation) {
+	fail := func(format string, args ...any) {
+		*out = append(*out, schemaViolation{Pointer: ptr, Message: fmt.Sprintf(format, args...)})
+	}
+	if min, ok := schema["minItems"].(float64); ok && float64(len(arr)) < min {
+		fail("array has fewer than %s items", jsonText(min))
+	}
+	if max, ok := schema["maxItems"].(float64); ok && float64(len(arr)) > max {
+		fail("array has more than %s items", jsonText(max))
+	}
+	if unique, _ := schema["uniqueItems"].(bool); unique {
+	dup:
+		for i := range arr {
+			for j := range i {
+				if reflect.DeepEqual(arr[i], arr[j]) {
+					fail("items %d and %d are equal", j, i)
+					break dup
+				}
+			}
+		}
+	}
+	prefix, _ := schema["prefixItems"].([]any)
+	items, hasItems := schema["items"]
+	for i, v := range arr {
+		at := ptr + "/" + strconv.Itoa(i)
+		switch {
+		case i < len(prefix):
+			s.check(prefix[i], v, at, depth+1, out)
+		case hasItems:
+			s.check(items, v, at, depth+1, out)
+		}
+	}
+}
+
+// isMultipleOf reports whether v is an integer multiple of m. Both are
+// compared as the decimals they were written as, so 0.3 is a multiple of 0.1
+// even though their float64 quotient isn't an integer.
+func isMultipleOf(v, m float64) bool {
+	x, _ := new(big.Rat).SetString(strconv.FormatFloat(v, 'g', -1, 64))
+	y, _ := new(big.Rat).SetString(strconv.FormatFloat(m, 'g', -1, 64))
+	return x.Quo(x, y).IsInt()
+}
+
+// jsonType names the JSON type of a decoded value.
+func jsonType(v any) string {
+	switch n := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if n == math.Trunc(n) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func jsonTypeMatches(v any, want string) bool {
+	got := jsonType(v)
+	return got == want || (want == "number" && got == "integer")
+}
+
+var schemaTypes = []string{"null", "boolean", "integer", "number", "string", "array", "object"}
+
+func checkSchemaTypes(v any) error {
+	types, ok := stringList(v)
+	if s, isString := v.(string); isString {
+		types, ok = []string{s}, true
+	}
+	if !ok || len(types) == 0 {
+		return fmt.Errorf("must be a type name or an array of type names")
+	}
+	for _, t := range types {
+		if !slices.Contains(schemaTypes, t) {
+			return fmt.Errorf("unknown type %q", t)
+		}
+	}
+	return nil
+}
+
+// stringList converts a decoded JSON array of strings.
+func stringList(v any) ([]string, bool) {
+	list, ok := v.([]any)
+	if !ok {
+		return nil, false
+	}
+	out := make([]string, len(list))
+	for i, item := range list {
+		if out[i], ok = item.(string); !ok {
+			return nil, false
+		}
+	}
+	return out, true
+}
+
+// jsonText renders a decoded value as compact JSON for messages.
+func jsonText(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// escapePointer escapes a property name as a JSON pointer token.
+func escapePointer(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
+
+// schemaPtr renders a schema location for error messages.
+func schemaPtr(ptr string) string {
+	return "#" + ptr
+}
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testMetadataSchema = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "required": ["title", "version", "audience"],
+  "properties": {
+    "title": {"type": "string", "minLength": 1},
+    "version": {"type": "string", "pattern": "^\\d+\\.\\d+\\.\\d+$"},
+    "audience": {"type": "array", "items": {"$ref": "#/$defs/audience"}, "minItems": 1, "uniqueItems": true},
+    "pages": {"type": "integer", "minimum": 1},
+    "status": {"enum": ["draft", "final"]}
+  },
+  "additionalProperties": false,
+  "$defs": {
+    "audience": {"enum": ["internal", "partner", "public"]}
+  }
+}`
+
+func writeTestSchema(t *testing.T, schema string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "schema.json")
+	if err := os.WriteFile(p, []byte(schema), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestMetadataSchemaValidate(t *testing.T) {
+	s, err := loadMetadataSchema(writeTestSchema(t, testMetadataSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var meta map[string]any
+	if err := json.Unmarshal([]byte(`{"title": "", "version": "1.0", "audience": ["public", "press", "public"], "pages": 2.5, "status": "final", "owner": "x"}`), &meta); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, v := range s.validate(meta) {
+		got = append(got, v.String())
+	}
+	want := []string{
+		`metadata /audience: items 0 and 2 are equal`,
+		`metadata /audience/1: value must be one of "internal", "partner", "public"`,
+		`metadata /owner: property is not allowed`,
+		`metadata /pages: expected integer, got number`,
+		`metadata /title: string is shorter than 1 characters`,
+		`metadata /version: string does not match pattern "^\\d+\\.\\d+\\.\\d+$"`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("violations:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	missing := s.validate(nil)
+	if len(missing) != 3 || missing[0].Pointer != "/title" || missing[0].Message != "missing required property" {
+		t.Errorf("expected missing metadata to be checked as an empty object, got %+v", missing)
+	}
+
+	if v := s.validate(map[string]any{"title": "T", "version": "1.2.3", "audience": []any{"public"}, "pages": float64(3)}); len(v) != 0 {
+		t.Errorf("expected valid metadata, got %+v", v)
+	}
+}
+
+func TestMetadataSchemaCombinators(t *testing.T) {
+	s, err := loadMetadataSchema(writeTestSchema(t, `{
+	  "properties": {
+	    "a": {"anyOf": [{"type": "string"}, {"type": "null"}]},
+	    "b": {"oneOf": [{"type": "number"}, {"type": "integer"}]},
+	    "c": {"not": {"const": 0}},
+	    "d": {"if": {"type": "string"}, "then": {"maxLength": 2}, "else": {"exclusiveMaximum": 10}}
+	  },
+	  "dependentRequired": {"a": ["c"]}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		meta string
+		want []string
+	}{
+		{`{"a": null, "b": 1.5, "c": 1, "d": "ab"}`, nil},
+		{`{"a": 1}`, []string{"/c: missing property required by \"a\"", "/a: value does not match any anyOf schema"}},
+		{`{"b": 2, "c": 0, "d": "abc"}`, []string{"/b: value matches 2 oneOf schemas, expected exactly 1", "/c: value must not match the \"not\" schema", "/d: string is longer than 2 characters"}},
+		{`{"d": 10}`, []string{"/d: value must be less than 10"}},
+	}
+	for _, c := range cases {
+		var meta map[string]any
+		if err := json.Unmarshal([]byte(c.meta), &meta); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, v := range s.validate(meta) {
+			got = append(got, v.Pointer+": "+v.Message)
+		}
+		if strings.Join(got, "\n") != strings.Join(c.want, "\n") {
+			t.Errorf("%s:\n%s\nwant:\n%s", c.meta, strings.Join(got, "\n"), strings.Join(c.want, "\n"))
+		}
+	}
+}
+
+func TestMetadataSchemaMultipleOf(t *testing.T) {
+	s, err := loadMetadataSchema(writeTestSchema(t, `{"properties": {"price": {"multipleOf": 0.1}, "count": {"multipleOf": 3}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		meta  string
+		valid bool
+	}{
+		{`{"price": 0.3}`, true},
+		{`{"price": 19.99}`, false},
+		{`{"price": 1e21}`, true},
+		{`{"count": 9}`, true},
+		{`{"count": 10}`, false},
+	} {
+		var meta map[string]any
+		if err := json.Unmarshal([]byte(c.meta), &meta); err != nil {
+			t.Fatal(err)
+		}
+		if v := s.validate(meta); (len(v) == 0) != c.valid {
+			t.Errorf("%s: valid = %v, want %v (%+v)", c.meta, len(v) == 0, c.valid, v)
+		}
+	}
+}
+
+func TestLoadMetadataSchemaErrors(t *testing.T) {
+	cases := map[string]string{
+		`{"properties": {"a": {"contains": {}}}}`:   `#/properties/a: unsupported keyword "contains"`,
+		`{"$ref": "other.json#/x"}`:                 `only references within the schema`,
+		`{"$ref": "#/$defs/missing"}`:               `does not resolve`,
+		`{"pattern": "("}`:                          `invalid pattern`,
+		`{"type": "text"}`:                          `unknown type "text"`,
+		`{"required": "title"}`:                     `must be an array of strings`,
+		`[1, 2]`:                                    `a schema must be an object or a boolean`,
+		`{"allOf": [{"$ref": "#"}, {"$ref": "#"}]}`: `#/allOf/0: $ref cycle`,
+		`{"$defs": {"a": {"not": {"$ref": "#/$defs/b"}}, "b": {"anyOf": [{"$ref": "#/$defs/a"}]}}}`: `$ref cycle`,
+	}
+	for schema, want := range cases {
+		if _, err := loadMetadataSchema(writeTestSchema(t, schema)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected error containing %q, got %v", schema, want, err)
+		}
+	}
+	if _, err := loadMetadataSchema(writeTestSchema(t, `{"$defs": {"node": {"properties": {"next": {"$ref": "#/$defs/node"}}}}, "$ref": "#/$defs/node"}`)); err != nil {
+		t.Errorf("recursive schema: %v", err)
+	}
+}
+
+func TestMetadataSchemaCommands(t *testing.T) {
+	tmp := t.TempDir()
+	schema := writeTestSchema(t, testMetadataSchema)
+	mdPath := filepath.Join(tmp, "readme.md")
+	if err := os.WriteFile(mdPath, []byte("# Hello\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	metaPath := filepath.Join(tmp, "meta.json")
+	if err := os.WriteFile(metaPath, []byte(`{"title": "Guide", "version": "1.0"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(tmp, "out.mdocx")
+
+	_, err := executeCommand(rootCmd, "pack", mdPath, "--metadata", metaPath, "--metadata-schema", schema, "-o", out)
+	if err == nil || !strings.Contains(err.Error(), "metadata /audience: missing required property") ||
+		!strings.Contains(err.Error(), "metadata /version: string does not match pattern") {
+		t.Fatalf("expected schema violations, got %v", err)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Error("pack should not write output when metadata fails the schema")
+	}
+
+	if _, err := executeCommand(rootCmd, "pack", mdPath, "--metadata", metaPath, "-o", out); err != nil {
+		t.Fatalf("pack without schema: %v", err)
+	}
+	vout, err := executeCommand(rootCmd, "validate", "--json", "--metadata-schema", schema, out)
+	if err == nil {
+		t.Fatal("expected validate to fail the schema")
+	}
+	var result validationResult
+	if err := json.NewDecoder(strings.NewReader(vout)).Decode(&result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, vout)
+	}
+	var pointers []string
+	for _, f := range result.Findings {
+		if f.Rule == "MDX023" {
+			pointers = append(pointers, f.Pointer)
+		}
+	}
+	if strings.Join(pointers, " ") != "/audience /version" || result.Valid {
+		t.Errorf("expected MDX023 findings at /audience and /version, got %+v", result.Findings)
+	}
+
+	if _, err := executeCommand(rootCmd, "validate", "--metadata-schema", schema, "--rule-severity", "metadata-schema=warning", out); err != nil {
+		t.Errorf("downgraded schema findings should not fail validation: %v", err)
+	}
+}
@@ -23,12 +23,16 @@ var packCmd = &cobra.Command{
 		rootPath, _ := cmd.Flags().GetString("root")
 		compressionName, _ := cmd.Flags().GetString("compression")
 		outputPath, _ := cmd.Flags().GetString("output")
+		preserveAttrs, _ := cmd.Flags().GetBool("preserve-attrs")
+		portable, _ := cmd.Flags().GetBool("portable")
+		schemaPath, _ := cmd.Flags().GetString("metadata-schema")
+		sanitize, _ := cmd.Flags().GetBool("sanitize")
 
 		if mdDir == "" && len(args) == 0 {
 			return fmt.Errorf("provide markdown files or --markdown-dir")
 		}
 
-		markdownFiles, err := collectMarkdownFiles(args, mdDir)
+		markdownFiles, err := collectMarkdownFiles(args, mdDir, preserveAttrs)
 		if err != nil {
 			return fmt.Errorf("collect markdown: %w", err)
 		}
@@ -46,7 +50,7 @@ var packCmd = &cobra.Command{
 			}
 		}
 
-		mediaItems, err := collectMediaItems(mediaDir)
+		mediaItems, err := collectMediaItems(mediaDir, preserveAttrs)
 		if err != nil {
 			return fmt.Errorf("collect media: %w", err)
 		}
@@ -63,6 +67,15 @@ var packCmd = &cobra.Command{
 				return fmt.Errorf("read metadata: %w", err)
 			}
 		}
+		if schemaPath != "" {
+			schema, err := loadMetadataSchema(schemaPath)
+			if err != nil {
+				return err
+			}
+			if err := requireMetadataSchema(metadata, schema); err != nil {
+				return err
+			}
+		}
 
 		doc := &mdocx.Document{
 			Metadata: metadata,
@@ -80,6 +93,16 @@ var packCmd = &cobra.Command{
 		if err := validateContainerPaths(doc); err != nil {
 			return fmt.Errorf("invalid bundle: %w", err)
 		}
+		if portable {
+			if err := requirePortablePaths(doc); err != nil {
+				return err
+			}
+		}
+		if sanitize {
+			for _, c := range sanitizeDocument(doc) {
+				fmt.Fprintf(cmd.OutOrStdout(), "sanitized %s\n", c)
+			}
+		}
 
 		out, err := os.Create(outputPath)
 		if err != nil {
@@ -113,7 +136,11 @@ func init() {
 	packCmd.Flags().String("markdown-dir", "", "directory containing markdown files")
 	packCmd.Flags().String("media-dir", "", "directory containing media files")
 	packCmd.Flags().String("metadata", "", "path to metadata JSON file")
+	packCmd.Flags().String("metadata-schema", "", "JSON Schema file the metadata must match")
 	packCmd.Flags().String("root", "", "root markdown path inside the bundle")
 	packCmd.Flags().String("compression", "zstd", "compression (none|zip|zstd|lz4|br)")
 	packCmd.Flags().StringP("output", "o", "bundle.mdocx", "output .mdocx file")
+	packCmd.Flags().Bool("preserve-attrs", false, "record file modification times and permissions")
+	packCmd.Flags().Bool("portable", false, "refuse paths that can't be unpacked on Windows, macOS and Linux alike")
+	packCmd.Flags().Bool("sanitize", false, "strip scripts, event handlers, script URLs and remote resources from inline HTML and SVG media")
 }
@@ -37,7 +37,7 @@ func TestPackHelpers_RoundTripEncodeDecode(t *testing.T) {
 		t.Fatalf("write media: %v", err)
 	}
 
-	mdFiles, err := collectMarkdownFiles([]string{mdPath}, "")
+	mdFiles, err := collectMarkdownFiles([]string{mdPath}, "", false)
 	if err != nil {
 		t.Fatalf("collectMarkdownFiles: %v", err)
 	}
@@ -45,7 +45,7 @@ func TestPackHelpers_RoundTripEncodeDecode(t *testing.T) {
 		t.Fatalf("unexpected markdown file path: %#v", mdFiles)
 	}
 
-	mediaItems, err := collectMediaItems(mediaDir)
+	mediaItems, err := collectMediaItems(mediaDir, false)
 	if err != nil {
 		t.Fatalf("collectMediaItems: %v", err)
 	}
@@ -75,7 +75,7 @@ func TestPackHelpers_RoundTripEncodeDecode(t *testing.T) {
 		t.Fatalf("decode: %v", err)
 	}
 
-	result := buildValidationResult(decoded, nil, nil)
+	result := buildValidationResult(decoded, nil, nil, nil)
 	if !result.Valid {
 		t.Fatalf("expected valid")
 	}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/logicossoftware/go-mdocx"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// portableMaxSegment is the longest file or directory name most file
+	// systems accept: 255 bytes on Linux and macOS, 255 UTF-16 units on Windows.
+	portableMaxSegment = 255
+	// portableMaxPath leaves 20 characters for the output directory under
+	// Windows' 260-character MAX_PATH.
+	portableMaxPath = 240
+)
+
+// windowsIllegalChars can't appear in Windows file names, along with
+// control characters.
+const windowsIllegalChars = `<>:"|?*\`
+
+// isWindowsReservedName reports whether a path segment is a DOS device name
+// such as CON or com1.txt, which Windows won't create as a file.
+func isWindowsReservedName(seg string) bool {
+	base, _, _ := strings.Cut(seg, ".")
+	base = strings.ToUpper(strings.TrimRight(base, " "))
+	switch base {
+	case "CON", "PRN", "AUX", "NUL", "CONIN$", "CONOUT$":
+		return true
+	}
+	if len(base) == 4 && (strings.HasPrefix(base, "COM") || strings.HasPrefix(base, "LPT")) {
+		return base[3] >= '1' && base[3] <= '9'
+	}
+	return false
+}
+
+// checkPortability reports container paths that can't be unpacked on every
+// platform: names that collide on case-insensitive or normalizing file
+// systems, Windows reserved names, trailing dots and spaces, characters
+// Windows forbids, and names or paths beyond common length limits. Media
+// without a path is checked at media/<ID>, where unpack places it.
+func checkPortability(doc *mdocx.Document, r *findingReporter) {
+	var paths []string
+	for _, mf := range doc.Markdown.Files {
+		paths = append(paths, mf.Path)
+	}
+	paths = append(paths, newContainerIndex(doc).mediaPaths...)
+
+	byFold := make(map[string][]string)
+	byNorm := make(map[string][]string)
+	for _, p := range paths {
+		checkPortablePath(p, r)
+		byNorm[norm.NFC.String(p)] = append(byNorm[norm.NFC.String(p)], p)
+		folded := strings.ToLower(norm.NFC.String(p))
+		byFold[folded] = append(byFold[folded], p)
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(byNorm)) {
+		if group := distinct(byNorm[key]); len(group) > 1 {
+			r.report(ruleNormalizationCollision, finding{Message: fmt.Sprintf("paths differ only in Unicode normalization and collide on macOS: %s", quoteList(group)), Path: group[1]})
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(byFold)) {
+		group := distinct(byFold[key])
+		// Groups that only differ in normalization are reported above.
+		if len(distinctNFC(group)) > 1 {
+			r.report(ruleCaseCollision, finding{Message: fmt.Sprintf("paths differ only in case and collide on Windows and macOS: %s", quoteList(group)), Path: group[1]})
+		}
+	}
+}
+
+// requirePortablePaths returns an error listing every portability finding
+// in doc, for pack --portable.
+func requirePortablePaths(doc *mdocx.Document) error {
+	r := &findingReporter{}
+	checkPortability(doc, r)
+	if len(r.findings) == 0 {
+		return nil
+	}
+	msgs := make([]string, len(r.findings))
+	for i, f := range r.findings {
+		msgs[i] = fmt.Sprintf("  %s [%s %s]", f.Message, f.Rule, f.Name)
+	}
+	return fmt.Errorf("paths are not portable:\n%s", strings.Join(msgs, "\n"))
+}
+
+// checkPortablePath reports problems with the segments and length of p.
+func checkPortablePath(p string, r *findingReporter) {
+	for _, seg := range strings.Split(p, "/") {
+		if isWindowsReservedName(seg) {
+			r.report(ruleReservedName, finding{Message: fmt.Sprintf("path %q uses the reserved Windows name %q", p, seg), Path: p})
+		}
+		if strings.HasSuffix(seg, ".") || strings.HasSuffix(seg, " ") {
+			r.report(ruleReservedName, finding{Message: fmt.Sprintf("path %q has a name ending in a dot or space, which Windows strips", p), Path: p})
+		}
+		if i := strings.IndexFunc(seg, func(c rune) bool { return c < 0x20 || c == 0x7f || strings.ContainsRune(windowsIllegalChars, c) }); i >= 0 {
+			c, _ := utf8.DecodeRuneInString(seg[i:])
+			r.report(ruleIllegalCharacter, finding{Message: fmt.Sprintf("path %q contains %q, which is not allowed in Windows file names", p, c), Path: p})
+		}
+		// UTF-8 is never shorter than UTF-16, so the byte count covers both limits.
+		if len(seg) > portableMaxSegment {
+			r.report(rulePathTooLong, finding{Message: fmt.Sprintf("path %q has a %d-byte name; the limit is %d", p, len(seg), portableMaxSegment), Path: p})
+		}
+	}
+	if n := len(utf16.Encode([]rune(p))); n > portableMaxPath {
+		r.report(rulePathTooLong, finding{Message: fmt.Sprintf("path %q is %d characters long; keep paths under %d for Windows", p, n, portableMaxPath), Path: p})
+	}
+}
+
+// distinct returns the sorted unique strings in list.
+func distinct(list []string) []string {
+	set := make(map[string]bool, len(list))
+	for _, s := range list {
+		set[s] = true
+	}
+	return slices.Sorted(maps.Keys(set))
+}
+
+// distinctNFC returns the unique NFC forms of list.
+func distinctNFC(list []string) []string {
+	nfc := make([]string, len(list))
+	for i, s := range list {
+		nfc[i] = norm.NFC.String(s)
+	}
+	return distinct(nfc)
+}
+
+func quoteList(list []string) string {
+	quoted := make([]string, len(list))
+	for i, s := range list {
+		quoted[i] = fmt.Sprintf("%q", s)
+	}
+	return strings.Join(quoted, ", ")
+}
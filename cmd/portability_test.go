@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/logicossoftware/go-mdocx"
+)
+
+func TestIsWindowsReservedName(t *testing.T) {
+	for _, name := range []string{"CON", "con", "nul.txt", "COM1", "lpt9.md", "aux.tar.gz", "PRN "} {
+		if !isWindowsReservedName(name) {
+			t.Errorf("%q should be reserved", name)
+		}
+	}
+	for _, name := range []string{"console", "COM0", "COM10", "nullable.md", "readme.md", ""} {
+		if isWindowsReservedName(name) {
+			t.Errorf("%q should not be reserved", name)
+		}
+	}
+}
+
+func TestCheckPortability(t *testing.T) {
+	doc := &mdocx.Document{
+		Markdown: mdocx.MarkdownBundle{
+			BundleVersion: mdocx.VersionV1,
+			Files: []mdocx.MarkdownFile{
+				{Path: "README.md"},
+				{Path: "readme.md"},
+				{Path: "caf\u00e9.md"},
+				{Path: "cafe\u0301.md"},
+				{Path: "docs/con.md"},
+				{Path: "notes./a.md"},
+				{Path: "what?.md"},
+				{Path: strings.Repeat("a", 256) + ".md"},
+			},
+		},
+		Media: mdocx.MediaBundle{
+			BundleVersion: mdocx.VersionV1,
+			Items:         []mdocx.MediaItem{{ID: "AUX"}},
+		},
+	}
+	r := &findingReporter{}
+	checkPortability(doc, r)
+	var got []string
+	for _, f := range r.findings {
+		got = append(got, string(f.Rule)+" "+f.Path)
+	}
+	want := []string{
+		"MDX020 docs/con.md",
+		"MDX020 notes./a.md",
+		"MDX021 what?.md",
+		"MDX022 " + strings.Repeat("a", 256) + ".md",
+		"MDX022 " + strings.Repeat("a", 256) + ".md",
+		"MDX020 media/AUX",
+		"MDX019 caf\u00e9.md",
+		"MDX018 readme.md",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("findings:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestPackCommand_Portable(t *testing.T) {
+	dir := t.TempDir()
+	mdDir := filepath.Join(dir, "md")
+	if err := os.MkdirAll(mdDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Guide.md", "guide.md"} {
+		if err := os.WriteFile(filepath.Join(mdDir, name), []byte("# Guide\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if entries, _ := os.ReadDir(mdDir); len(entries) != 2 {
+		t.Skip("file system is case-insensitive")
+	}
+	out := filepath.Join(dir, "out.mdocx")
+
+	_, err := executeCommand(rootCmd, "pack", "--markdown-dir", mdDir, "--portable", "-o", out)
+	if err == nil || !strings.Contains(err.Error(), `"Guide.md", "guide.md"`) || !strings.Contains(err.Error(), "MDX018") {
+		t.Fatalf("expected a case collision error, got %v", err)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Errorf("pack --portable should not write output on failure")
+	}
+
+	if _, err := executeCommand(rootCmd, "pack", "--markdown-dir", mdDir, "-o", out); err != nil {
+		t.Fatalf("pack without --portable: %v", err)
+	}
+	vout, err := executeCommand(rootCmd, "validate", out)
+	if err != nil || !strings.Contains(vout, "WARNING: paths differ only in case") {
+		t.Errorf("expected a case collision warning, got %v:\n%s", err, vout)
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright © 2026 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/logicossoftware/go-mdocx"
+	"github.com/spf13/cobra"
+)
+
+// pruneCmd represents the prune command
+var pruneCmd = &cobra.Command{
+	Use:   "prune <file>",
+	Short: "Remove media items no markdown file references",
+	Long: `Rewrite an .mdocx bundle without the media items that no markdown link,
+image or metadata value references. Pass --output for a new bundle or
+--in-place to replace the input; each section keeps its compression.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		outputPath, _ := cmd.Flags().GetString("output")
+		inPlace, _ := cmd.Flags().GetBool("in-place")
+		strict, _ := cmd.Flags().GetBool("strict")
+		limits, err := limitsFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+		switch {
+		case inPlace && outputPath != "":
+			return fmt.Errorf("--in-place and --output are mutually exclusive")
+		case inPlace:
+			outputPath = args[0]
+		case outputPath == "" && !dryRun:
+			return fmt.Errorf("--output is required (or use --in-place or --dry-run)")
+		}
+
+		doc, err := decodeContainerFile(args[0], strict, limits)
+		if err != nil {
+			return err
+		}
+		_, used := scanReferences(doc)
+		orphans := orphanedMedia(used)
+
+		out := cmd.OutOrStdout()
+		if len(orphans) == 0 && (dryRun || outputPath == args[0]) {
+			fmt.Fprintf(out, "No unreferenced media in %s\n", args[0])
+			return nil
+		}
+		if dryRun {
+			saved := writeOrphans(out, doc, orphans, "would remove")
+			fmt.Fprintf(out, "Would remove %d of %d media items, saving %s of media data\n", len(orphans), len(doc.Media.Items), humanSize(saved))
+			return nil
+		}
+
+		markdownComp, mediaComp := sectionCompressions(args[0])
+		before := fileSize(args[0])
+		pruned := *doc
+		pruned.Media.Items = pruneMediaItems(doc.Media.Items, orphans)
+		if err := writeContainerFile(outputPath, &pruned, markdownComp, mediaComp); err != nil {
+			return err
+		}
+		saved := writeOrphans(out, doc, orphans, "removed")
+		fmt.Fprintf(out, "Removed %d of %d media items (%s of media data); wrote %s (%s -> %s)\n",
+			len(orphans), len(doc.Media.Items), humanSize(saved), outputPath, humanSize(int(before)), humanSize(int(fileSize(outputPath))))
+		return nil
+	},
+}
+
+// writeOrphans lists the orphaned media items and returns their total size.
+func writeOrphans(w io.Writer, doc *mdocx.Document, orphans []int, verb string) int {
+	total := 0
+	for _, i := range orphans {
+		mi := doc.Media.Items[i]
+		total += len(mi.Data)
+		fmt.Fprintf(w, "%s %s (%s, %s)\n", verb, mi.ID, orDash(mi.Path), humanSize(len(mi.Data)))
+	}
+	return total
+}
+
+// pruneMediaItems returns items without those at the sorted indexes in drop.
+func pruneMediaItems(items []mdocx.MediaItem, drop []int) []mdocx.MediaItem {
+	kept := make([]mdocx.MediaItem, 0, len(items)-len(drop))
+	for i, mi := range items {
+		if len(drop) > 0 && drop[0] == i {
+			drop = drop[1:]
+			continue
+		}
+		kept = append(kept, mi)
+	}
+	return kept
+}
+
+func init() {
+	rootCmd.AddCommand(pruneCmd)
+
+	pruneCmd.Flags().Bool("dry-run", false, "list unreferenced media and the bytes saved without writing")
+	pruneCmd.Flags().StringP("output", "o", "", "write the pruned bundle here")
+	pruneCmd.Flags().Bool("in-place", false, "replace the input bundle")
+	pruneCmd.Flags().Bool("strict", true, "fail on any spec violation")
+	addLimitFlags(pruneCmd)
+}
@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/logicossoftware/go-mdocx"
+)
+
+func pruneTestDoc() *mdocx.Document {
+	return &mdocx.Document{
+		Metadata: map[string]any{"cover": "mdocx://media/cover"},
+		Markdown: mdocx.MarkdownBundle{
+			BundleVersion: mdocx.VersionV1,
+			Files: []mdocx.MarkdownFile{
+				{Path: "readme.md", Content: []byte("# Home\n\n![Logo](assets/logo.png)\n\n```\n![Old](assets/old.png)\n```\n")},
+			},
+		},
+		Media: mdocx.MediaBundle{
+			BundleVersion: mdocx.VersionV1,
+			Items: []mdocx.MediaItem{
+				{ID: "logo", Path: "assets/logo.png", MIMEType: "image/png", Data: testPNG(0)},
+				{ID: "old", Path: "assets/old.png", MIMEType: "image/png", Data: testPNG(100)},
+				{ID: "cover", MIMEType: "image/png", Data: testPNG(0)},
+				{ID: "unused", MIMEType: "text/plain", Data: make([]byte, 20)},
+			},
+		},
+	}
+}
+
+func TestOrphanedMedia(t *testing.T) {
+	_, used := scanReferences(pruneTestDoc())
+	orphans := orphanedMedia(used)
+	if len(orphans) != 2 || orphans[0] != 1 || orphans[1] != 3 {
+		t.Errorf("expected old and unused to be orphaned, got %v", orphans)
+	}
+
+	result := buildValidationResult(pruneTestDoc(), nil, nil, nil)
+	if !result.Valid {
+		t.Errorf("orphaned media should only warn, got %v", result.Warnings)
+	}
+	if strings.Join(result.OrphanedMedia, ",") != "old,unused" {
+		t.Errorf("unexpected orphaned media: %v", result.OrphanedMedia)
+	}
+	if len(result.Warnings) != 2 || !strings.Contains(result.Warnings[0], `media item "old" is not referenced`) {
+		t.Errorf("unexpected warnings: %v", result.Warnings)
+	}
+}
+
+func TestOrphanedMedia_HTMLAttributes(t *testing.T) {
+	doc := pruneTestDoc()
+	doc.Metadata = nil
+	doc.Markdown.Files[0].Content = []byte("<img src=assets/logo.png alt=Logo>\n\n<img srcset=\"x.png 1x, assets/old.png 2x\">\n")
+	_, used := scanReferences(doc)
+	orphans := orphanedMedia(used)
+	if len(orphans) != 2 || orphans[0] != 2 || orphans[1] != 3 {
+		t.Errorf("expected only cover and unused to be orphaned, got %v", orphans)
+	}
+}
+
+func TestPruneKeepsMediaRefs(t *testing.T) {
+	doc := pruneTestDoc()
+	doc.Markdown.Files[0].MediaRefs = []string{"old"}
+	p := filepath.Join(t.TempDir(), "refs.mdocx")
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mdocx.Encode(f, doc); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	if out, err := executeCommand(rootCmd, "prune", "--in-place", p); err != nil {
+		t.Fatalf("prune failed: %v\n%s", err, out)
+	}
+	doc, err = decodeContainerFile(p, true, defaultDecodeLimits())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, mi := range doc.Media.Items {
+		ids = append(ids, mi.ID)
+	}
+	if strings.Join(ids, ",") != "logo,old,cover" {
+		t.Errorf("media listed in MediaRefs should be kept, got %v", ids)
+	}
+}
+
+func writePruneTestFile(t *testing.T) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "prune.mdocx")
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mdocx.Encode(f, pruneTestDoc(), mdocx.WithMarkdownCompression(mdocx.CompNone), mdocx.WithMediaCompression(mdocx.CompLZ4)); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	return p
+}
+
+func TestPruneDryRun(t *testing.T) {
+	p := writePruneTestFile(t)
+	before, _ := os.ReadFile(p)
+	out, err := executeCommand(rootCmd, "prune", "--dry-run", p)
+	if err != nil {
+		t.Fatalf("prune --dry-run failed: %v\n%s", err, out)
+	}
+	for _, want := range []string{"would remove old (assets/old.png, 100 B)", "would remove unused (-, 20 B)", "Would remove 2 of 4 media items, saving 120 B"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	after, _ := os.ReadFile(p)
+	if string(before) != string(after) {
+		t.Error("--dry-run modified the file")
+	}
+}
+
+func TestPruneRequiresOutput(t *testing.T) {
+	p := writePruneTestFile(t)
+	before, _ := os.ReadFile(p)
+	out, err := executeCommand(rootCmd, "prune", p)
+	if err == nil || !strings.Contains(out, "--output is required") {
+		t.Errorf("expected prune without -o or --in-place to fail, got %v:\n%s", err, out)
+	}
+	after, _ := os.ReadFile(p)
+	if string(before) != string(after) {
+		t.Error("prune without -o or --in-place modified the file")
+	}
+}
+
+func TestPruneInPlace(t *testing.T) {
+	p := writePruneTestFile(t)
+	out, err := executeCommand(rootCmd, "prune", "--in-place", p)
+	if err != nil {
+		t.Fatalf("prune failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "Removed 2 of 4 media items") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	doc, err := decodeContainerFile(p, true, defaultDecodeLimits())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, mi := range doc.Media.Items {
+		ids = append(ids, mi.ID)
+	}
+	if strings.Join(ids, ",") != "logo,cover" {
+		t.Errorf("unexpected media after prune: %v", ids)
+	}
+	if markdown, media := sectionCompressions(p); markdown != mdocx.CompNone || media != mdocx.CompLZ4 {
+		t.Errorf("section compression not preserved: %v %v", markdown, media)
+	}
+
+	out, err = executeCommand(rootCmd, "prune", "--in-place", p)
+	if err != nil || !strings.Contains(out, "No unreferenced media") {
+		t.Errorf("expected nothing left to prune, got %v:\n%s", err, out)
+	}
+}
+
+func TestPruneOutput(t *testing.T) {
+	p := writePruneTestFile(t)
+	before, _ := os.ReadFile(p)
+	dst := filepath.Join(filepath.Dir(p), "pruned.mdocx")
+	if out, err := executeCommand(rootCmd, "prune", "-o", dst, p); err != nil {
+		t.Fatalf("prune -o failed: %v\n%s", err, out)
+	}
+	after, _ := os.ReadFile(p)
+	if string(before) != string(after) {
+		t.Error("prune -o modified the input")
+	}
+	doc, err := decodeContainerFile(dst, true, defaultDecodeLimits())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Media.Items) != 2 {
+		t.Errorf("expected 2 media items in output, got %d", len(doc.Media.Items))
+	}
+	entries, _ := os.ReadDir(filepath.Dir(p))
+	if len(entries) != 2 {
+		t.Errorf("expected no temporary files left behind, got %d entries", len(entries))
+	}
+}
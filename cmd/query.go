@@ -0,0 +1,717 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A query is a small jq-like expression evaluated over JSON data, as decoded
+// by encoding/json into map[string]any, []any, string, float64, bool and nil.
+// The supported subset:
+//
+//	.  .key  ."key"  .[0]  .[-1]  .["key"]  .[]  a | b  a, b  (a)
+//	== != < <= > >=  ~ (regular expression match)  and  or
+//	"string"  123  true  false  null
+//	select(f)  map(f)  has("key")  length  keys  not
+//
+// Evaluating a query yields zero or more values, like a jq program.
+type query func(v any) ([]any, error)
+
+// compileQuery parses expr into a query.
+func compileQuery(expr string) (query, error) {
+	toks, err := lexQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &queryParser{toks: toks}
+	q, err := p.parsePipe()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("query: unexpected %q at offset %d", t.text, t.pos)
+	}
+	return q, nil
+}
+
+// runQuery compiles and evaluates expr against v.
+func runQuery(expr string, v any) ([]any, error) {
+	q, err := compileQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+	return q(v)
+}
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokDot
+	tokIdent
+	tokString
+	tokNumber
+	tokPunct // | , ( ) [ ]
+	tokOp    // == != < <= > >= ~
+)
+
+type queryToken struct {
+	kind tokKind
+	text string // identifier name, decoded string, number or operator
+	pos  int
+}
+
+func lexQuery(s string) ([]queryToken, error) {
+	var toks []queryToken
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '.':
+			toks = append(toks, queryToken{tokDot, ".", i})
+			i++
+		case strings.ContainsRune("|,()[]", rune(c)):
+			toks = append(toks, queryToken{tokPunct, string(c), i})
+			i++
+		case c == '~':
+			toks = append(toks, queryToken{tokOp, "~", i})
+			i++
+		case c == '=' || c == '!' || c == '<' || c == '>':
+			op := string(c)
+			if i+1 < len(s) && s[i+1] == '=' {
+				op += "="
+			}
+			if op == "=" || op == "!" {
+				return nil, fmt.Errorf("query: unexpected %q at offset %d", op, i)
+			}
+			toks = append(toks, queryToken{tokOp, op, i})
+			i += len(op)
+		case c == '"':
+			end := i + 1
+			for end < len(s) && s[end] != '"' {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(s) {
+				return nil, fmt.Errorf("query: unterminated string at offset %d", i)
+			}
+			var str string
+			if err := json.Unmarshal([]byte(s[i:end+1]), &str); err != nil {
+				return nil, fmt.Errorf("query: bad string at offset %d: %v", i, err)
+			}
+			toks = append(toks, queryToken{tokString, str, i})
+			i = end + 1
+		case c == '-' || (c >= '0' && c <= '9'):
+			end := i + 1
+			for end < len(s) && (s[end] >= '0' && s[end] <= '9' || s[end] == '.' || s[end] == 'e' || s[end] == 'E') {
+				end++
+			}
+			toks = append(toks, queryToken{tokNumber, s[i:end], i})
+			i = end
+		default:
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if !isIdentRune(r, true) {
+				return nil, fmt.Errorf("query: unexpected %q at offset %d", r, i)
+			}
+			end := i + size
+			for end < len(s) {
+				r, size := utf8.DecodeRuneInString(s[end:])
+				if !isIdentRune(r, false) {
+					break
+				}
+				end += size
+			}
+			toks = append(toks, queryToken{tokIdent, s[i:end], i})
+			i = end
+		}
+	}
+	return append(toks, queryToken{tokEOF, "end of query", len(s)}), nil
+}
+
+func isIdentRune(r rune, first bool) bool {
+	return r == '_' || unicode.IsLetter(r) || (!first && (unicode.IsDigit(r) || r == '-'))
+}
+
+type queryParser struct {
+	toks []queryToken
+	i    int
+}
+
+func (p *queryParser) peek() queryToken { return p.toks[p.i] }
+
+func (p *queryParser) next() queryToken {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *queryParser) accept(kind tokKind, text string) bool {
+	if t := p.peek(); t.kind == kind && t.text == text {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *queryParser) expect(kind tokKind, text string) error {
+	if !p.accept(kind, text) {
+		t := p.peek()
+		return fmt.Errorf("query: expected %q, got %q at offset %d", text, t.text, t.pos)
+	}
+	return nil
+}
+
+// parsePipe parses a | b | c.
+func (p *queryParser) parsePipe() (query, error) {
+	left, err := p.parseComma()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept(tokPunct, "|") {
+		right, err := p.parseComma()
+		if err != nil {
+			return nil, err
+		}
+		left = pipeQuery(left, right)
+	}
+	return left, nil
+}
+
+func pipeQuery(left, right query) query {
+	return func(v any) ([]any, error) {
+		in, err := left(v)
+		if err != nil {
+			return nil, err
+		}
+		var out []any
+		for _, x := range in {
+			res, err := right(x)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, res...)
+		}
+		return out, nil
+	}
+}
+
+// parseComma parses a, b: the outputs of a followed by those of b.
+func (p *queryParser) parseComma() (query, error) {
+	left, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept(tokPunct, ",") {
+		right, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(v any) ([]any, error) {
+			a, err := l(v)
+			if err != nil {
+				return nil, err
+			}
+			b, err := right(v)
+			if err != nil {
+				return nil, err
+			}
+			return append(a, b...), nil
+		}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseOr() (query, error) {
+	return p.parseLogical("or", p.parseAnd, func(a, b bool) bool { return a || b })
+}
+
+func (p *queryParser) parseAnd() (query, error) {
+	return p.parseLogical("and", p.parseCompare, func(a, b bool) bool { return a && b })
+}
+
+func (p *queryParser) parseLogical(word string, operand func() (query, error), combine func(a, b bool) bool) (query, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept(tokIdent, word) {
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryQuery(left, right, func(a, b any) (any, error) {
+			return combine(truthy(a), truthy(b)), nil
+		})
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseCompare() (query, error) {
+	left, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	if t.kind != tokOp {
+		return left, nil
+	}
+	p.next()
+	right, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+	return binaryQuery(left, right, func(a, b any) (any, error) { return compareValues(t.text, a, b) }), nil
+}
+
+// binaryQuery applies op to every pairing of the outputs of left and right.
+func binaryQuery(left, right query, op func(a, b any) (any, error)) query {
+	return func(v any) ([]any, error) {
+		as, err := left(v)
+		if err != nil {
+			return nil, err
+		}
+		bs, err := right(v)
+		if err != nil {
+			return nil, err
+		}
+		var out []any
+		for _, a := range as {
+			for _, b := range bs {
+				r, err := op(a, b)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, r)
+			}
+		}
+		return out, nil
+	}
+}
+
+// parsePostfix parses a term followed by any number of .key, [..] suffixes.
+func (p *queryParser) parsePostfix() (query, error) {
+	q, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch t := p.peek(); {
+		case t.kind == tokDot:
+			p.next()
+			step, err := p.parseDotStep()
+			if err != nil {
+				return nil, err
+			}
+			q = pipeQuery(q, step)
+		case t.kind == tokPunct && t.text == "[":
+			step, err := p.parseBracket()
+			if err != nil {
+				return nil, err
+			}
+			q = pipeQuery(q, step)
+		default:
+			return q, nil
+		}
+	}
+}
+
+func (p *queryParser) parseTerm() (query, error) {
+	t := p.next()
+	switch t.kind {
+	case tokDot:
+		// "." alone is the identity; ".key" and ".[...]" step into it. The
+		// key must follow the dot directly, so ". and ." is not a key.
+		if n := p.peek(); n.pos == t.pos+1 && (n.kind == tokIdent || n.kind == tokString || n.kind == tokPunct && n.text == "[") {
+			return p.parseDotStep()
+		}
+		return func(v any) ([]any, error) { return []any{v}, nil }, nil
+	case tokString:
+		return constQuery(t.text), nil
+	case tokNumber:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("query: bad number %q at offset %d", t.text, t.pos)
+		}
+		return constQuery(f), nil
+	case tokPunct:
+		if t.text == "(" {
+			q, err := p.parsePipe()
+			if err != nil {
+				return nil, err
+			}
+			return q, p.expect(tokPunct, ")")
+		}
+	case tokIdent:
+		return p.parseFunc(t)
+	}
+	return nil, fmt.Errorf("query: unexpected %q at offset %d", t.text, t.pos)
+}
+
+func constQuery(c any) query {
+	return func(any) ([]any, error) { return []any{c}, nil }
+}
+
+// parseDotStep parses the key after a '.'.
+func (p *queryParser) parseDotStep() (query, error) {
+	t := p.next()
+	switch t.kind {
+	case tokIdent, tokString:
+		return keyQuery(t.text), nil
+	case tokPunct:
+		if t.text == "[" {
+			p.i--
+			return p.parseBracket()
+		}
+	}
+	return nil, fmt.Errorf("query: expected key after '.', got %q at offset %d", t.text, t.pos)
+}
+
+// parseBracket parses [], [n] or ["key"].
+func (p *queryParser) parseBracket() (query, error) {
+	if err := p.expect(tokPunct, "["); err != nil {
+		return nil, err
+	}
+	if p.accept(tokPunct, "]") {
+		return iterateQuery, nil
+	}
+	t := p.next()
+	var q query
+	switch t.kind {
+	case tokString:
+		q = keyQuery(t.text)
+	case tokNumber:
+		n, err := strconv.Atoi(t.text)
+		if err != nil {
+			return nil, fmt.Errorf("query: bad index %q at offset %d", t.text, t.pos)
+		}
+		q = indexQuery(n)
+	default:
+		return nil, fmt.Errorf("query: expected index or key, got %q at offset %d", t.text, t.pos)
+	}
+	return q, p.expect(tokPunct, "]")
+}
+
+func keyQuery(key string) query {
+	return func(v any) ([]any, error) {
+		switch x := v.(type) {
+		case nil:
+			return []any{nil}, nil
+		case map[string]any:
+			return []any{x[key]}, nil
+		default:
+			return nil, fmt.Errorf("query: cannot index %s with %q", typeName(v), key)
+		}
+	}
+}
+
+func indexQuery(n int) query {
+	return func(v any) ([]any, error) {
+		switch x := v.(type) {
+		case nil:
+			return []any{nil}, nil
+		case []any:
+			i := n
+			if i < 0 {
+				i += len(x)
+			}
+			if i < 0 || i >= len(x) {
+				return []any{nil}, nil
+			}
+			return []any{x[i]}, nil
+		default:
+			return nil, fmt.Errorf("query: cannot index %s with number", typeName(v))
+		}
+	}
+}
+
+// iterateQuery yields the elements of an array or the values of an object,
+// the latter in key order.
+func iterateQuery(v any) ([]any, error) {
+	switch x := v.(type) {
+	case []any:
+		return x, nil
+	case map[string]any:
+		out := make([]any, 0, len(x))
+		for _, k := range sortedMapKeys(x) {
+			out = append(out, x[k])
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("query: cannot iterate over %s", typeName(v))
+	}
+}
+
+func (p *queryParser) parseFunc(name queryToken) (query, error) {
+	switch name.text {
+	case "true":
+		return constQuery(true), nil
+	case "false":
+		return constQuery(false), nil
+	case "null":
+		return constQuery(nil), nil
+	case "length":
+		return func(v any) ([]any, error) {
+			switch x := v.(type) {
+			case nil:
+				return []any{0.0}, nil
+			case string:
+				return []any{float64(utf8.RuneCountInString(x))}, nil
+			case []any:
+				return []any{float64(len(x))}, nil
+			case map[string]any:
+				return []any{float64(len(x))}, nil
+			case float64:
+				return []any{math.Abs(x)}, nil
+			default:
+				return nil, fmt.Errorf("query: %s has no length", typeName(v))
+			}
+		}, nil
+	case "keys":
+		return func(v any) ([]any, error) {
+			m, ok := v.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("query: %s has no keys", typeName(v))
+			}
+			keys := sortedMapKeys(m)
+			out := make([]any, len(keys))
+			for i, k := range keys {
+				out[i] = k
+			}
+			return []any{out}, nil
+		}, nil
+	case "not":
+		return func(v any) ([]any, error) { return []any{!truthy(v)}, nil }, nil
+	case "select", "map", "has":
+		if err := p.expect(tokPunct, "("); err != nil {
+			return nil, err
+		}
+		arg, err := p.parsePipe()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokPunct, ")"); err != nil {
+			return nil, err
+		}
+		switch name.text {
+		case "select":
+			return func(v any) ([]any, error) {
+				conds, err := arg(v)
+				if err != nil {
+					return nil, err
+				}
+				var out []any
+				for _, c := range conds {
+					if truthy(c) {
+						out = append(out, v)
+					}
+				}
+				return out, nil
+			}, nil
+		case "map":
+			return func(v any) ([]any, error) {
+				items, err := iterateQuery(v)
+				if err != nil {
+					return nil, err
+				}
+				out := make([]any, 0, len(items))
+				for _, item := range items {
+					res, err := arg(item)
+					if err != nil {
+						return nil, err
+					}
+					out = append(out, res...)
+				}
+				return []any{out}, nil
+			}, nil
+		default: // has
+			return func(v any) ([]any, error) {
+				keys, err := arg(v)
+				if err != nil {
+					return nil, err
+				}
+				m, ok := v.(map[string]any)
+				if !ok {
+					return nil, fmt.Errorf("query: cannot check keys of %s", typeName(v))
+				}
+				out := make([]any, len(keys))
+				for i, k := range keys {
+					s, _ := k.(string)
+					_, out[i] = m[s]
+				}
+				return out, nil
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("query: unknown function %q at offset %d", name.text, name.pos)
+}
+
+// truthy follows jq: only false and null are false.
+func truthy(v any) bool {
+	return v != nil && v != false
+}
+
+func compareValues(op string, a, b any) (any, error) {
+	switch op {
+	case "==":
+		return reflect.DeepEqual(a, b), nil
+	case "!=":
+		return !reflect.DeepEqual(a, b), nil
+	case "~":
+		pattern, ok := b.(string)
+		if !ok {
+			return nil, fmt.Errorf("query: ~ needs a string pattern, got %s", typeName(b))
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("query: %w", err)
+		}
+		s, ok := a.(string)
+		return ok && re.MatchString(s), nil
+	}
+
+	var cmp int
+	switch x := a.(type) {
+	case float64:
+		y, ok := b.(float64)
+		if !ok {
+			return nil, fmt.Errorf("query: cannot compare number with %s", typeName(b))
+		}
+		cmp = compareOrdered(x, y)
+	case string:
+		y, ok := b.(string)
+		if !ok {
+			return nil, fmt.Errorf("query: cannot compare string with %s", typeName(b))
+		}
+		cmp = strings.Compare(x, y)
+	default:
+		return nil, fmt.Errorf("query: cannot order %s", typeName(a))
+	}
+	switch op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default: // >=
+		return cmp >= 0, nil
+	}
+}
+
+func compareOrdered(x, y float64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func sortedMapKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// queryData converts v to the generic form queries and templates work on,
+// keyed by JSON field names.
+func queryData(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var data any
+	if err := json.Unmarshal(b, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// writeQueryResults prints each value expr yields on its own line: strings
+// as-is, everything else as compact JSON.
+func writeQueryResults(w io.Writer, expr string, data any) error {
+	results, err := runQuery(expr, data)
+	if err != nil {
+		return err
+	}
+	for _, r := range results {
+		if s, ok := r.(string); ok {
+			fmt.Fprintln(w, s)
+			continue
+		}
+		b, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(b))
+	}
+	return nil
+}
+
+// executeTemplate renders text as a Go text/template over data. Besides the
+// builtins, templates can use json, join and size (human-readable bytes).
+func executeTemplate(w io.Writer, text string, data any) error {
+	tmpl, err := template.New("inspect").Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+		"join": func(sep string, v []any) string {
+			parts := make([]string, len(v))
+			for i, x := range v {
+				parts[i] = fmt.Sprint(x)
+			}
+			return strings.Join(parts, sep)
+		},
+		"size": func(v float64) string { return humanSize(int(v)) },
+	}).Parse(text)
+	if err != nil {
+		return fmt.Errorf("template: %w", err)
+	}
+	if err := tmpl.Execute(w, data); err != nil {
+		return fmt.Errorf("template: %w", err)
+	}
+	if !strings.HasSuffix(text, "\n") {
+		fmt.Fprintln(w)
+	}
+	return nil
+}
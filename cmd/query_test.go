@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const queryTestJSON = `{
+  "root_path": "readme.md",
+  "metadata": {"title": "Guide", "tags": ["a", "b"], "draft": false},
+  "markdown": [{"path": "readme.md", "words": 10}, {"path": "docs/x.md", "words": 3}],
+  "media": [
+    {"id": "logo", "mime": "image/png", "size": 100},
+    {"id": "data", "mime": "application/json", "size": 5}
+  ]
+}`
+
+func TestRunQuery(t *testing.T) {
+	var data any
+	if err := json.Unmarshal([]byte(queryTestJSON), &data); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		expr string
+		want string // results as compact JSON, space-separated
+	}{
+		{".", ""},
+		{".metadata.title", `"Guide"`},
+		{`."root_path"`, `"readme.md"`},
+		{`.metadata["tags"][1]`, `"b"`},
+		{".metadata.tags[-1]", `"b"`},
+		{".metadata.missing", "null"},
+		{".metadata.tags[]", `"a" "b"`},
+		{`.media[] | select(.mime ~ "image/") | .id`, `"logo"`},
+		{".markdown[] | select(.words >= 5 and .path != \"x\") | .path", `"readme.md"`},
+		{".markdown[] | .path, .words", `"readme.md" 10 "docs/x.md" 3`},
+		{".media | map(.size)", "[100,5]"},
+		{".media | length", "2"},
+		{".metadata | keys", `["draft","tags","title"]`},
+		{`.metadata | has("title"), has("nope")`, "true false"},
+		{".metadata.draft | not", "true"},
+		{"(.media[0].size > 50) or false", "true"},
+		{".metadata.title == \"Guide\"", "true"},
+	}
+	for _, tc := range cases {
+		results, err := runQuery(tc.expr, data)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.expr, err)
+			continue
+		}
+		if tc.expr == "." {
+			if len(results) != 1 {
+				t.Errorf(".: expected the input back, got %d results", len(results))
+			}
+			continue
+		}
+		var got []string
+		for _, r := range results {
+			b, _ := json.Marshal(r)
+			got = append(got, string(b))
+		}
+		if strings.Join(got, " ") != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.expr, strings.Join(got, " "), tc.want)
+		}
+	}
+}
+
+func TestRunQuery_Errors(t *testing.T) {
+	var data any
+	if err := json.Unmarshal([]byte(queryTestJSON), &data); err != nil {
+		t.Fatal(err)
+	}
+	for _, expr := range []string{"", ".metadata.title.x", ".root_path[]", "frobnicate", ".a = 1", `"unterminated`, ".media[", "select(.a", ".media | length > \"x\""} {
+		if _, err := runQuery(expr, data); err == nil {
+			t.Errorf("%q: expected error", expr)
+		}
+	}
+}
+
+func TestExecuteTemplate(t *testing.T) {
+	var data any
+	if err := json.Unmarshal([]byte(queryTestJSON), &data); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	err := executeTemplate(&buf, `{{.metadata.title}} [{{join "," .metadata.tags}}]{{range .media}} {{.id}}={{size .size}}{{end}}`, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "Guide [a,b] logo=100 B data=5 B\n" {
+		t.Errorf("unexpected output: %q", got)
+	}
+	if err := executeTemplate(&buf, "{{.metadata.title", data); err == nil {
+		t.Error("expected parse error")
+	}
+}
+
+func TestInspectCommand_QueryAndTemplate(t *testing.T) {
+	tmp := t.TempDir()
+	p := createTestMDOCX(t, filepath.Join(tmp, "test.mdocx"), map[string]any{"title": "Handbook"})
+
+	out, err := executeCommand(rootCmd, "inspect", "--query", ".metadata.title", p)
+	if err != nil {
+		t.Fatalf("inspect --query failed: %v", err)
+	}
+	if out != "Handbook\n" {
+		t.Errorf("expected raw string, got %q", out)
+	}
+
+	out, err = executeCommand(rootCmd, "inspect", "--query", `.media[] | select(.mime ~ "image/") | .path`, p)
+	if err != nil {
+		t.Fatalf("inspect --query failed: %v", err)
+	}
+	if out != "assets/logo.png\n" {
+		t.Errorf("unexpected query output: %q", out)
+	}
+
+	out, err = executeCommand(rootCmd, "inspect", "--template", "{{.root_path}} {{len .markdown}}", p)
+	if err != nil {
+		t.Fatalf("inspect --template failed: %v", err)
+	}
+	if out != "readme.md 2\n" {
+		t.Errorf("unexpected template output: %q", out)
+	}
+
+	if _, err := executeCommand(rootCmd, "inspect", "--query", ".", "--template", "x", p); err == nil {
+		t.Error("expected error for --query with --template")
+	}
+	if _, err := executeCommand(rootCmd, "inspect", "--query", ".", "--format", "csv", p); err == nil {
+		t.Error("expected error for --query with --format")
+	}
+}
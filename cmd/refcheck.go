@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/logicossoftware/go-mdocx"
+)
+
+// brokenReference is a link or image in a markdown file whose target should
+// be in the container but isn't, or whose #fragment names no heading.
+type brokenReference struct {
+	File    string `json:"file"`
+	Offset  int    `json:"offset"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Target  string `json:"target"`
+	Message string `json:"message"`
+	Anchor  bool   `json:"anchor,omitempty"` // the target exists but the #fragment doesn't
+}
+
+func (r brokenReference) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s", r.File, r.Line, r.Column, r.Message)
+}
+
+// scanReferences resolves every internal link and image in the container's
+// markdown files, skipping external URLs. It returns the references that
+// don't resolve and, per media item, whether anything references it. IDs
+// listed in a file's MediaRefs and string values in the metadata that
+// resolve to a media item (e.g. a cover image) count as references too.
+func scanReferences(doc *mdocx.Document) ([]brokenReference, []bool) {
+	ix := newContainerIndex(doc)
+	parsed := make([]*markdownDoc, len(doc.Markdown.Files))
+	for i, mf := range doc.Markdown.Files {
+		parsed[i] = parseMarkdown(mf.Content)
+	}
+	anchors := make([]map[string]bool, len(doc.Markdown.Files))
+	anchorsOf := func(i int) map[string]bool {
+		if anchors[i] == nil {
+			anchors[i] = markdownAnchors(parsed[i])
+		}
+		return anchors[i]
+	}
+
+	used := make([]bool, len(doc.Media.Items))
+	var broken []brokenReference
+	for i, mf := range doc.Markdown.Files {
+		for _, id := range mf.MediaRefs {
+			if m, ok := ix.mediaByID[id]; ok {
+				used[m] = true
+			}
+		}
+		for _, link := range parsed[i].Links {
+			ref, internal := ix.resolve(mf.Path, link.Target)
+			if !internal {
+				continue
+			}
+			if ref.Media >= 0 {
+				used[ref.Media] = true
+			}
+			kind := "link"
+			if link.Image {
+				kind = "image"
+			}
+			report := func(format string, args ...any) {
+				broken = append(broken, brokenReference{
+					File:    mf.Path,
+					Offset:  link.Start,
+					Anchor:  ref.found(),
+					Line:    link.Line,
+					Column:  link.Col,
+					Target:  link.Target,
+					Message: fmt.Sprintf("%s %q: ", kind, link.Target) + fmt.Sprintf(format, args...),
+				})
+			}
+			switch {
+			case !ref.found() && strings.HasPrefix(strings.ToLower(link.Target), mdocxMediaPrefix):
+				report("no media item with this ID")
+			case !ref.found():
+				report("no markdown file or media item at %q", ref.Path)
+			case ref.Markdown >= 0 && ref.Fragment != "":
+				fragment := ref.Fragment
+				if decoded, err := url.PathUnescape(fragment); err == nil {
+					fragment = decoded
+				}
+				if !anchorsOf(ref.Markdown)[strings.ToLower(fragment)] {
+					report("no heading with anchor #%s in %s", fragment, ref.Path)
+				}
+			}
+		}
+	}
+
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case string:
+			if ref, internal := ix.resolve("", v); internal && ref.Media >= 0 {
+				used[ref.Media] = true
+			}
+		case []any:
+			for _, e := range v {
+				walk(e)
+			}
+		case map[string]any:
+			for _, e := range v {
+				walk(e)
+			}
+		}
+	}
+	walk(doc.Metadata)
+	return broken, used
+}
+
+// orphanedMedia returns the indexes of media items nothing references.
+func orphanedMedia(used []bool) []int {
+	var orphans []int
+	for i, u := range used {
+		if !u {
+			orphans = append(orphans, i)
+		}
+	}
+	return orphans
+}
+
+var (
+	// htmlAnchorPattern matches explicit id and name attributes, which are
+	// link targets just like headings.
+	htmlAnchorPattern = regexp.MustCompile(`<[a-zA-Z][^>]*?\s(?:id|name)\s*=\s*["']([^"']+)["']`)
+	inlineLinkPattern = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+	htmlTagPattern    = regexp.MustCompile(`<[^>]+>`)
+)
+
+// markdownAnchors returns the lower-cased fragments that resolve within a
+// markdown file: heading slugs, numbered as GitHub does for duplicates, plus
+// explicit HTML id and name attributes.
+func markdownAnchors(d *markdownDoc) map[string]bool {
+	anchors := make(map[string]bool)
+	seen := make(map[string]int)
+	for _, h := range d.Headings {
+		slug := headingSlug(h.Text)
+		if n := seen[slug]; n > 0 {
+			anchors[fmt.Sprintf("%s-%d", slug, n)] = true
+		} else {
+			anchors[slug] = true
+		}
+		seen[slug]++
+	}
+	for _, m := range htmlAnchorPattern.FindAllSubmatch(d.masked, -1) {
+		anchors[strings.ToLower(string(m[1]))] = true
+	}
+	return anchors
+}
+
+// headingSlug converts heading text to its anchor the way GitHub does:
+// markup and punctuation are dropped, letters are lower-cased and spaces
+// become hyphens.
+func headingSlug(text string) string {
+	text = inlineLinkPattern.ReplaceAllString(text, "$1")
+	text = htmlTagPattern.ReplaceAllString(text, "")
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteByte('-')
+		}
+	}
+	return b.String()
+}
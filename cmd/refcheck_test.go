@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/logicossoftware/go-mdocx"
+)
+
+func TestHeadingSlug(t *testing.T) {
+	cases := map[string]string{
+		"Getting Started":           "getting-started",
+		"What's new in v1.2?":       "whats-new-in-v12",
+		"`code` and **bold**":       "code-and-bold",
+		"See [the guide](guide.md)": "see-the-guide",
+		"snake_case & kebab-case":   "snake_case--kebab-case",
+		"Über <em>straße</em>":      "über-straße",
+		"  Trailing  ":              "--trailing--",
+	}
+	for in, want := range cases {
+		if got := headingSlug(in); got != want {
+			t.Errorf("headingSlug(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestMarkdownAnchors(t *testing.T) {
+	d := parseMarkdown([]byte("# Intro\n\n## Setup\n\n## Setup\n\n<a id=\"Custom\"></a>\n\n```\n# Not a heading\n```\n"))
+	anchors := markdownAnchors(d)
+	for _, want := range []string{"intro", "setup", "setup-1", "custom"} {
+		if !anchors[want] {
+			t.Errorf("missing anchor %q in %v", want, anchors)
+		}
+	}
+	if anchors["not-a-heading"] {
+		t.Error("headings inside code blocks should not produce anchors")
+	}
+}
+
+func TestMarkdownAnchors_InlineCode(t *testing.T) {
+	d := parseMarkdown([]byte("# Intro\n\n## The `run` command\n\nUsing ``a`b``\n-------------\n\n[x](#the-run-command) [y](#using-ab)\n"))
+	anchors := markdownAnchors(d)
+	for _, want := range []string{"the-run-command", "using-ab"} {
+		if !anchors[want] {
+			t.Errorf("missing anchor %q in %v", want, anchors)
+		}
+	}
+
+	doc := &mdocx.Document{Markdown: mdocx.MarkdownBundle{
+		BundleVersion: mdocx.VersionV1,
+		Files:         []mdocx.MarkdownFile{{Path: "a.md", Content: d.content}},
+	}}
+	if broken, _ := scanReferences(doc); len(broken) != 0 {
+		t.Errorf("links to headings with inline code reported broken: %v", broken)
+	}
+}
+
+func refCheckDoc() *mdocx.Document {
+	return &mdocx.Document{
+		Markdown: mdocx.MarkdownBundle{
+			BundleVersion: mdocx.VersionV1,
+			RootPath:      "readme.md",
+			Files: []mdocx.MarkdownFile{
+				{Path: "readme.md", Content: []byte("# Home\n\n" +
+					"[Guide](docs/guide.md#install) and [self](#home).\n" +
+					"![Logo](assets/logo.png) ![Icon](mdocx://media/icon)\n" +
+					"[Web](https://example.com) `[code](missing.md)`\n")},
+				{Path: "docs/guide.md", Content: []byte("# Guide\n\n## Install\n\n" +
+					"[Back](../readme.md) [Gone](gone.md)\n" +
+					"  ![Missing](mdocx://media/nope) [Bad anchor](../readme.md#nowhere)\n")},
+			},
+		},
+		Media: mdocx.MediaBundle{
+			BundleVersion: mdocx.VersionV1,
+			Items: []mdocx.MediaItem{
+				{ID: "logo", Path: "assets/logo.png", MIMEType: "image/png", Data: testPNG(0)},
+				{ID: "icon", MIMEType: "image/png", Data: testPNG(0)},
+			},
+		},
+	}
+}
+
+func TestCheckReferences(t *testing.T) {
+	broken, _ := scanReferences(refCheckDoc())
+	var got []string
+	for _, b := range broken {
+		got = append(got, b.String())
+	}
+	want := []string{
+		`docs/guide.md:5:29: link "gone.md": no markdown file or media item at "docs/gone.md"`,
+		`docs/guide.md:6:14: image "mdocx://media/nope": no media item with this ID`,
+		`docs/guide.md:6:47: link "../readme.md#nowhere": no heading with anchor #nowhere in readme.md`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("broken references:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestValidateReportsBrokenReferences(t *testing.T) {
+	doc := refCheckDoc()
+	doc.Markdown.RootPath = "index.md"
+	result := buildValidationResult(doc, nil, nil, nil)
+	if result.Valid {
+		t.Fatal("expected broken references to make the container invalid")
+	}
+	if len(result.BrokenReferences) != 3 {
+		t.Errorf("expected 3 broken references, got %+v", result.BrokenReferences)
+	}
+	if !strings.Contains(strings.Join(result.Errors, "\n"), `RootPath "index.md" does not match any markdown file`) {
+		t.Errorf("expected a RootPath error, got %v", result.Errors)
+	}
+
+	p := filepath.Join(t.TempDir(), "refs.mdocx")
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mdocx.Encode(f, doc); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	out, err := executeCommand(rootCmd, "validate", p)
+	if err == nil {
+		t.Fatal("expected validate to fail")
+	}
+	if !strings.Contains(out, "ERROR: docs/guide.md:5:29: link \"gone.md\"") {
+		t.Errorf("expected a located warning, got:\n%s", out)
+	}
+}
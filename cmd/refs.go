@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/logicossoftware/go-mdocx"
+)
+
+// containerIndex looks up markdown files and media items by container path
+// and media ID, for resolving references found in markdown.
+type containerIndex struct {
+	markdown    map[string]int
+	mediaByPath map[string]int
+	mediaByID   map[string]int
+	mediaPaths  []string // container path of each media item as unpack places it
+}
+
+func newContainerIndex(doc *mdocx.Document) *containerIndex {
+	ix := &containerIndex{
+		markdown:    make(map[string]int, len(doc.Markdown.Files)),
+		mediaByPath: make(map[string]int, len(doc.Media.Items)),
+		mediaByID:   make(map[string]int, len(doc.Media.Items)),
+		mediaPaths:  make([]string, len(doc.Media.Items)),
+	}
+	for i, mf := range doc.Markdown.Files {
+		ix.markdown[mf.Path] = i
+	}
+	for i, mi := range doc.Media.Items {
+		ix.mediaByID[mi.ID] = i
+		p := mi.Path
+		if p == "" {
+			// unpack places path-less media here.
+			p = path.Join("media", mi.ID)
+		}
+		ix.mediaByPath[p] = i
+		ix.mediaPaths[i] = p
+	}
+	return ix
+}
+
+// resolvedRef is the container entry a markdown reference points at.
+type resolvedRef struct {
+	Path     string // container path the reference resolves to
+	Fragment string // text after '#', if any
+	Markdown int    // index into Markdown.Files, or -1
+	Media    int    // index into Media.Items, or -1
+}
+
+// found reports whether the reference resolved to an existing entry.
+func (r resolvedRef) found() bool { return r.Markdown >= 0 || r.Media >= 0 }
+
+// resolve interprets target as written in the markdown file at fromPath.
+// It returns false for external URLs, which don't point into the container.
+// Relative targets resolve against fromPath's directory, targets starting
+// with "/" against the container root, and mdocx://media/<ID> by media ID.
+func (ix *containerIndex) resolve(fromPath, target string) (resolvedRef, bool) {
+	ref := resolvedRef{Markdown: -1, Media: -1}
+	target = strings.TrimSpace(target)
+	if target == "" || isExternalTarget(target) {
+		return ref, false
+	}
+
+	if len(target) >= len(mdocxMediaPrefix) && strings.EqualFold(target[:len(mdocxMediaPrefix)], mdocxMediaPrefix) {
+		id, fragment := splitLinkTarget(target[len(mdocxMediaPrefix):])
+		ref.Fragment = fragment
+		if decoded, err := url.PathUnescape(id); err == nil {
+			id = decoded
+		}
+		if i, ok := ix.mediaByID[id]; ok {
+			ref.Media = i
+			ref.Path = ix.mediaPaths[i]
+		}
+		return ref, true
+	}
+
+	p, fragment := splitLinkTarget(target)
+	ref.Fragment = fragment
+	if p == "" {
+		ref.Path = fromPath
+		ref.Markdown = ix.markdownIndex(fromPath)
+		return ref, true
+	}
+	if decoded, err := url.PathUnescape(p); err == nil {
+		p = decoded
+	}
+	if strings.HasPrefix(p, "/") {
+		p = path.Clean(p)[1:]
+	} else {
+		p = path.Join(path.Dir(fromPath), p)
+	}
+	ref.Path = p
+	if i, ok := ix.markdown[p]; ok {
+		ref.Markdown = i
+	} else if i, ok := ix.mediaByPath[p]; ok {
+		ref.Media = i
+	}
+	return ref, true
+}
+
+func (ix *containerIndex) markdownIndex(p string) int {
+	if i, ok := ix.markdown[p]; ok {
+		return i
+	}
+	return -1
+}
+
+// relativeSlashPath returns a slash-separated path to target relative to the
+// directory fromDir. Both are slash-separated and relative to the same root.
+func relativeSlashPath(fromDir, target string) string {
+	split := func(p string) []string {
+		p = path.Clean(p)
+		if p == "." || p == "" {
+			return nil
+		}
+		return strings.Split(p, "/")
+	}
+	from, to := split(fromDir), split(target)
+	i := 0
+	for i < len(from) && i < len(to) && from[i] == to[i] {
+		i++
+	}
+	parts := make([]string, 0, len(from)-i+len(to)-i)
+	for range from[i:] {
+		parts = append(parts, "..")
+	}
+	parts = append(parts, to[i:]...)
+	if len(parts) == 0 {
+		return "."
+	}
+	return strings.Join(parts, "/")
+}
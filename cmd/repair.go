@@ -0,0 +1,226 @@
+/*
+Copyright © 2026 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/logicossoftware/go-mdocx"
+	"github.com/spf13/cobra"
+)
+
+// repairCmd represents the repair command
+var repairCmd = &cobra.Command{
+	Use:   "repair <file>",
+	Short: "Fix recoverable defects in an .mdocx bundle",
+	Long: `Decode an .mdocx bundle leniently and write a corrected copy. Repair
+normalizes the fixed header (version, header size, flags and reserved bytes)
+and section headers, sets bundle versions to 1, renames duplicate media IDs
+and markdown paths, and adds or corrects media SHA-256 hashes. Each applied
+fix is listed. Defects that lose data, such as truncated sections, are not
+repaired.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		outputPath, _ := cmd.Flags().GetString("output")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		limits, err := limitsFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+		if outputPath == "" && !dryRun {
+			return fmt.Errorf("--output is required (or use --dry-run)")
+		}
+
+		doc, fixes, err := decodeForRepair(args[0], limits)
+		if err != nil {
+			return err
+		}
+		fixes = append(fixes, repairDocument(doc)...)
+
+		out := cmd.OutOrStdout()
+		verb := "fixed"
+		if dryRun {
+			verb = "would fix"
+		}
+		for _, f := range fixes {
+			fmt.Fprintf(out, "%s: %s\n", verb, f)
+		}
+		if dryRun {
+			fmt.Fprintf(out, "%d fixes needed\n", len(fixes))
+			return nil
+		}
+		markdownComp, mediaComp := sectionCompressions(args[0])
+		if err := writeContainerFile(outputPath, doc, markdownComp, mediaComp); err != nil {
+			return err
+		}
+		if len(fixes) == 0 {
+			fmt.Fprintf(out, "No defects found; wrote %s\n", outputPath)
+			return nil
+		}
+		fmt.Fprintf(out, "Applied %d fixes; wrote %s\n", len(fixes), outputPath)
+		return nil
+	},
+}
+
+// decodeForRepair decodes the container at input without the header,
+// section and document checks of mdocx.Decode, so that defects repair can
+// fix don't stop it. It returns the header-level fixes writing the document
+// back out will make.
+func decodeForRepair(input string, limits decodeLimits) (*mdocx.Document, []string, error) {
+	header, err := readHeaderInfo(input)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read header: %w", err)
+	}
+	if !header.MagicValid {
+		return nil, nil, fmt.Errorf("decode: %w: not an MDOCX file", mdocx.ErrInvalidMagic)
+	}
+	var fixes []string
+	if header.Version != mdocx.VersionV1 {
+		fixes = append(fixes, fmt.Sprintf("header version %d set to %d", header.Version, mdocx.VersionV1))
+	}
+	if header.FixedHdrSize != fixedHeaderSize {
+		fixes = append(fixes, fmt.Sprintf("fixed header size %d set to %d", header.FixedHdrSize, fixedHeaderSize))
+	}
+	if extra := header.HeaderFlags &^ mdocx.HeaderFlagMetadataJSON; extra != 0 {
+		fixes = append(fixes, fmt.Sprintf("cleared unknown header flag bits 0x%04x", extra))
+	}
+	if !header.ReservedClean {
+		fixes = append(fixes, "cleared reserved header bytes 20-31")
+	}
+
+	layout, err := readContainerLayout(input)
+	if err != nil {
+		return nil, nil, fmt.Errorf("decode: %w", err)
+	}
+	if err := limits.checkLayout(layout); err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(input)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open: %w", err)
+	}
+	defer f.Close()
+
+	doc := &mdocx.Document{}
+	if layout.MetadataLength > 0 {
+		if header.HeaderFlags&mdocx.HeaderFlagMetadataJSON == 0 {
+			fixes = append(fixes, "set the METADATA_JSON header flag")
+		}
+		mr := io.NewSectionReader(f, layout.MetadataOffset, int64(layout.MetadataLength))
+		if err := json.NewDecoder(mr).Decode(&doc.Metadata); err != nil {
+			return nil, nil, fmt.Errorf("decode metadata: %w", err)
+		}
+	}
+
+	want := []mdocx.SectionType{mdocx.SectionMarkdown, mdocx.SectionMedia}
+	out := []any{&doc.Markdown, &doc.Media}
+	for i, sec := range layout.Sections {
+		name := sectionTypeName(uint16(want[i]))
+		if mdocx.SectionType(sec.Type) != want[i] {
+			fixes = append(fixes, fmt.Sprintf("section %d type %d set to %d (%s)", i+1, sec.Type, want[i], name))
+		}
+		if sec.Reserved != 0 {
+			fixes = append(fixes, fmt.Sprintf("cleared reserved bytes of the %s section header", name))
+		}
+		if i == 1 && sec.PayloadLen == 0 {
+			doc.Media.BundleVersion = mdocx.VersionV1
+			continue
+		}
+		r, err := sectionPayloadReader(f, sec)
+		if err != nil {
+			return nil, nil, fmt.Errorf("decode %s: %w", name, err)
+		}
+		err = gob.NewDecoder(r).Decode(out[i])
+		r.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("decode %s: %w", name, err)
+		}
+	}
+	if err := limits.checkDocument(doc); err != nil {
+		return nil, nil, err
+	}
+	return doc, fixes, nil
+}
+
+// repairDocument fixes bundle versions, duplicate media IDs and markdown
+// paths, and media hashes in place, and describes each fix.
+func repairDocument(doc *mdocx.Document) []string {
+	var fixes []string
+	if v := doc.Markdown.BundleVersion; v != mdocx.VersionV1 {
+		doc.Markdown.BundleVersion = mdocx.VersionV1
+		fixes = append(fixes, fmt.Sprintf("markdown BundleVersion %d set to %d", v, mdocx.VersionV1))
+	}
+	if v := doc.Media.BundleVersion; v != mdocx.VersionV1 {
+		doc.Media.BundleVersion = mdocx.VersionV1
+		fixes = append(fixes, fmt.Sprintf("media BundleVersion %d set to %d", v, mdocx.VersionV1))
+	}
+
+	paths := make(map[string]bool, len(doc.Markdown.Files))
+	for _, mf := range doc.Markdown.Files {
+		paths[mf.Path] = true
+	}
+	seen := make(map[string]bool, len(doc.Markdown.Files))
+	for i := range doc.Markdown.Files {
+		mf := &doc.Markdown.Files[i]
+		if seen[mf.Path] {
+			ext := path.Ext(mf.Path)
+			renamed := uniqueName(paths, func(n int) string { return fmt.Sprintf("%s_%d%s", strings.TrimSuffix(mf.Path, ext), n, ext) })
+			fixes = append(fixes, fmt.Sprintf("renamed duplicate markdown path %q (file %d) to %q", mf.Path, i+1, renamed))
+			mf.Path = renamed
+		}
+		seen[mf.Path] = true
+	}
+
+	ids := make(map[string]bool, len(doc.Media.Items))
+	for _, mi := range doc.Media.Items {
+		ids[mi.ID] = true
+	}
+	seen = make(map[string]bool, len(doc.Media.Items))
+	for i := range doc.Media.Items {
+		mi := &doc.Media.Items[i]
+		if seen[mi.ID] {
+			renamed := uniqueName(ids, func(n int) string { return fmt.Sprintf("%s_%d", mi.ID, n) })
+			fixes = append(fixes, fmt.Sprintf("renamed duplicate media ID %q (item %d) to %q", mi.ID, i+1, renamed))
+			mi.ID = renamed
+		}
+		seen[mi.ID] = true
+
+		sum := sha256.Sum256(mi.Data)
+		switch mi.SHA256 {
+		case sum:
+		case [32]byte{}:
+			fixes = append(fixes, fmt.Sprintf("added missing SHA-256 for media item %q", mi.ID))
+		default:
+			fixes = append(fixes, fmt.Sprintf("corrected SHA-256 of media item %q", mi.ID))
+		}
+		mi.SHA256 = sum
+	}
+	return fixes
+}
+
+// uniqueName returns the first candidate(n), counting from 2, that isn't in
+// taken, and adds it to taken.
+func uniqueName(taken map[string]bool, candidate func(n int) string) string {
+	for n := 2; ; n++ {
+		if name := candidate(n); !taken[name] {
+			taken[name] = true
+			return name
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(repairCmd)
+
+	repairCmd.Flags().StringP("output", "o", "", "write the repaired bundle here")
+	repairCmd.Flags().Bool("dry-run", false, "list the fixes without writing")
+	addLimitFlags(repairCmd)
+}
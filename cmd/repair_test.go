@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/logicossoftware/go-mdocx"
+)
+
+// writeRawContainer frames doc by hand, without the checks mdocx.Encode
+// makes, so tests can produce defective containers. Sections are
+// uncompressed; patch may alter the bytes before they're written.
+func writeRawContainer(t *testing.T, doc *mdocx.Document, patch func(b []byte)) string {
+	t.Helper()
+	var buf bytes.Buffer
+	hdr := make([]byte, fixedHeaderSize)
+	copy(hdr, "MDOCX\r\n\x1a")
+	binary.LittleEndian.PutUint16(hdr[8:], mdocx.VersionV1)
+	binary.LittleEndian.PutUint32(hdr[12:], fixedHeaderSize)
+	buf.Write(hdr)
+	for _, sec := range []struct {
+		typ mdocx.SectionType
+		v   any
+	}{{mdocx.SectionMarkdown, doc.Markdown}, {mdocx.SectionMedia, doc.Media}} {
+		var payload bytes.Buffer
+		if err := gob.NewEncoder(&payload).Encode(sec.v); err != nil {
+			t.Fatal(err)
+		}
+		sh := make([]byte, sectionHeaderSize)
+		binary.LittleEndian.PutUint16(sh[0:], uint16(sec.typ))
+		binary.LittleEndian.PutUint64(sh[4:], uint64(payload.Len()))
+		buf.Write(sh)
+		buf.Write(payload.Bytes())
+	}
+	b := buf.Bytes()
+	if patch != nil {
+		patch(b)
+	}
+	p := filepath.Join(t.TempDir(), "raw.mdocx")
+	if err := os.WriteFile(p, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func repairTestDoc() *mdocx.Document {
+	png := testPNG(0)
+	return &mdocx.Document{
+		Markdown: mdocx.MarkdownBundle{
+			BundleVersion: 2,
+			Files: []mdocx.MarkdownFile{
+				{Path: "readme.md", Content: []byte("# A\n\n![a](a.png) ![b](b.png)\n")},
+				{Path: "readme.md", Content: []byte("# B\n")},
+			},
+		},
+		Media: mdocx.MediaBundle{
+			BundleVersion: mdocx.VersionV1,
+			Items: []mdocx.MediaItem{
+				{ID: "img", Path: "a.png", MIMEType: "image/png", Data: png, SHA256: sha256.Sum256([]byte("wrong"))},
+				{ID: "img", Path: "b.png", MIMEType: "image/png", Data: png},
+			},
+		},
+	}
+}
+
+func TestRepairDocument(t *testing.T) {
+	doc := repairTestDoc()
+	doc.Media.Items = append(doc.Media.Items, mdocx.MediaItem{ID: "img_2", Data: []byte("x"), SHA256: sha256.Sum256([]byte("x"))})
+	fixes := repairDocument(doc)
+	want := []string{
+		`markdown BundleVersion 2 set to 1`,
+		`renamed duplicate markdown path "readme.md" (file 2) to "readme_2.md"`,
+		`corrected SHA-256 of media item "img"`,
+		`renamed duplicate media ID "img" (item 2) to "img_3"`,
+		`added missing SHA-256 for media item "img_3"`,
+	}
+	if strings.Join(fixes, "\n") != strings.Join(want, "\n") {
+		t.Errorf("fixes:\n%s\nwant:\n%s", strings.Join(fixes, "\n"), strings.Join(want, "\n"))
+	}
+	if fixes := repairDocument(doc); len(fixes) != 0 {
+		t.Errorf("a repaired document should need no fixes, got %v", fixes)
+	}
+}
+
+func TestRepairCommand(t *testing.T) {
+	in := writeRawContainer(t, repairTestDoc(), func(b []byte) {
+		b[25] = 0xFF                                             // reserved header byte
+		binary.LittleEndian.PutUint32(b[fixedHeaderSize+12:], 7) // markdown section reserved field
+	})
+	if _, err := executeCommand(rootCmd, "validate", in); err == nil {
+		t.Fatal("expected the defective container not to validate")
+	}
+
+	out, err := executeCommand(rootCmd, "repair", "--dry-run", in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "would fix: cleared reserved header bytes 20-31") || !strings.Contains(out, "7 fixes needed") {
+		t.Errorf("unexpected dry run output:\n%s", out)
+	}
+	if _, err := executeCommand(rootCmd, "repair", in); err == nil || !strings.Contains(err.Error(), "--output") {
+		t.Errorf("expected --output to be required, got %v", err)
+	}
+
+	repaired := filepath.Join(t.TempDir(), "fixed.mdocx")
+	out, err = executeCommand(rootCmd, "repair", in, "-o", repaired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"fixed: cleared reserved bytes of the markdown section header",
+		`fixed: renamed duplicate media ID "img" (item 2) to "img_2"`,
+		"Applied 7 fixes; wrote " + repaired,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if out, err := executeCommand(rootCmd, "validate", "--disable-rule", "media-id-convention", repaired); err != nil {
+		t.Errorf("repaired container should validate: %v\n%s", err, out)
+	}
+
+	out, err = executeCommand(rootCmd, "repair", repaired, "-o", repaired)
+	if err != nil || !strings.Contains(out, "No defects found") {
+		t.Errorf("expected no fixes on a repaired container: %v\n%s", err, out)
+	}
+}
+
+func TestRepairRejectsNonMDOCX(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "junk.mdocx")
+	if err := os.WriteFile(p, bytes.Repeat([]byte("junk"), 20), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := executeCommand(rootCmd, "repair", p, "-o", p+".out"); err == nil || !strings.Contains(err.Error(), "not an MDOCX file") {
+		t.Errorf("expected a magic error, got %v", err)
+	}
+}
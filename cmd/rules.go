@@ -0,0 +1,282 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// severity ranks a validation finding. Only errors make a container invalid.
+type severity string
+
+const (
+	severityError   severity = "error"
+	severityWarning severity = "warning"
+	severityInfo    severity = "info"
+	severityOff     severity = "off"
+)
+
+func parseSeverity(s string) (severity, error) {
+	switch sev := severity(strings.ToLower(strings.TrimSpace(s))); sev {
+	case severityError, severityWarning, severityInfo, severityOff:
+		return sev, nil
+	}
+	return "", fmt.Errorf("unknown severity %q (expected error|warning|info|off)", s)
+}
+
+// ruleID is a stable validation rule ID such as "MDX001". Code reports
+// findings through the constants below, each of which has an entry in
+// validationRules.
+type ruleID string
+
+const (
+	ruleDuplicateMediaID       ruleID = "MDX001"
+	ruleDuplicateMarkdownPath  ruleID = "MDX002"
+	ruleHeaderUnreadable       ruleID = "MDX003"
+	ruleInvalidMagic           ruleID = "MDX004"
+	ruleUnsupportedVersion     ruleID = "MDX005"
+	ruleInvalidHeaderSize      ruleID = "MDX006"
+	ruleReservedBytesNonzero   ruleID = "MDX007"
+	ruleBundleVersion          ruleID = "MDX008"
+	ruleMissingRootPath        ruleID = "MDX009"
+	ruleBrokenReference        ruleID = "MDX010"
+	ruleBrokenAnchor           ruleID = "MDX011"
+	ruleOrphanedMedia          ruleID = "MDX012"
+	ruleMIMEMismatch           ruleID = "MDX013"
+	ruleImageUndecodable       ruleID = "MDX014"
+	ruleSHA256Mismatch         ruleID = "MDX015"
+	ruleMediaIDConvention      ruleID = "MDX016"
+	rulePathConvention         ruleID = "MDX017"
+	ruleCaseCollision          ruleID = "MDX018"
+	ruleNormalizationCollision ruleID = "MDX019"
+	ruleReservedName           ruleID = "MDX020"
+	ruleIllegalCharacter       ruleID = "MDX021"
+	rulePathTooLong            ruleID = "MDX022"
+	ruleMetadataSchema         ruleID = "MDX023"
+	ruleMultipleH1             ruleID = "MDX024"
+	ruleHeadingIncrement       ruleID = "MDX025"
+	ruleTrailingWhitespace     ruleID = "MDX026"
+	ruleFencedCodeLanguage     ruleID = "MDX027"
+	ruleImageAltText           ruleID = "MDX028"
+	ruleBareURL                ruleID = "MDX029"
+	ruleSecret                 ruleID = "MDX030"
+	ruleHTMLScript             ruleID = "MDX031"
+	ruleEventHandler           ruleID = "MDX032"
+	ruleScriptURL              ruleID = "MDX033"
+	ruleExternalResource       ruleID = "MDX034"
+)
+
+// validationRule is one check validate performs. IDs are stable; names are
+// a readable alias accepted anywhere an ID is.
+type validationRule struct {
+	ID          ruleID
+	Name        string
+	Severity    severity
+	Description string
+}
+
+var validationRules = []validationRule{
+	{ruleDuplicateMediaID, "duplicate-media-id", severityError, "Media IDs must be unique"},
+	{ruleDuplicateMarkdownPath, "duplicate-markdown-path", severityError, "Markdown paths must be unique"},
+	{ruleHeaderUnreadable, "header-unreadable", severityError, "The fixed header could not be read"},
+	{ruleInvalidMagic, "invalid-magic", severityError, "The file does not start with the MDOCX magic bytes"},
+	{ruleUnsupportedVersion, "unsupported-version", severityError, "The header version is not 1"},
+	{ruleInvalidHeaderSize, "invalid-header-size", severityError, "The fixed header size is not 32"},
+	{ruleReservedBytesNonzero, "reserved-bytes-nonzero", severityError, "Reserved header bytes 20-31 are not zero"},
+	{ruleBundleVersion, "bundle-version", severityError, "A markdown or media bundle version is not 1"},
+	{ruleMissingRootPath, "missing-root-path", severityError, "RootPath does not name a markdown file"},
+	{ruleBrokenReference, "broken-reference", severityError, "A link or image target is not in the container"},
+	{ruleBrokenAnchor, "broken-anchor", severityError, "A #fragment matches no heading or HTML anchor in its target"},
+	{ruleOrphanedMedia, "orphaned-media", severityWarning, "A media item is not referenced by any markdown or metadata"},
+	{ruleMIMEMismatch, "mime-mismatch", severityWarning, "A media item's declared MIME type is missing or doesn't match its content"},
+	{ruleImageUndecodable, "image-undecodable", severityWarning, "A PNG, JPEG or GIF media item does not decode"},
+	{ruleSHA256Mismatch, "sha256-mismatch", severityError, "A media item's stored SHA-256 does not match its data"},
+	{ruleMediaIDConvention, "media-id-convention", severityOff, "A media ID is not the one pack derives from its path"},
+	{rulePathConvention, "path-convention", severityWarning, "A container path is not in canonical form"},
+	{ruleCaseCollision, "case-collision", severityWarning, "Paths differ only in case and collide on Windows and macOS"},
+	{ruleNormalizationCollision, "normalization-collision", severityWarning, "Paths differ only in Unicode normalization and collide on macOS"},
+	{ruleReservedName, "reserved-name", severityWarning, "A path uses a Windows reserved name or ends a name in a dot or space"},
+	{ruleIllegalCharacter, "illegal-character", severityWarning, "A path contains a character Windows does not allow in file names"},
+	{rulePathTooLong, "path-too-long", severityWarning, "A name exceeds 255 bytes or a path exceeds 240 characters"},
+	{ruleMetadataSchema, "metadata-schema", severityError, "Metadata does not match the --metadata-schema JSON Schema"},
+	{ruleMultipleH1, "multiple-h1", severityWarning, "A markdown file has more than one level-1 heading"},
+	{ruleHeadingIncrement, "heading-increment", severityWarning, "A heading skips a level, e.g. ## followed by ####"},
+	{ruleTrailingWhitespace, "trailing-whitespace", severityWarning, "A line ends in whitespace other than a hard line break of two or more spaces"},
+	{ruleFencedCodeLanguage, "fenced-code-language", severityWarning, "A fenced code block has no language tag"},
+	{ruleImageAltText, "image-alt-text", severityWarning, "An image has no alt text"},
+	{ruleBareURL, "bare-url", severityWarning, "A URL appears in text without <> or link syntax"},
+	{ruleSecret, "secret", severityError, "Markdown, metadata or text media contains what looks like a secret"},
+	{ruleHTMLScript, "html-script", severityError, "Inline HTML or an SVG media item contains a <script> element"},
+	{ruleEventHandler, "event-handler", severityError, "An HTML or SVG element has an on* event handler attribute"},
+	{ruleScriptURL, "script-url", severityError, "An HTML or SVG attribute holds a javascript: or vbscript: URL"},
+	{ruleExternalResource, "external-resource", severityWarning, "Inline HTML or an SVG loads an image, frame or stylesheet from another host"},
+}
+
+// lookupRule finds a rule by ID or name, case-insensitively.
+func lookupRule(key string) (validationRule, bool) {
+	key = strings.TrimSpace(key)
+	for _, r := range validationRules {
+		if strings.EqualFold(string(r.ID), key) || strings.EqualFold(r.Name, key) {
+			return r, true
+		}
+	}
+	return validationRule{}, false
+}
+
+// ruleSet holds per-rule severity overrides, keyed by rule ID. A nil ruleSet
+// uses every rule's default severity.
+type ruleSet map[ruleID]severity
+
+func (rs ruleSet) severity(r validationRule) severity {
+	if sev, ok := rs[r.ID]; ok {
+		return sev
+	}
+	return r.Severity
+}
+
+// set overrides the severity of the rule named by key (an ID or name).
+func (rs ruleSet) set(key string, value string) error {
+	r, ok := lookupRule(key)
+	if !ok {
+		return fmt.Errorf("unknown rule %q", key)
+	}
+	sev, err := parseSeverity(value)
+	if err != nil {
+		return fmt.Errorf("rule %s: %w", r.ID, err)
+	}
+	rs[r.ID] = sev
+	return nil
+}
+
+// ruleConfigFile is the format of the --rules file:
+//
+//	{"rules": {"MDX012": "off", "broken-anchor": "warning"}}
+type ruleConfigFile struct {
+	Rules map[string]string `json:"rules"`
+}
+
+// loadRuleConfig reads severity overrides from a JSON rule config file into rs.
+func loadRuleConfig(path string, rs ruleSet) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read rules: %w", err)
+	}
+	var cfg ruleConfigFile
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("parse rules %s: %w", path, err)
+	}
+	for key, value := range cfg.Rules {
+		if err := rs.set(key, value); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// addRuleFlags registers the flags that configure validation rules.
+func addRuleFlags(cmd *cobra.Command) {
+	cmd.Flags().String("rules", "", "JSON file of rule severity overrides ({\"rules\": {\"MDX012\": \"off\"}})")
+	cmd.Flags().StringSlice("disable-rule", nil, "turn off a rule by ID or name (repeatable)")
+	cmd.Flags().StringSlice("rule-severity", nil, "override a rule's severity, e.g. orphaned-media=error (repeatable)")
+	cmd.Flags().Bool("list-rules", false, "list validation rules and their severities, then exit")
+}
+
+// rulesFromFlags reads the flags registered by addRuleFlags. Flags are
+// applied after the config file, so they take precedence.
+func rulesFromFlags(cmd *cobra.Command) (ruleSet, error) {
+	rs := ruleSet{}
+	if path, _ := cmd.Flags().GetString("rules"); path != "" {
+		if err := loadRuleConfig(path, rs); err != nil {
+			return nil, err
+		}
+	}
+	disabled, _ := cmd.Flags().GetStringSlice("disable-rule")
+	for _, key := range disabled {
+		if err := rs.set(key, string(severityOff)); err != nil {
+			return nil, fmt.Errorf("--disable-rule: %w", err)
+		}
+	}
+	overrides, _ := cmd.Flags().GetStringSlice("rule-severity")
+	for _, o := range overrides {
+		key, value, ok := strings.Cut(o, "=")
+		if !ok {
+			return nil, fmt.Errorf("--rule-severity: expected RULE=SEVERITY, got %q", o)
+		}
+		if err := rs.set(key, value); err != nil {
+			return nil, fmt.Errorf("--rule-severity: %w", err)
+		}
+	}
+	return rs, nil
+}
+
+// writeRuleList prints rules with their effective severity.
+func writeRuleList(w io.Writer, rs ruleSet, rules []validationRule) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tSEVERITY\tDESCRIPTION")
+	for _, r := range rules {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.ID, r.Name, rs.severity(r), r.Description)
+	}
+	tw.Flush()
+}
+
+// finding is one rule violation. Offset is a byte offset into the entry at
+// Path when Path is set, and into the container file otherwise. Pointer is a
+// JSON pointer into the metadata.
+type finding struct {
+	Rule     ruleID   `json:"rule"`
+	Name     string   `json:"name"`
+	Severity severity `json:"severity"`
+	Message  string   `json:"message"`
+	Path     string   `json:"path,omitempty"`
+	Offset   *int64   `json:"offset,omitempty"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+	Pointer  string   `json:"pointer,omitempty"`
+}
+
+// String renders the finding as one line, e.g.
+// "ERROR: duplicate media ID: "logo" [MDX001 duplicate-media-id]".
+func (f finding) String() string {
+	return fmt.Sprintf("%s: %s [%s %s]", strings.ToUpper(string(f.Severity)), f.Message, f.Rule, f.Name)
+}
+
+// findingReporter collects findings, applying the configured severities.
+type findingReporter struct {
+	rules    ruleSet
+	findings []finding
+}
+
+// report records a finding for the rule with the given ID unless the rule is
+// turned off. f's Rule, Name and Severity are filled in from the rule.
+func (r *findingReporter) report(id ruleID, f finding) {
+	rule, ok := lookupRule(string(id))
+	if !ok {
+		// Every ruleID constant is in validationRules (see rules_test.go);
+		// should one slip through, report it as an error rather than crash.
+		rule = validationRule{ID: id, Severity: severityError}
+	}
+	sev := r.rules.severity(rule)
+	if sev == severityOff {
+		return
+	}
+	f.Rule, f.Name, f.Severity = rule.ID, rule.Name, sev
+	r.findings = append(r.findings, f)
+}
+
+// hasErrors reports whether any error-level finding was recorded.
+func (r *findingReporter) hasErrors() bool {
+	for _, f := range r.findings {
+		if f.Severity == severityError {
+			return true
+		}
+	}
+	return false
+}
+
+// offsetPtr returns a pointer to n, for finding.Offset.
+func offsetPtr(n int64) *int64 { return &n }
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/logicossoftware/go-mdocx"
+)
+
+func TestValidationRuleIDsAreUnique(t *testing.T) {
+	seen := map[string]bool{}
+	for _, r := range validationRules {
+		if seen[string(r.ID)] || seen[r.Name] {
+			t.Errorf("duplicate rule ID or name: %s %s", r.ID, r.Name)
+		}
+		seen[string(r.ID)], seen[r.Name] = true, true
+		if _, err := parseSeverity(string(r.Severity)); err != nil {
+			t.Errorf("rule %s: %v", r.ID, err)
+		}
+	}
+	if r, ok := lookupRule("Duplicate-Media-ID"); !ok || r.ID != "MDX001" {
+		t.Errorf("lookup by name failed: %+v", r)
+	}
+}
+
+// TestReportedRuleIDsAreKnown checks that every ruleID constant names a rule
+// in validationRules and that findings are only reported through those
+// constants, never a bare "MDXnnn" literal.
+func TestReportedRuleIDsAreKnown(t *testing.T) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	constants := 0
+	for _, f := range pkgs["cmd"].Files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.ValueSpec:
+				if typ, ok := n.Type.(*ast.Ident); !ok || typ.Name != "ruleID" {
+					return true
+				}
+				for i, v := range n.Values {
+					lit, ok := v.(*ast.BasicLit)
+					if !ok {
+						t.Errorf("%s: %s is not a literal rule ID", fset.Position(v.Pos()), n.Names[i])
+						continue
+					}
+					constants++
+					if _, ok := lookupRule(strings.Trim(lit.Value, `"`)); !ok {
+						t.Errorf("%s: %s = %s is not in validationRules", fset.Position(v.Pos()), n.Names[i], lit.Value)
+					}
+				}
+			case *ast.CallExpr:
+				// findingReporter.report and the add helpers that feed it.
+				var name string
+				switch fn := n.Fun.(type) {
+				case *ast.SelectorExpr:
+					if fn.Sel.Name == "report" {
+						name = fn.Sel.Name
+					}
+				case *ast.Ident:
+					if fn.Name == "add" {
+						name = fn.Name
+					}
+				}
+				if name != "" && len(n.Args) > 0 {
+					if lit, ok := n.Args[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+						t.Errorf("%s: %s(%s): use a ruleID constant", fset.Position(lit.Pos()), name, lit.Value)
+					}
+				}
+			}
+			return true
+		})
+	}
+	if constants != len(validationRules) {
+		t.Errorf("%d ruleID constants for %d rules", constants, len(validationRules))
+	}
+}
+
+func TestRuleSetOverrides(t *testing.T) {
+	rs := ruleSet{}
+	if err := rs.set("orphaned-media", "error"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.set("MDX010", "nope"); err == nil {
+		t.Error("expected an error for an unknown severity")
+	}
+	if err := rs.set("MDX999", "off"); err == nil {
+		t.Error("expected an error for an unknown rule")
+	}
+
+	result := buildValidationResult(pruneTestDoc(), nil, nil, rs)
+	if result.Valid {
+		t.Error("orphaned media promoted to error should make the container invalid")
+	}
+	if len(result.Findings) != 2 || result.Findings[0].Rule != "MDX012" || result.Findings[0].Severity != severityError {
+		t.Errorf("unexpected findings: %+v", result.Findings)
+	}
+	if len(result.Errors) != 2 || len(result.Warnings) != 0 {
+		t.Errorf("error-level findings should be listed under Errors only: errors %v, warnings %v", result.Errors, result.Warnings)
+	}
+
+	result = buildValidationResult(pruneTestDoc(), nil, nil, ruleSet{"MDX012": severityOff})
+	if !result.Valid || len(result.Findings) != 0 || len(result.Warnings) != 0 {
+		t.Errorf("disabled rule still reported: %+v", result.Findings)
+	}
+}
+
+func TestFindingLocations(t *testing.T) {
+	result := buildValidationResult(refCheckDoc(), &headerInfo{MagicValid: true, Version: 1, FixedHdrSize: 32}, nil, nil)
+	var reserved, anchor *finding
+	for i, f := range result.Findings {
+		switch f.Rule {
+		case "MDX007":
+			reserved = &result.Findings[i]
+		case "MDX011":
+			anchor = &result.Findings[i]
+		}
+	}
+	if reserved == nil || reserved.Offset == nil || *reserved.Offset != 20 || reserved.Path != "" {
+		t.Errorf("expected reserved-bytes finding at file offset 20, got %+v", reserved)
+	}
+	if anchor == nil || anchor.Path != "docs/guide.md" || anchor.Line != 6 || anchor.Column != 47 || anchor.Offset == nil {
+		t.Fatalf("expected broken-anchor finding with a location, got %+v", anchor)
+	}
+	content := string(refCheckDoc().Markdown.Files[1].Content)
+	if !strings.HasPrefix(content[*anchor.Offset:], "../readme.md#nowhere") {
+		t.Errorf("offset %d does not point at the target", *anchor.Offset)
+	}
+}
+
+func TestValidateCommand_RuleConfig(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "refs.mdocx")
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mdocx.Encode(f, refCheckDoc()); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	out, err := executeCommand(rootCmd, "validate", p)
+	if err == nil || !strings.Contains(err.Error(), "3 errors") {
+		t.Fatalf("expected 3 errors, got %v\n%s", err, out)
+	}
+
+	out, err = executeCommand(rootCmd, "validate", "--rule-severity", "MDX010=warning", "--disable-rule", "broken-anchor", p)
+	if err != nil {
+		t.Fatalf("downgraded findings should not fail validation: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "WARNING: docs/guide.md:5:29") || !strings.Contains(out, "[MDX010 broken-reference]") || strings.Contains(out, "MDX011") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	cfg := filepath.Join(dir, "rules.json")
+	if err := os.WriteFile(cfg, []byte(`{"rules": {"broken-reference": "info", "MDX011": "warning"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, err = executeCommand(rootCmd, "validate", "--json", "--rules", cfg, p)
+	if err != nil {
+		t.Fatalf("validate with rules file failed: %v\n%s", err, out)
+	}
+	var result validationResult
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if !result.Valid || len(result.Findings) != 3 || result.Findings[0].Severity != severityInfo || result.Findings[2].Severity != severityWarning {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	if _, err := executeCommand(rootCmd, "validate", "--disable-rule", "no-such-rule", p); err == nil || !strings.Contains(err.Error(), "unknown rule") {
+		t.Errorf("expected an unknown rule error, got %v", err)
+	}
+
+	out, err = executeCommand(rootCmd, "validate", "--list-rules", "--disable-rule", "MDX012")
+	if err != nil {
+		t.Fatalf("--list-rules failed: %v", err)
+	}
+	if !strings.Contains(out, "MDX001") || !strings.Contains(out, "orphaned-media") || !strings.Contains(out, "off") {
+		t.Errorf("unexpected rule list:\n%s", out)
+	}
+}
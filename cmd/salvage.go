@@ -0,0 +1,359 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/logicossoftware/go-mdocx"
+)
+
+// salvageReport describes what salvageContainer recovered from a container
+// and what it had to give up.
+type salvageReport struct {
+	// Header lists defects in the fixed header. Salvage carries on past them.
+	Header []string      `json:"header,omitempty"`
+	Parts  []salvagePart `json:"parts"`
+	// Lost names every part or entry that could not be recovered, and why.
+	Lost []string `json:"lost,omitempty"`
+}
+
+// salvagePart is the outcome for the metadata block or one section.
+type salvagePart struct {
+	Name string `json:"name"` // metadata, markdown or media
+	// Status is "ok", "damaged" (recovered with problems or dropped
+	// entries) or "lost" (nothing recovered).
+	Status   string   `json:"status"`
+	Entries  int      `json:"entries"`
+	Dropped  int      `json:"dropped,omitempty"`
+	Problems []string `json:"problems,omitempty"`
+}
+
+func (p *salvagePart) damage(format string, args ...any) {
+	p.Problems = append(p.Problems, fmt.Sprintf(format, args...))
+	if p.Status == "ok" {
+		p.Status = "damaged"
+	}
+}
+
+// lose marks all of p as unrecoverable.
+func (r *salvageReport) lose(p *salvagePart, format string, args ...any) {
+	why := fmt.Sprintf(format, args...)
+	p.Status = "lost"
+	p.Problems = append(p.Problems, why)
+	what := "entire " + p.Name + " section"
+	if p.Name == "metadata" {
+		what = "all metadata"
+	}
+	r.Lost = append(r.Lost, what+": "+why)
+}
+
+// drop records an entry of p that had to be left out.
+func (r *salvageReport) drop(p *salvagePart, format string, args ...any) {
+	p.Dropped++
+	if p.Status == "ok" {
+		p.Status = "damaged"
+	}
+	r.Lost = append(r.Lost, fmt.Sprintf(format, args...))
+}
+
+// complete reports whether nothing was lost.
+func (r *salvageReport) complete() bool { return len(r.Lost) == 0 }
+
+// salvageContainer recovers what it can of a damaged container. The header,
+// metadata block and each section are read independently, so a corrupt
+// media section doesn't cost the markdown. Entries with unusable paths,
+// duplicate names or (with verifyHashes) bad hashes are dropped. It fails
+// only when the file can't be read at all or the recovered document breaks
+// limits.
+func salvageContainer(input string, verifyHashes bool, limits decodeLimits) (*mdocx.Document, *salvageReport, error) {
+	header, err := readHeaderInfo(input)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read header: %w", err)
+	}
+	f, err := os.Open(input)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open: %w", err)
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, nil, fmt.Errorf("stat: %w", err)
+	}
+	size := stat.Size()
+
+	rep := &salvageReport{}
+	if !header.MagicValid {
+		rep.Header = append(rep.Header, fmt.Sprintf("bad magic %s", header.MagicHex))
+	}
+	if header.Version != mdocx.VersionV1 {
+		rep.Header = append(rep.Header, fmt.Sprintf("unsupported version %d", header.Version))
+	}
+	if header.FixedHdrSize != fixedHeaderSize {
+		rep.Header = append(rep.Header, fmt.Sprintf("fixed header size %d, expected %d", header.FixedHdrSize, fixedHeaderSize))
+	}
+	if !header.ReservedClean {
+		rep.Header = append(rep.Header, "reserved bytes 20-31 are not zero")
+	}
+
+	doc := &mdocx.Document{
+		Markdown: mdocx.MarkdownBundle{BundleVersion: mdocx.VersionV1},
+		Media:    mdocx.MediaBundle{BundleVersion: mdocx.VersionV1},
+	}
+
+	meta := salvagePart{Name: "metadata", Status: "ok"}
+	metaEnd := int64(fixedHeaderSize) + int64(header.MetadataLength)
+	switch {
+	case header.MetadataLength == 0:
+	case metaEnd > size:
+		rep.lose(&meta, "declares %d bytes, only %d present", header.MetadataLength, size-fixedHeaderSize)
+	default:
+		if header.HeaderFlags&mdocx.HeaderFlagMetadataJSON == 0 {
+			meta.damage("METADATA_JSON header flag not set")
+		}
+		mr := io.NewSectionReader(f, fixedHeaderSize, int64(header.MetadataLength))
+		if err := json.NewDecoder(mr).Decode(&doc.Metadata); err != nil {
+			doc.Metadata = nil
+			rep.lose(&meta, "invalid JSON: %v", err)
+		}
+	}
+	meta.Entries = len(doc.Metadata)
+	rep.Parts = append(rep.Parts, meta)
+
+	offset := metaEnd
+	outs := []any{&doc.Markdown, &doc.Media}
+	for i, want := range []mdocx.SectionType{mdocx.SectionMarkdown, mdocx.SectionMedia} {
+		p := salvagePart{Name: sectionTypeName(uint16(want)), Status: "ok"}
+		if want == mdocx.SectionMedia {
+			// A header that doesn't look like one is kept when the scan
+			// finds nothing better, so a flipped type byte still decodes.
+			if found, scanned := locateMediaSection(f, offset, metaEnd+sectionHeaderSize, size); scanned && found >= 0 {
+				p.damage("section header found by scanning at offset %d", found)
+				offset = found
+			}
+		}
+		offset = salvageSection(f, size, offset, want, outs[i], limits, &p, rep)
+		// A failed gob decode may have filled in part of the bundle.
+		switch {
+		case p.Status != "lost":
+		case i == 0:
+			doc.Markdown = mdocx.MarkdownBundle{BundleVersion: mdocx.VersionV1}
+		default:
+			doc.Media = mdocx.MediaBundle{BundleVersion: mdocx.VersionV1}
+		}
+		rep.Parts = append(rep.Parts, p)
+	}
+	salvageEntries(doc, verifyHashes, &rep.Parts[1], &rep.Parts[2], rep)
+
+	if err := limits.checkDocument(doc); err != nil {
+		return nil, nil, err
+	}
+	return doc, rep, nil
+}
+
+// salvageSection decodes the section whose header is at offset into out and
+// returns the offset of the next section, or -1 when it can't be known.
+func salvageSection(f *os.File, size, offset int64, want mdocx.SectionType, out any, limits decodeLimits, p *salvagePart, rep *salvageReport) int64 {
+	if offset < 0 {
+		rep.lose(p, "section header can't be located after the damaged section before it, and scanning found none")
+		return -1
+	}
+	if offset+sectionHeaderSize > size {
+		rep.lose(p, "section header at offset %d is missing; the file ends at %d", offset, size)
+		return -1
+	}
+	sec, err := readSectionInfo(f, offset)
+	if err != nil {
+		rep.lose(p, "%v", err)
+		return -1
+	}
+	next := int64(-1)
+	if sec.PayloadLen <= uint64(size-sec.PayloadOffset) {
+		next = sec.PayloadOffset + int64(sec.PayloadLen)
+	} else {
+		p.damage("truncated: %d of %d payload bytes present", size-sec.PayloadOffset, sec.PayloadLen)
+	}
+	if mdocx.SectionType(sec.Type) != want {
+		p.damage("section type %d, expected %d", sec.Type, want)
+	}
+	if sec.Reserved != 0 {
+		p.damage("reserved section header bytes are not zero")
+	}
+	if want == mdocx.SectionMedia && sec.PayloadLen == 0 {
+		return next
+	}
+	if limits.MaxTotalSize > 0 && sec.UncompressedLen > uint64(limits.MaxTotalSize) {
+		rep.lose(p, "declares %d uncompressed bytes, over --max-total-size %d", sec.UncompressedLen, limits.MaxTotalSize)
+		return next
+	}
+	r, err := sectionPayloadReader(f, sec)
+	if err != nil {
+		rep.lose(p, "decode: %v", err)
+		return next
+	}
+	// Keep whatever decompresses, even if the stream breaks off.
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err == nil {
+		err = gob.NewDecoder(bytes.NewReader(data)).Decode(out)
+	}
+	if err == nil {
+		return next
+	}
+	// gob decodes all or nothing; walk the entries by hand to keep those in
+	// front of the damage.
+	var declared, got int
+	switch out := out.(type) {
+	case *mdocx.MarkdownBundle:
+		declared, _ = salvageMarkdownEntries(data, out)
+		got = len(out.Files)
+	case *mdocx.MediaBundle:
+		declared, _ = salvageMediaEntries(data, out)
+		got = len(out.Items)
+	}
+	if got == 0 {
+		rep.lose(p, "decode: %v", err)
+		return next
+	}
+	p.damage("decode: %v", err)
+	p.Dropped += max(declared-got, 0)
+	rep.Lost = append(rep.Lost, fmt.Sprintf("%s section after entry %d of %d: decode: %v", p.Name, got, declared, err))
+	return next
+}
+
+// locateMediaSection returns offset if a plausible media section header is
+// there. Otherwise, as after a markdown section with a corrupt length, it
+// scans from `from` for a media section header whose payload ends exactly
+// at the end of the file, returning -1 if there is none. scanned reports
+// whether it had to scan.
+func locateMediaSection(f io.ReaderAt, offset, from, size int64) (found int64, scanned bool) {
+	plausible := func(sec sectionInfo) bool {
+		return mdocx.SectionType(sec.Type) == mdocx.SectionMedia && sec.Reserved == 0 &&
+			sec.Flags&^(sectionFlagCompressionMask|sectionFlagHasUncompressedLen) == 0
+	}
+	if offset >= 0 && offset+sectionHeaderSize <= size {
+		if sec, err := readSectionInfo(f, offset); err == nil && plausible(sec) {
+			return offset, false
+		}
+	}
+	const chunk = 64 << 10
+	buf := make([]byte, chunk+sectionHeaderSize-1)
+	for base := max(from, 0); base+sectionHeaderSize <= size; base += chunk {
+		n, err := f.ReadAt(buf[:min(int64(len(buf)), size-base)], base)
+		if err != nil && err != io.EOF {
+			return -1, true
+		}
+		for i := 0; i+sectionHeaderSize <= n; i++ {
+			if binary.LittleEndian.Uint16(buf[i:]) != uint16(mdocx.SectionMedia) {
+				continue
+			}
+			at := base + int64(i)
+			sec := sectionInfo{
+				Type:     binary.LittleEndian.Uint16(buf[i:]),
+				Flags:    binary.LittleEndian.Uint16(buf[i+2:]),
+				Reserved: binary.LittleEndian.Uint32(buf[i+12:]),
+			}
+			if plausible(sec) && binary.LittleEndian.Uint64(buf[i+4:]) == uint64(size-at-sectionHeaderSize) {
+				return at, true
+			}
+		}
+	}
+	return -1, true
+}
+
+// salvageEntries drops the entries of doc that unpacking can't use: invalid
+// or duplicate paths, duplicate media IDs and, with verifyHashes, media whose
+// data doesn't match its SHA-256. Invalid file attributes are cleared.
+func salvageEntries(doc *mdocx.Document, verifyHashes bool, md, media *salvagePart, rep *salvageReport) {
+	seen := make(map[string]bool, len(doc.Markdown.Files))
+	files := doc.Markdown.Files[:0]
+	for _, mf := range doc.Markdown.Files {
+		if _, err := sanitizeContainerPath(mf.Path); err != nil {
+			rep.drop(md, "markdown file %q: %v", mf.Path, err)
+			continue
+		}
+		if seen[mf.Path] {
+			rep.drop(md, "markdown file %q: duplicate path", mf.Path)
+			continue
+		}
+		seen[mf.Path] = true
+		if _, err := parseFileAttributes(mf.Attributes); err != nil {
+			mf.Attributes = nil
+			md.damage("markdown file %q: cleared invalid attributes: %v", mf.Path, err)
+		}
+		files = append(files, mf)
+	}
+	doc.Markdown.Files = files
+	md.Entries = len(files)
+
+	seen = make(map[string]bool, len(doc.Media.Items))
+	items := doc.Media.Items[:0]
+	for _, mi := range doc.Media.Items {
+		p := mi.Path
+		if strings.TrimSpace(p) == "" {
+			p = path.Join("media", mi.ID)
+		}
+		if _, err := sanitizeContainerPath(p); err != nil {
+			rep.drop(media, "media item %q: %v", mi.ID, err)
+			continue
+		}
+		if seen[mi.ID] {
+			rep.drop(media, "media item %q: duplicate ID", mi.ID)
+			continue
+		}
+		if mi.SHA256 != [32]byte{} && mi.SHA256 != sha256.Sum256(mi.Data) {
+			if verifyHashes {
+				rep.drop(media, "media item %q: data does not match its SHA-256", mi.ID)
+				continue
+			}
+			media.damage("media item %q: data does not match its SHA-256", mi.ID)
+		}
+		seen[mi.ID] = true
+		if _, err := parseFileAttributes(mi.Attributes); err != nil {
+			mi.Attributes = nil
+			media.damage("media item %q: cleared invalid attributes: %v", mi.ID, err)
+		}
+		items = append(items, mi)
+	}
+	doc.Media.Items = items
+	media.Entries = len(items)
+}
+
+// writeSalvageReport prints the outcome for each part, then everything lost.
+func writeSalvageReport(w io.Writer, r *salvageReport) {
+	fmt.Fprintln(w, "Salvage:")
+	if len(r.Header) == 0 {
+		fmt.Fprintln(w, "  header: ok")
+	} else {
+		fmt.Fprintf(w, "  header: damaged (%s)\n", strings.Join(r.Header, "; "))
+	}
+	nouns := map[string]string{"metadata": "keys", "markdown": "files", "media": "items"}
+	for _, p := range r.Parts {
+		line := fmt.Sprintf("  %s: %s", p.Name, p.Status)
+		if p.Status != "lost" {
+			line += fmt.Sprintf(", %d %s recovered", p.Entries, nouns[p.Name])
+		}
+		if p.Dropped > 0 {
+			line += fmt.Sprintf(", %d dropped", p.Dropped)
+		}
+		if len(p.Problems) > 0 {
+			line += " (" + strings.Join(p.Problems, "; ") + ")"
+		}
+		fmt.Fprintln(w, line)
+	}
+	if r.complete() {
+		fmt.Fprintln(w, "Nothing was lost")
+		return
+	}
+	fmt.Fprintf(w, "Lost (%d):\n", len(r.Lost))
+	for _, l := range r.Lost {
+		fmt.Fprintf(w, "  %s\n", l)
+	}
+}
@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/logicossoftware/go-mdocx"
+)
+
+func salvageTestDoc() *mdocx.Document {
+	png := testPNG(0)
+	return &mdocx.Document{
+		Markdown: mdocx.MarkdownBundle{
+			BundleVersion: mdocx.VersionV1,
+			Files: []mdocx.MarkdownFile{
+				{Path: "readme.md", Content: []byte("# Readme\n")},
+				{Path: "guide/intro.md", Content: []byte("# Intro\n")},
+			},
+		},
+		Media: mdocx.MediaBundle{
+			BundleVersion: mdocx.VersionV1,
+			Items: []mdocx.MediaItem{
+				{ID: "logo", Path: "logo.png", MIMEType: "image/png", Data: png, SHA256: sha256.Sum256(png)},
+			},
+		},
+	}
+}
+
+// truncateFile cuts n bytes off the end of the file at p.
+func truncateFile(t *testing.T, p string, n int64) {
+	t.Helper()
+	st, err := os.Stat(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(p, st.Size()-n); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSalvageContainer_Intact(t *testing.T) {
+	p := writeRawContainer(t, salvageTestDoc(), nil)
+	doc, rep, err := salvageContainer(p, true, decodeLimits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rep.complete() || len(rep.Header) != 0 {
+		t.Errorf("expected nothing lost, got %+v", rep)
+	}
+	for _, part := range rep.Parts {
+		if part.Status != "ok" {
+			t.Errorf("part %s: status %s", part.Name, part.Status)
+		}
+	}
+	if len(doc.Markdown.Files) != 2 || len(doc.Media.Items) != 1 {
+		t.Errorf("unexpected document: %+v", doc)
+	}
+}
+
+func TestSalvageContainer_TruncatedMedia(t *testing.T) {
+	p := writeRawContainer(t, salvageTestDoc(), nil)
+	truncateFile(t, p, 40)
+	if _, err := decodeContainerFile(p, true, decodeLimits{}); err == nil {
+		t.Fatal("expected a normal decode to fail")
+	}
+
+	doc, rep, err := salvageContainer(p, true, decodeLimits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Markdown.Files) != 2 || len(doc.Media.Items) != 0 {
+		t.Errorf("expected the markdown only, got %d files and %d items", len(doc.Markdown.Files), len(doc.Media.Items))
+	}
+	md, media := rep.Parts[1], rep.Parts[2]
+	if md.Status != "ok" || md.Entries != 2 {
+		t.Errorf("markdown: %+v", md)
+	}
+	if media.Status != "lost" || !strings.Contains(strings.Join(media.Problems, ";"), "truncated") {
+		t.Errorf("media: %+v", media)
+	}
+	if len(rep.Lost) != 1 || !strings.HasPrefix(rep.Lost[0], "entire media section: decode:") {
+		t.Errorf("lost: %v", rep.Lost)
+	}
+}
+
+func TestSalvageContainer_TruncatedMediaKeepsEarlierItems(t *testing.T) {
+	doc := salvageTestDoc()
+	for _, id := range []string{"second", "third"} {
+		png := testPNG(0)
+		doc.Media.Items = append(doc.Media.Items, mdocx.MediaItem{ID: id, Path: id + ".png", MIMEType: "image/png", Data: png, SHA256: sha256.Sum256(png)})
+	}
+	p := writeRawContainer(t, doc, nil)
+	truncateFile(t, p, 40)
+
+	got, rep, err := salvageContainer(p, true, decodeLimits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Media.Items) != 2 || got.Media.Items[1].ID != "second" || got.Media.Items[1].SHA256 != doc.Media.Items[1].SHA256 {
+		t.Fatalf("expected the first two items, got %+v", got.Media.Items)
+	}
+	media := rep.Parts[2]
+	if media.Status != "damaged" || media.Entries != 2 || media.Dropped != 1 {
+		t.Errorf("media: %+v", media)
+	}
+	if len(rep.Lost) != 1 || !strings.HasPrefix(rep.Lost[0], "media section after entry 2 of 3: decode:") {
+		t.Errorf("lost: %v", rep.Lost)
+	}
+}
+
+func TestSalvageContainer_CorruptMarkdownLength(t *testing.T) {
+	// The markdown length runs past the end of the file, so the media
+	// section header has to be found by scanning.
+	p := writeRawContainer(t, salvageTestDoc(), func(b []byte) { binary.LittleEndian.PutUint64(b[36:], 1<<40) })
+
+	got, rep, err := salvageContainer(p, true, decodeLimits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Markdown.Files) != 2 || len(got.Media.Items) != 1 {
+		t.Fatalf("expected everything back, got %d files and %d items", len(got.Markdown.Files), len(got.Media.Items))
+	}
+	if !rep.complete() || rep.Parts[1].Status != "damaged" || !strings.Contains(strings.Join(rep.Parts[2].Problems, ";"), "found by scanning") {
+		t.Errorf("unexpected report: %+v", rep)
+	}
+}
+
+func TestSalvageEntries_RoundTrip(t *testing.T) {
+	md := mdocx.MarkdownBundle{BundleVersion: mdocx.VersionV1, RootPath: "b.md", Files: []mdocx.MarkdownFile{
+		{Path: "a.md", Content: []byte("# A\n"), MediaRefs: []string{"x", "y"}},
+		{Path: "b.md", Attributes: map[string]string{attrModTime: "2026-01-02T03:04:05Z"}},
+	}}
+	media := mdocx.MediaBundle{BundleVersion: mdocx.VersionV1, Items: []mdocx.MediaItem{
+		{ID: "x", Path: "x.bin", MIMEType: "application/octet-stream", Data: make([]byte, 300), SHA256: sha256.Sum256(make([]byte, 300))},
+		{ID: "y", Attributes: map[string]string{attrMode: "0644"}},
+	}}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(md); err != nil {
+		t.Fatal(err)
+	}
+	var gotMD mdocx.MarkdownBundle
+	if n, err := salvageMarkdownEntries(buf.Bytes(), &gotMD); err != nil || n != 2 || !reflect.DeepEqual(gotMD, md) {
+		t.Errorf("markdown: %d, %v\n%+v", n, err, gotMD)
+	}
+	buf.Reset()
+	if err := gob.NewEncoder(&buf).Encode(media); err != nil {
+		t.Fatal(err)
+	}
+	var gotMedia mdocx.MediaBundle
+	if n, err := salvageMediaEntries(buf.Bytes(), &gotMedia); err != nil || n != 2 || !reflect.DeepEqual(gotMedia, media) {
+		t.Errorf("media: %d, %v\n%+v", n, err, gotMedia)
+	}
+}
+
+func TestSalvageContainer_DroppedEntries(t *testing.T) {
+	doc := salvageTestDoc()
+	doc.Markdown.Files = append(doc.Markdown.Files,
+		mdocx.MarkdownFile{Path: "readme.md", Content: []byte("# Again\n")},
+		mdocx.MarkdownFile{Path: "../escape.md", Content: []byte("x")},
+		mdocx.MarkdownFile{Path: "dated.md", Content: []byte("x"), Attributes: map[string]string{attrModTime: "yesterday"}},
+	)
+	doc.Media.Items = append(doc.Media.Items, mdocx.MediaItem{ID: "bad", Data: []byte("data"), SHA256: sha256.Sum256([]byte("other"))})
+	p := writeRawContainer(t, doc, func(b []byte) { b[25] = 1 })
+
+	got, rep, err := salvageContainer(p, true, decodeLimits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`markdown file "readme.md": duplicate path`,
+		`markdown file "../escape.md": invalid container path "../escape.md": path traversal`,
+		`media item "bad": data does not match its SHA-256`,
+	}
+	if strings.Join(rep.Lost, "\n") != strings.Join(want, "\n") {
+		t.Errorf("lost:\n%s\nwant:\n%s", strings.Join(rep.Lost, "\n"), strings.Join(want, "\n"))
+	}
+	if len(rep.Header) != 1 || rep.Parts[1].Status != "damaged" || rep.Parts[1].Dropped != 2 || rep.Parts[1].Entries != 3 {
+		t.Errorf("unexpected report: %+v", rep)
+	}
+	if got.Markdown.Files[2].Attributes != nil {
+		t.Error("expected invalid attributes to be cleared")
+	}
+
+	if _, rep, _ := salvageContainer(p, false, decodeLimits{}); len(rep.Lost) != 2 || rep.Parts[2].Entries != 2 {
+		t.Errorf("without hash verification the item should be kept: %+v", rep)
+	}
+}
+
+func TestUnpackCommand_Salvage(t *testing.T) {
+	p := writeRawContainer(t, salvageTestDoc(), nil)
+	truncateFile(t, p, 40)
+	outDir := filepath.Join(t.TempDir(), "out")
+
+	if _, err := executeCommand(rootCmd, "unpack", p, "-o", outDir); err == nil {
+		t.Fatal("expected unpack without --salvage to fail")
+	}
+	out, err := executeCommand(rootCmd, "unpack", "--salvage", p, "-o", outDir)
+	if err == nil || !strings.Contains(err.Error(), "1 parts or entries could not be recovered") {
+		t.Errorf("expected a salvage error, got %v", err)
+	}
+	for _, want := range []string{"  markdown: ok, 2 files recovered", "  media: lost", "Lost (1):", "wrote "} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if b, err := os.ReadFile(filepath.Join(outDir, "guide", "intro.md")); err != nil || string(b) != "# Intro\n" {
+		t.Errorf("expected the markdown to be extracted: %v", err)
+	}
+}
+
+func TestInspectCommand_Salvage(t *testing.T) {
+	p := writeRawContainer(t, salvageTestDoc(), nil)
+	truncateFile(t, p, 40)
+
+	out, err := executeCommand(rootCmd, "inspect", "--salvage", "--json", p)
+	if err != nil {
+		t.Fatalf("inspect --salvage should not fail on losses: %v", err)
+	}
+	var summary inspectSummary
+	if err := json.NewDecoder(strings.NewReader(out)).Decode(&summary); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if len(summary.MarkdownFiles) != 2 || summary.Salvage == nil || len(summary.Salvage.Lost) != 1 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+
+	out, err = executeCommand(rootCmd, "inspect", "--salvage", p)
+	if err != nil || !strings.Contains(out, "Markdown files (2") || !strings.Contains(out, "entire media section") {
+		t.Errorf("unexpected text output: %v\n%s", err, out)
+	}
+	if _, err := executeCommand(rootCmd, "inspect", "--salvage", "--only", "markdown", p); err == nil {
+		t.Error("expected --salvage with --only to fail")
+	}
+}
@@ -0,0 +1,268 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/logicossoftware/go-mdocx"
+)
+
+// gobEntryReader walks the gob wire format of a markdown or media bundle
+// by hand. encoding/gob decodes a whole message or nothing, so when a
+// section is truncated or corrupt this is how salvage gets back the entries
+// in front of the damage. It relies on the field order of the go-mdocx
+// types, which is what the format specifies.
+type gobEntryReader struct {
+	b []byte
+}
+
+var errGobShort = fmt.Errorf("gob data ends early: %w", io.ErrUnexpectedEOF)
+
+func (g *gobEntryReader) uint() (uint64, error) {
+	if len(g.b) == 0 {
+		return 0, errGobShort
+	}
+	c := g.b[0]
+	g.b = g.b[1:]
+	if c <= 0x7f {
+		return uint64(c), nil
+	}
+	n := -int(int8(c))
+	if n > 8 {
+		return 0, errors.New("gob: invalid unsigned integer")
+	}
+	if len(g.b) < n {
+		return 0, errGobShort
+	}
+	var v uint64
+	for _, c := range g.b[:n] {
+		v = v<<8 | uint64(c)
+	}
+	g.b = g.b[n:]
+	return v, nil
+}
+
+func (g *gobEntryReader) int() (int64, error) {
+	u, err := g.uint()
+	if u&1 != 0 {
+		return ^int64(u >> 1), err
+	}
+	return int64(u >> 1), err
+}
+
+func (g *gobEntryReader) bytes() ([]byte, error) {
+	n, err := g.uint()
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(len(g.b)) {
+		return nil, errGobShort
+	}
+	b := append([]byte(nil), g.b[:n]...)
+	g.b = g.b[n:]
+	return b, nil
+}
+
+func (g *gobEntryReader) string() (string, error) {
+	b, err := g.bytes()
+	return string(b), err
+}
+
+// fields calls field with the number of each field of the struct at the
+// reader until the struct ends.
+func (g *gobEntryReader) fields(field func(n uint64) error) error {
+	n := uint64(math.MaxUint64) // the first delta counts from -1
+	for {
+		delta, err := g.uint()
+		if err != nil {
+			return err
+		}
+		if delta == 0 {
+			return nil
+		}
+		n += delta
+		if err := field(n); err != nil {
+			return err
+		}
+	}
+}
+
+func (g *gobEntryReader) stringSlice() ([]string, error) {
+	n, err := g.uint()
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for ; n > 0; n-- {
+		s, err := g.string()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+func (g *gobEntryReader) stringMap() (map[string]string, error) {
+	n, err := g.uint()
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]string{}
+	for ; n > 0; n-- {
+		k, err := g.string()
+		if err != nil {
+			return nil, err
+		}
+		v, err := g.string()
+		if err != nil {
+			return nil, err
+		}
+		out[k] = v
+	}
+	return out, nil
+}
+
+// valueMessage skips the type definitions at the start of a gob stream and
+// positions the reader at the body of the first value, which may be cut
+// short.
+func (g *gobEntryReader) valueMessage() error {
+	for {
+		n, err := g.uint()
+		if err != nil {
+			return err
+		}
+		body := g.b
+		id, err := g.int()
+		if err != nil {
+			return err
+		}
+		if consumed := uint64(len(body) - len(g.b)); n < consumed {
+			return errors.New("gob: invalid message length")
+		} else if id > 0 {
+			if n < uint64(len(body)) {
+				g.b = body[consumed:n]
+			}
+			return nil
+		}
+		if n > uint64(len(body)) {
+			return errGobShort
+		}
+		g.b = body[n:]
+	}
+}
+
+// entries reads a bundle struct: BundleVersion, the entry slice in field
+// slice, read element by element with entry, and any other field with
+// field. It returns the number of entries the slice declares.
+func (g *gobEntryReader) entries(version *uint16, slice uint64, field func(n uint64) error, entry func() error) (declared int, err error) {
+	if err := g.valueMessage(); err != nil {
+		return 0, err
+	}
+	err = g.fields(func(n uint64) error {
+		switch n {
+		case 0:
+			v, err := g.uint()
+			*version = uint16(v)
+			return err
+		case slice:
+			count, err := g.uint()
+			if err != nil {
+				return err
+			}
+			declared = int(min(count, math.MaxInt32))
+			for ; count > 0; count-- {
+				if err := entry(); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		return field(n)
+	})
+	return declared, err
+}
+
+// salvageMarkdownEntries recovers the markdown files that precede any
+// damage in the gob payload data. It returns how many files the payload
+// declared (0 if it got no further than the count) and the error that
+// stopped it.
+func salvageMarkdownEntries(data []byte, out *mdocx.MarkdownBundle) (int, error) {
+	*out = mdocx.MarkdownBundle{}
+	g := &gobEntryReader{b: data}
+	root := func(n uint64) error {
+		if n != 1 {
+			return fmt.Errorf("gob: unexpected markdown bundle field %d", n)
+		}
+		var err error
+		out.RootPath, err = g.string()
+		return err
+	}
+	return g.entries(&out.BundleVersion, 2, root, func() error {
+		var mf mdocx.MarkdownFile
+		err := g.fields(func(n uint64) error {
+			var err error
+			switch n {
+			case 0:
+				mf.Path, err = g.string()
+			case 1:
+				mf.Content, err = g.bytes()
+			case 2:
+				mf.MediaRefs, err = g.stringSlice()
+			case 3:
+				mf.Attributes, err = g.stringMap()
+			default:
+				err = fmt.Errorf("gob: unexpected markdown file field %d", n)
+			}
+			return err
+		})
+		if err == nil {
+			out.Files = append(out.Files, mf)
+		}
+		return err
+	})
+}
+
+// salvageMediaEntries is salvageMarkdownEntries for the media section.
+func salvageMediaEntries(data []byte, out *mdocx.MediaBundle) (int, error) {
+	*out = mdocx.MediaBundle{}
+	g := &gobEntryReader{b: data}
+	none := func(n uint64) error { return fmt.Errorf("gob: unexpected media bundle field %d", n) }
+	return g.entries(&out.BundleVersion, 1, none, func() error {
+		var mi mdocx.MediaItem
+		err := g.fields(func(n uint64) error {
+			var err error
+			switch n {
+			case 0:
+				mi.ID, err = g.string()
+			case 1:
+				mi.Path, err = g.string()
+			case 2:
+				mi.MIMEType, err = g.string()
+			case 3:
+				mi.Data, err = g.bytes()
+			case 4:
+				var count uint64
+				if count, err = g.uint(); err == nil && count != uint64(len(mi.SHA256)) {
+					err = fmt.Errorf("gob: SHA256 has %d bytes", count)
+				}
+				for i := 0; err == nil && i < len(mi.SHA256); i++ {
+					var c uint64
+					c, err = g.uint()
+					mi.SHA256[i] = byte(c)
+				}
+			case 5:
+				mi.Attributes, err = g.stringMap()
+			default:
+				err = fmt.Errorf("gob: unexpected media item field %d", n)
+			}
+			return err
+		})
+		if err == nil {
+			out.Items = append(out.Items, mi)
+		}
+		return err
+	})
+}
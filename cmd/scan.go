@@ -0,0 +1,397 @@
+/*
+Copyright © 2026 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/logicossoftware/go-mdocx"
+	"github.com/spf13/cobra"
+)
+
+// scanCmd represents the scan command
+var scanCmd = &cobra.Command{
+	Use:   "scan <file>",
+	Short: "Search an .mdocx bundle for secrets and sensitive data",
+	Long: `Search the markdown, metadata and text-like media of an .mdocx bundle for
+API keys, tokens, private keys, credentials in URLs, password assignments
+and internal hostnames. Add patterns of your own with --pattern NAME=REGEX;
+when REGEX has a capture group, only the group is treated as the secret.
+
+An allowlist file holds one regular expression per line, matched against
+each secret found, or path:GLOB to skip a markdown file or media item
+(path:metadata skips the metadata). Lines starting with # are comments.
+
+With --redact, every secret found is replaced by [REDACTED] and the cleaned
+bundle is written to --output. Secrets are never printed in full.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		redact, _ := cmd.Flags().GetBool("redact")
+		outputPath, _ := cmd.Flags().GetString("output")
+		jsonOut, _ := cmd.Flags().GetBool("json")
+		strict, _ := cmd.Flags().GetBool("strict")
+		patterns, _ := cmd.Flags().GetStringArray("pattern")
+		noDefaults, _ := cmd.Flags().GetBool("no-default-patterns")
+		allowlist, _ := cmd.Flags().GetString("allowlist")
+		limits, err := limitsFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+		if outputPath != "" && !redact {
+			return fmt.Errorf("--output requires --redact")
+		}
+		if redact && outputPath == "" {
+			return fmt.Errorf("--redact requires --output")
+		}
+		scanner, err := newSecretScanner(patterns, !noDefaults, allowlist)
+		if err != nil {
+			return err
+		}
+
+		doc, err := decodeContainerFile(args[0], strict, limits)
+		if err != nil {
+			return err
+		}
+		secrets, redacted := scanner.findSecrets(doc)
+		r := &findingReporter{}
+		reportSecrets(secrets, r)
+		result := scanResult{File: args[0], Findings: r.findings}
+		if redact && (len(secrets) > 0 || outputPath != args[0]) {
+			markdownComp, mediaComp := sectionCompressions(args[0])
+			if err := writeContainerFile(outputPath, redacted, markdownComp, mediaComp); err != nil {
+				return err
+			}
+			result.Redacted, result.Output = len(secrets), outputPath
+		}
+
+		out := cmd.OutOrStdout()
+		if jsonOut {
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(result); err != nil {
+				return err
+			}
+		} else {
+			writeScanResult(out, result)
+		}
+		if len(secrets) > 0 && !redact {
+			return fmt.Errorf("scan found %d possible secrets", len(secrets))
+		}
+		return nil
+	},
+}
+
+// scanResult is the JSON output of scan.
+type scanResult struct {
+	File     string    `json:"file"`
+	Findings []finding `json:"findings"`
+	Redacted int       `json:"redacted,omitempty"`
+	Output   string    `json:"output,omitempty"`
+}
+
+func writeScanResult(w io.Writer, result scanResult) {
+	for _, f := range result.Findings {
+		if f.Pointer != "" {
+			fmt.Fprintf(w, "metadata %s: %s\n", f.Pointer, f)
+		} else {
+			fmt.Fprintf(w, "%s:%d:%d: %s\n", f.Path, f.Line, f.Column, f)
+		}
+	}
+	if result.Output != "" {
+		fmt.Fprintf(w, "Redacted %d secrets; wrote %s\n", result.Redacted, result.Output)
+	}
+	if len(result.Findings) == 0 {
+		fmt.Fprintln(w, "No secrets found")
+	}
+}
+
+// defaultSecretPatterns are the secret formats scan looks for unless
+// --no-default-patterns is set. A capture group marks the secret itself.
+var defaultSecretPatterns = []struct{ name, expr string }{
+	{"aws-access-key-id", `\b((?:AKIA|ASIA)[0-9A-Z]{16})\b`},
+	{"aws-secret-access-key", `(?i)aws.{0,20}?secret.{0,20}?[:=]\s*["']?([A-Za-z0-9/+]{40})\b`},
+	{"github-token", `\b((?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{50,}))\b`},
+	{"slack-token", `\b(xox[abposr]-[A-Za-z0-9-]{10,})`},
+	{"stripe-key", `\b((?:sk|rk)_live_[A-Za-z0-9]{20,})\b`},
+	{"google-api-key", `\b(AIza[0-9A-Za-z_\-]{35})`},
+	{"jwt", `\b(eyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,})`},
+	{"private-key", `(?s)-----BEGIN [A-Z ]*PRIVATE KEY-----.*?(?:-----END [A-Z ]*PRIVATE KEY-----|\z)`},
+	{"url-credentials", `\b[a-zA-Z][a-zA-Z0-9+.-]*://[^\s/:@]+:([^\s/@]+)@`},
+	{"password-assignment", `(?i)(?:api[_-]?key|secret|password|passwd|token)["']?\s*[:=]\s*["']?([A-Za-z0-9+/_\-.~!@#$%^&*]{8,})`},
+	{"internal-hostname", `(?i)\b((?:[a-z0-9](?:[a-z0-9-]*[a-z0-9])?\.)+(?:internal|corp|intranet|lan|local|localdomain))\b`},
+}
+
+// secretPattern is one named kind of secret.
+type secretPattern struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// secretScanner finds secrets in text, skipping what the allowlist allows.
+type secretScanner struct {
+	patterns     []secretPattern
+	allow        []*regexp.Regexp
+	allowedPaths []string
+}
+
+// newSecretScanner builds a scanner from NAME=REGEX patterns, the defaults
+// when withDefaults is set, and the allowlist file at allowlistPath, if any.
+func newSecretScanner(patterns []string, withDefaults bool, allowlistPath string) (*secretScanner, error) {
+	s := &secretScanner{}
+	if withDefaults {
+		for _, p := range defaultSecretPatterns {
+			s.patterns = append(s.patterns, secretPattern{p.name, regexp.MustCompile(p.expr)})
+		}
+	}
+	for _, p := range patterns {
+		name, expr, ok := strings.Cut(p, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || expr == "" {
+			return nil, fmt.Errorf("--pattern: expected NAME=REGEX, got %q", p)
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("--pattern %s: %w", name, err)
+		}
+		s.patterns = append(s.patterns, secretPattern{name, re})
+	}
+	if len(s.patterns) == 0 {
+		return nil, fmt.Errorf("no patterns to scan for (--no-default-patterns needs --pattern)")
+	}
+	if allowlistPath != "" {
+		if err := s.loadAllowlist(allowlistPath); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s *secretScanner) loadAllowlist(p string) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return fmt.Errorf("allowlist: %w", err)
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+		case strings.HasPrefix(line, "path:"):
+			glob := strings.TrimSpace(strings.TrimPrefix(line, "path:"))
+			if _, err := path.Match(glob, ""); err != nil {
+				return fmt.Errorf("allowlist %s:%d: %w", p, n, err)
+			}
+			s.allowedPaths = append(s.allowedPaths, glob)
+		default:
+			re, err := regexp.Compile(line)
+			if err != nil {
+				return fmt.Errorf("allowlist %s:%d: %w", p, n, err)
+			}
+			s.allow = append(s.allow, re)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("allowlist: %w", err)
+	}
+	return nil
+}
+
+// pathAllowed reports whether the allowlist skips the entry at p.
+func (s *secretScanner) pathAllowed(p string) bool {
+	return slices.ContainsFunc(s.allowedPaths, func(glob string) bool {
+		ok, _ := path.Match(glob, p)
+		return ok
+	})
+}
+
+// secretMatch is one secret in a piece of text.
+type secretMatch struct {
+	Pattern    string
+	Start, End int
+}
+
+// scanText finds the secrets in text, ordered by offset. Where matches
+// overlap, the first pattern to match wins. Text already redacted doesn't
+// match again.
+func (s *secretScanner) scanText(text []byte) []secretMatch {
+	var matches []secretMatch
+	for _, p := range s.patterns {
+		for _, m := range p.re.FindAllSubmatchIndex(text, -1) {
+			start, end := m[0], m[1]
+			if len(m) >= 4 && m[2] >= 0 {
+				start, end = m[2], m[3]
+			}
+			if start == end || bytes.Contains(text[start:end], []byte(redactedText)) ||
+				slices.ContainsFunc(s.allow, func(re *regexp.Regexp) bool { return re.Match(text[start:end]) }) {
+				continue
+			}
+			if slices.ContainsFunc(matches, func(o secretMatch) bool { return start < o.End && end > o.Start }) {
+				continue
+			}
+			matches = append(matches, secretMatch{p.name, start, end})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Start < matches[j].Start })
+	return matches
+}
+
+// secretFinding is a secret found in a document. Path names the markdown
+// file or media item; metadata secrets have a Pointer instead.
+type secretFinding struct {
+	Pattern       string
+	Path, Pointer string
+	Offset        int
+	Line, Column  int
+	Masked        string
+}
+
+// redactedText replaces each secret.
+const redactedText = "[REDACTED]"
+
+// findSecrets scans the markdown, metadata strings and text-like media of
+// doc. It returns the secrets found, and a copy of doc with each of them
+// replaced by [REDACTED].
+func (s *secretScanner) findSecrets(doc *mdocx.Document) ([]secretFinding, *mdocx.Document) {
+	var found []secretFinding
+	scan := func(p string, text []byte) []byte {
+		if s.pathAllowed(p) {
+			return text
+		}
+		matches := s.scanText(text)
+		if len(matches) == 0 {
+			return text
+		}
+		edits := make([]textEdit, len(matches))
+		for i, m := range matches {
+			line, col := textPosition(text, m.Start)
+			found = append(found, secretFinding{Pattern: m.Pattern, Path: p, Offset: m.Start, Line: line, Column: col, Masked: maskSecret(string(text[m.Start:m.End]))})
+			edits[i] = textEdit{Start: m.Start, End: m.End, Text: redactedText}
+		}
+		return applyTextEdits(text, edits)
+	}
+
+	out := *doc
+	out.Markdown.Files = slices.Clone(doc.Markdown.Files)
+	for i := range out.Markdown.Files {
+		mf := &out.Markdown.Files[i]
+		mf.Content = scan(mf.Path, mf.Content)
+	}
+
+	if doc.Metadata != nil {
+		out.Metadata = redactMetadata(doc.Metadata, "", func(ptr, v string) string {
+			before := len(found)
+			redacted := string(scan("metadata", []byte(v)))
+			for i := before; i < len(found); i++ {
+				found[i].Path, found[i].Pointer, found[i].Line, found[i].Column = "", ptr, 0, 0
+			}
+			return redacted
+		}).(map[string]any)
+	}
+
+	out.Media.Items = slices.Clone(doc.Media.Items)
+	for i := range out.Media.Items {
+		mi := &out.Media.Items[i]
+		if !isTextMedia(*mi) {
+			continue
+		}
+		p := mi.Path
+		if p == "" {
+			p = mi.ID
+		}
+		if data := scan(p, mi.Data); !bytes.Equal(data, mi.Data) {
+			mi.Data, mi.SHA256 = data, sha256.Sum256(data)
+		}
+	}
+	return found, &out
+}
+
+// redactMetadata returns a copy of v with every string replaced by
+// fn(pointer, string), visiting object keys in sorted order.
+func redactMetadata(v any, ptr string, fn func(ptr, s string) string) any {
+	switch v := v.(type) {
+	case string:
+		return fn(ptr, v)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			out[k] = redactMetadata(v[k], ptr+"/"+escapePointer(k), fn)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = redactMetadata(e, fmt.Sprintf("%s/%d", ptr, i), fn)
+		}
+		return out
+	}
+	return v
+}
+
+// isTextMedia reports whether a media item is text worth scanning: a
+// textual MIME type, or no MIME type and content that sniffs as text.
+func isTextMedia(mi mdocx.MediaItem) bool {
+	m := baseMIME(mi.MIMEType)
+	if m == "" || m == "application/octet-stream" {
+		m = baseMIME(http.DetectContentType(mi.Data))
+	}
+	return isTextualMIME(m) && utf8.Valid(mi.Data)
+}
+
+// textPosition returns the 1-based line and column of offset in text.
+func textPosition(text []byte, offset int) (int, int) {
+	start := bytes.LastIndexByte(text[:offset], '\n') + 1
+	return bytes.Count(text[:offset], []byte("\n")) + 1, utf8.RuneCount(text[start:offset]) + 1
+}
+
+// maskSecret keeps the first few characters of a secret and hides the rest.
+func maskSecret(s string) string {
+	r := []rune(s)
+	keep := min(4, len(r)/4)
+	return string(r[:keep]) + strings.Repeat("*", len(r)-keep)
+}
+
+// reportSecrets reports each secret as an MDX030 finding.
+func reportSecrets(secrets []secretFinding, r *findingReporter) {
+	for _, s := range secrets {
+		f := finding{Message: fmt.Sprintf("possible %s %q", s.Pattern, s.Masked), Pointer: s.Pointer}
+		if s.Pointer == "" {
+			f.Path, f.Offset, f.Line, f.Column = s.Path, offsetPtr(int64(s.Offset)), s.Line, s.Column
+		}
+		r.report(ruleSecret, f)
+	}
+}
+
+// checkSecrets reports the secrets in doc, for validate --scan-secrets.
+func checkSecrets(doc *mdocx.Document, s *secretScanner, r *findingReporter) {
+	secrets, _ := s.findSecrets(doc)
+	reportSecrets(secrets, r)
+}
+
+func init() {
+	rootCmd.AddCommand(scanCmd)
+
+	scanCmd.Flags().StringArray("pattern", nil, "extra secret pattern as NAME=REGEX (repeatable)")
+	scanCmd.Flags().Bool("no-default-patterns", false, "scan only for the --pattern patterns")
+	scanCmd.Flags().String("allowlist", "", "file of regular expressions (and path:GLOB lines) for secrets to ignore")
+	scanCmd.Flags().Bool("redact", false, "replace every secret found with "+redactedText+", writing the bundle to --output")
+	scanCmd.Flags().StringP("output", "o", "", "with --redact, write the cleaned bundle here")
+	scanCmd.Flags().Bool("json", false, "output machine-readable JSON")
+	scanCmd.Flags().Bool("strict", true, "fail on any spec violation")
+	addLimitFlags(scanCmd)
+}
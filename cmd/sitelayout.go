@@ -0,0 +1,347 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/logicossoftware/go-mdocx"
+)
+
+// siteGenerator describes where a static site generator expects content.
+type siteGenerator struct {
+	Name       string
+	ContentDir string // markdown files go here
+	AssetDir   string // media items go here
+	// RootURLMedia references media by site-root URL rather than by a path
+	// relative to the page, for generators that serve pages at pretty URLs.
+	RootURLMedia bool
+	// IndexFile, if set, is the content file the root markdown becomes.
+	IndexFile string
+	// FrontMatter adds a title to markdown files that have no front matter.
+	FrontMatter bool
+	config      func(site *siteInfo) []unpackEntry
+}
+
+var siteGenerators = map[string]siteGenerator{
+	"mkdocs": {Name: "mkdocs", ContentDir: "docs", AssetDir: "docs/assets", config: mkdocsConfig},
+	"hugo":   {Name: "hugo", ContentDir: "content", AssetDir: "static", RootURLMedia: true, IndexFile: "_index.md", FrontMatter: true, config: hugoConfig},
+	"mdbook": {Name: "mdbook", ContentDir: "src", AssetDir: "src/assets", config: mdbookConfig},
+}
+
+// sitePage is a markdown file placed for a site generator. Path is relative
+// to the generator's content directory.
+type sitePage struct {
+	Path  string
+	Title string
+	Root  bool
+}
+
+// siteInfo is what config and navigation generation needs to know.
+type siteInfo struct {
+	Title   string
+	Authors []string
+	Pages   []sitePage // root page first, then by path
+	Content map[string]bool
+}
+
+// siteEntries lays out doc for gen: markdown under the content directory,
+// media under the asset directory, references to media and to renamed or
+// moved pages rewritten to their new location, and the generator's config and navigation files generated
+// from RootPath and the metadata title and author.
+func siteEntries(doc *mdocx.Document, gen siteGenerator) ([]unpackEntry, error) {
+	base, err := unpackEntries(doc)
+	if err != nil {
+		return nil, err
+	}
+	ix := newContainerIndex(doc)
+
+	pagePath := make(map[string]string, len(doc.Markdown.Files))
+	for _, mf := range doc.Markdown.Files {
+		pagePath[mf.Path] = mf.Path
+	}
+	if gen.IndexFile != "" && doc.Markdown.RootPath != "" {
+		if _, taken := ix.markdown[gen.IndexFile]; !taken {
+			pagePath[doc.Markdown.RootPath] = gen.IndexFile
+		}
+	}
+
+	site := &siteInfo{Title: metadataString(doc.Metadata, "title"), Authors: metadataAuthors(doc.Metadata), Content: make(map[string]bool)}
+	var entries []unpackEntry
+	var md, media int
+	for _, e := range base {
+		switch e.Kind {
+		case "markdown":
+			mf := doc.Markdown.Files[md]
+			md++
+			rel := pagePath[mf.Path]
+			parsed := parseMarkdown(mf.Content)
+			page := sitePage{Path: rel, Title: pageTitle(parsed, rel), Root: mf.Path == doc.Markdown.RootPath}
+			e.Path = path.Join(gen.ContentDir, rel)
+			e.Data = rewriteSiteRefs(mf.Path, e.Path, mf.Content, parsed, ix, pagePath, gen)
+			if gen.FrontMatter && !hasFrontMatter(e.Data) {
+				e.Data = append([]byte("---\ntitle: "+quoteString(page.Title)+"\n---\n\n"), e.Data...)
+			}
+			if page.Root {
+				site.Pages = append([]sitePage{page}, site.Pages...)
+				if site.Title == "" {
+					site.Title = page.Title
+				}
+			} else {
+				site.Pages = append(site.Pages, page)
+			}
+			site.Content[rel] = true
+		case "media":
+			e.Path = path.Join(gen.AssetDir, ix.mediaPaths[media])
+			media++
+		}
+		entries = append(entries, e)
+	}
+	if site.Title == "" {
+		site.Title = "Documentation"
+	}
+	rest := site.Pages
+	if len(rest) > 0 && rest[0].Root {
+		rest = rest[1:]
+	}
+	sort.SliceStable(rest, func(i, j int) bool { return rest[i].Path < rest[j].Path })
+
+	entries = append(entries, gen.config(site)...)
+	for _, e := range entries {
+		if _, err := sanitizeContainerPath(e.Path); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// rewriteSiteRefs points every reference in content that resolves to a
+// media item at the item's location in the site layout, and every reference
+// to a page that pagePath renames (such as the root page becoming
+// _index.md) at the page's new file. When the page itself lands in another
+// directory, all its references to pages are rewritten.
+func rewriteSiteRefs(fromPath, newPath string, content []byte, parsed *markdownDoc, ix *containerIndex, pagePath map[string]string, gen siteGenerator) []byte {
+	moved := path.Dir(newPath) != path.Dir(path.Join(gen.ContentDir, fromPath))
+	var out bytes.Buffer
+	last := 0
+	for _, link := range parsed.Links {
+		ref, internal := ix.resolve(fromPath, link.Target)
+		if !internal {
+			continue
+		}
+		var target string
+		switch {
+		case ref.Media >= 0 && gen.RootURLMedia:
+			target = "/" + ix.mediaPaths[ref.Media]
+		case ref.Media >= 0:
+			target = relativeSlashPath(path.Dir(newPath), path.Join(gen.AssetDir, ix.mediaPaths[ref.Media]))
+		case ref.Markdown >= 0 && (moved || pagePath[ref.Path] != ref.Path):
+			if p, _ := splitLinkTarget(link.Target); p == "" {
+				continue // a same-page fragment needs no rewrite
+			}
+			target = relativeSlashPath(path.Dir(newPath), path.Join(gen.ContentDir, pagePath[ref.Path]))
+		default:
+			continue
+		}
+		if ref.Fragment != "" {
+			target += "#" + ref.Fragment
+		}
+		out.Write(content[last:link.Start])
+		out.WriteString(target)
+		last = link.End
+	}
+	if last == 0 {
+		return content
+	}
+	out.Write(content[last:])
+	return out.Bytes()
+}
+
+// pageTitle is the first heading of a page, or its file name.
+func pageTitle(parsed *markdownDoc, p string) string {
+	for _, h := range parsed.Headings {
+		if h.Text != "" {
+			return h.Text
+		}
+	}
+	base := strings.TrimSuffix(path.Base(p), path.Ext(p))
+	return strings.ReplaceAll(base, "_", " ")
+}
+
+func hasFrontMatter(content []byte) bool {
+	return bytes.HasPrefix(content, []byte("---\n")) || bytes.HasPrefix(content, []byte("---\r\n")) ||
+		bytes.HasPrefix(content, []byte("+++\n")) || bytes.HasPrefix(content, []byte("+++\r\n"))
+}
+
+// metadataString returns metadata[key] if it is a non-empty string.
+func metadataString(metadata map[string]any, key string) string {
+	s, _ := metadata[key].(string)
+	return strings.TrimSpace(s)
+}
+
+// metadataAuthors collects author names from the "author", "authors" and
+// "creator" metadata keys, each either a string or an array of strings.
+func metadataAuthors(metadata map[string]any) []string {
+	var out []string
+	for _, key := range []string{"author", "authors", "creator"} {
+		switch v := metadata[key].(type) {
+		case string:
+			if s := strings.TrimSpace(v); s != "" {
+				out = append(out, s)
+			}
+		case []any:
+			for _, item := range v {
+				if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+					out = append(out, strings.TrimSpace(s))
+				}
+			}
+		}
+		if len(out) > 0 {
+			return out
+		}
+	}
+	return out
+}
+
+// quoteString renders s as a double-quoted string that is valid in JSON,
+// YAML and TOML.
+func quoteString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// navNode groups pages by directory for nested navigation.
+type navNode struct {
+	Name     string
+	Page     *sitePage
+	Children []*navNode
+}
+
+// buildNavTree nests pages under their directories, keeping page order.
+func buildNavTree(pages []sitePage) []*navNode {
+	var roots []*navNode
+	dirs := make(map[string]*navNode)
+	var dirNode func(dir string) *navNode
+	dirNode = func(dir string) *navNode {
+		if n, ok := dirs[dir]; ok {
+			return n
+		}
+		n := &navNode{Name: path.Base(dir)}
+		dirs[dir] = n
+		if parent := path.Dir(dir); parent != "." {
+			p := dirNode(parent)
+			p.Children = append(p.Children, n)
+		} else {
+			roots = append(roots, n)
+		}
+		return n
+	}
+	for i := range pages {
+		leaf := &navNode{Name: pages[i].Title, Page: &pages[i]}
+		if dir := path.Dir(pages[i].Path); dir != "." && !pages[i].Root {
+			p := dirNode(dir)
+			p.Children = append(p.Children, leaf)
+		} else {
+			roots = append(roots, leaf)
+		}
+	}
+	return roots
+}
+
+func mkdocsConfig(site *siteInfo) []unpackEntry {
+	var b strings.Builder
+	fmt.Fprintf(&b, "site_name: %s\n", quoteString(site.Title))
+	if len(site.Authors) > 0 {
+		fmt.Fprintf(&b, "site_author: %s\n", quoteString(strings.Join(site.Authors, ", ")))
+	}
+	b.WriteString("docs_dir: docs\n")
+	b.WriteString("nav:\n")
+	var write func(nodes []*navNode, indent string)
+	write = func(nodes []*navNode, indent string) {
+		for _, n := range nodes {
+			if n.Page != nil {
+				name := n.Name
+				if n.Page.Root {
+					name = "Home"
+				}
+				fmt.Fprintf(&b, "%s- %s: %s\n", indent, quoteString(name), quoteString(n.Page.Path))
+				continue
+			}
+			fmt.Fprintf(&b, "%s- %s:\n", indent, quoteString(n.Name))
+			write(n.Children, indent+"    ")
+		}
+	}
+	write(buildNavTree(site.Pages), "  ")
+	return []unpackEntry{{Kind: "config", Path: "mkdocs.yml", Data: []byte(b.String())}}
+}
+
+func hugoConfig(site *siteInfo) []unpackEntry {
+	var b strings.Builder
+	b.WriteString("baseURL = \"/\"\n")
+	fmt.Fprintf(&b, "title = %s\n", quoteString(site.Title))
+	if len(site.Authors) > 0 {
+		b.WriteString("\n[params]\n")
+		fmt.Fprintf(&b, "  author = %s\n", quoteString(strings.Join(site.Authors, ", ")))
+	}
+	for i, p := range site.Pages {
+		ref := "/" + strings.TrimSuffix(p.Path, path.Ext(p.Path))
+		if path.Base(p.Path) == "_index.md" {
+			ref = "/" + path.Dir(p.Path)
+			if ref == "/." {
+				ref = "/"
+			}
+		}
+		b.WriteString("\n[[menus.main]]\n")
+		fmt.Fprintf(&b, "  name = %s\n", quoteString(p.Title))
+		fmt.Fprintf(&b, "  pageRef = %s\n", quoteString(ref))
+		fmt.Fprintf(&b, "  weight = %d\n", i+1)
+	}
+	return []unpackEntry{{Kind: "config", Path: "hugo.toml", Data: []byte(b.String())}}
+}
+
+func mdbookConfig(site *siteInfo) []unpackEntry {
+	var b strings.Builder
+	b.WriteString("[book]\n")
+	fmt.Fprintf(&b, "title = %s\n", quoteString(site.Title))
+	if len(site.Authors) > 0 {
+		quoted := make([]string, len(site.Authors))
+		for i, a := range site.Authors {
+			quoted[i] = quoteString(a)
+		}
+		fmt.Fprintf(&b, "authors = [%s]\n", strings.Join(quoted, ", "))
+	}
+	b.WriteString("src = \"src\"\n")
+	entries := []unpackEntry{{Kind: "config", Path: "book.toml", Data: []byte(b.String())}}
+
+	// A SUMMARY.md shipped in the container wins over a generated one.
+	if site.Content["SUMMARY.md"] {
+		return entries
+	}
+	var s strings.Builder
+	s.WriteString("# Summary\n\n")
+	nodes := buildNavTree(site.Pages)
+	if len(nodes) > 0 && nodes[0].Page != nil && nodes[0].Page.Root {
+		fmt.Fprintf(&s, "[%s](%s)\n\n", nodes[0].Name, nodes[0].Page.Path)
+		nodes = nodes[1:]
+	}
+	var write func(nodes []*navNode, indent string)
+	write = func(nodes []*navNode, indent string) {
+		for _, n := range nodes {
+			if n.Page != nil {
+				fmt.Fprintf(&s, "%s- [%s](%s)\n", indent, n.Name, n.Page.Path)
+				continue
+			}
+			fmt.Fprintf(&s, "%s- [%s]()\n", indent, n.Name)
+			write(n.Children, indent+"  ")
+		}
+	}
+	write(nodes, "")
+	entries = append(entries, unpackEntry{Kind: "config", Path: "src/SUMMARY.md", Data: []byte(s.String())})
+	return entries
+}
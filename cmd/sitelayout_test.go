@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/logicossoftware/go-mdocx"
+)
+
+func siteTestDoc() *mdocx.Document {
+	return &mdocx.Document{
+		Metadata: map[string]any{"title": "Handbook", "author": "Ada"},
+		Markdown: mdocx.MarkdownBundle{
+			BundleVersion: mdocx.VersionV1,
+			RootPath:      "readme.md",
+			Files: []mdocx.MarkdownFile{
+				{Path: "readme.md", Content: []byte("# Welcome\n\n![logo](assets/logo.png)\n[Guide](docs/guide.md)\n")},
+				{Path: "docs/guide.md", Content: []byte("# Guide\n\n![logo](../assets/logo.png) ![icon](mdocx://media/icon)\n")},
+			},
+		},
+		Media: mdocx.MediaBundle{
+			BundleVersion: mdocx.VersionV1,
+			Items: []mdocx.MediaItem{
+				{ID: "logo_png", Path: "assets/logo.png", MIMEType: "image/png", Data: []byte{1}},
+				{ID: "icon", MIMEType: "image/png", Data: []byte{2}},
+			},
+		},
+	}
+}
+
+func entriesByPath(t *testing.T, entries []unpackEntry) map[string]string {
+	t.Helper()
+	out := make(map[string]string, len(entries))
+	for _, e := range entries {
+		out[e.Path] = string(e.Data)
+	}
+	return out
+}
+
+func TestSiteEntries_MkDocs(t *testing.T) {
+	entries, err := layoutEntries(siteTestDoc(), "mkdocs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := entriesByPath(t, entries)
+
+	for _, p := range []string{"metadata.json", "mkdocs.yml", "docs/readme.md", "docs/docs/guide.md", "docs/assets/assets/logo.png", "docs/assets/media/icon"} {
+		if _, ok := files[p]; !ok {
+			t.Errorf("missing %s in %v", p, sortedKeys(files))
+		}
+	}
+	if !strings.Contains(files["docs/readme.md"], "![logo](assets/assets/logo.png)") {
+		t.Errorf("root image not rewritten:\n%s", files["docs/readme.md"])
+	}
+	if !strings.Contains(files["docs/readme.md"], "[Guide](docs/guide.md)") {
+		t.Errorf("markdown links should be left alone:\n%s", files["docs/readme.md"])
+	}
+	guide := files["docs/docs/guide.md"]
+	if !strings.Contains(guide, "![logo](../assets/assets/logo.png)") || !strings.Contains(guide, "![icon](../assets/media/icon)") {
+		t.Errorf("guide images not rewritten:\n%s", guide)
+	}
+
+	cfg := files["mkdocs.yml"]
+	for _, want := range []string{`site_name: "Handbook"`, `site_author: "Ada"`, `- "Home": "readme.md"`, `- "docs":`, `- "Guide": "docs/guide.md"`} {
+		if !strings.Contains(cfg, want) {
+			t.Errorf("expected %q in mkdocs.yml:\n%s", want, cfg)
+		}
+	}
+	if strings.Index(cfg, "Home") > strings.Index(cfg, "Guide") {
+		t.Errorf("root page should come first in nav:\n%s", cfg)
+	}
+}
+
+func TestSiteEntries_Hugo(t *testing.T) {
+	doc := siteTestDoc()
+	guide := &doc.Markdown.Files[1]
+	guide.Content = append(guide.Content, "[Back](../readme.md#top) [Self](#guide)\n"...)
+	entries, err := layoutEntries(doc, "hugo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := entriesByPath(t, entries)
+
+	index, ok := files["content/_index.md"]
+	if !ok {
+		t.Fatalf("expected root page as content/_index.md, got %v", sortedKeys(files))
+	}
+	if !strings.HasPrefix(index, "---\ntitle: \"Welcome\"\n---\n") {
+		t.Errorf("expected generated front matter:\n%s", index)
+	}
+	if !strings.Contains(index, "![logo](/assets/logo.png)") {
+		t.Errorf("expected site-root media URL:\n%s", index)
+	}
+	if !strings.Contains(files["content/docs/guide.md"], "[Back](../_index.md#top) [Self](#guide)") {
+		t.Errorf("expected links to the root page to follow its rename:\n%s", files["content/docs/guide.md"])
+	}
+	if _, ok := files["static/assets/logo.png"]; !ok {
+		t.Error("expected media under static/")
+	}
+	cfg := files["hugo.toml"]
+	for _, want := range []string{`title = "Handbook"`, `author = "Ada"`, `pageRef = "/"`, `pageRef = "/docs/guide"`} {
+		if !strings.Contains(cfg, want) {
+			t.Errorf("expected %q in hugo.toml:\n%s", want, cfg)
+		}
+	}
+}
+
+func TestSiteEntries_HugoNestedRoot(t *testing.T) {
+	doc := &mdocx.Document{
+		Markdown: mdocx.MarkdownBundle{
+			BundleVersion: mdocx.VersionV1,
+			RootPath:      "guide/start.md",
+			Files: []mdocx.MarkdownFile{
+				{Path: "guide/start.md", Content: []byte("# Start\n\n[Next](next.md#setup) [Self](#start) [Up](../faq.md)\n")},
+				{Path: "guide/next.md", Content: []byte("# Next\n\n[Back](start.md)\n")},
+				{Path: "faq.md", Content: []byte("# FAQ\n")},
+			},
+		},
+	}
+	entries, err := layoutEntries(doc, "hugo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := entriesByPath(t, entries)
+	if !strings.Contains(files["content/_index.md"], "[Next](guide/next.md#setup) [Self](#start) [Up](faq.md)") {
+		t.Errorf("expected links of the moved root page to follow it:\n%s", files["content/_index.md"])
+	}
+	if !strings.Contains(files["content/guide/next.md"], "[Back](../_index.md)") {
+		t.Errorf("expected links to the root page to follow its rename:\n%s", files["content/guide/next.md"])
+	}
+}
+
+func TestSiteEntries_MdBook(t *testing.T) {
+	entries, err := layoutEntries(siteTestDoc(), "mdbook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := entriesByPath(t, entries)
+
+	summary := files["src/SUMMARY.md"]
+	want := "# Summary\n\n[Welcome](readme.md)\n\n- [docs]()\n  - [Guide](docs/guide.md)\n"
+	if summary != want {
+		t.Errorf("unexpected SUMMARY.md:\n%s", summary)
+	}
+	if !strings.Contains(files["book.toml"], `authors = ["Ada"]`) {
+		t.Errorf("unexpected book.toml:\n%s", files["book.toml"])
+	}
+	if !strings.Contains(files["src/readme.md"], "![logo](assets/assets/logo.png)") {
+		t.Errorf("image not rewritten:\n%s", files["src/readme.md"])
+	}
+}
+
+func TestUnpackCommand_Layout(t *testing.T) {
+	tmp := t.TempDir()
+	mdocxPath := createTestMDOCX(t, filepath.Join(tmp, "test.mdocx"), map[string]any{"title": "T"})
+	outDir := filepath.Join(tmp, "site")
+
+	if _, err := executeCommand(rootCmd, "unpack", "--layout", "mkdocs", "-o", outDir, mdocxPath); err != nil {
+		t.Fatalf("unpack --layout failed: %v", err)
+	}
+	for _, p := range []string{"mkdocs.yml", "docs/readme.md", "docs/docs/guide.md", "docs/assets/assets/logo.png"} {
+		if _, err := os.Stat(filepath.Join(outDir, filepath.FromSlash(p))); err != nil {
+			t.Errorf("expected %s: %v", p, err)
+		}
+	}
+
+	if _, err := executeCommand(rootCmd, "unpack", "--layout", "jekyll", "-o", outDir, mdocxPath); err == nil {
+		t.Error("expected error for unknown layout")
+	}
+}
+
+func TestRelativeSlashPath(t *testing.T) {
+	cases := []struct{ from, to, want string }{
+		{"docs", "docs/assets/a.png", "assets/a.png"},
+		{"docs/sub", "docs/assets/a.png", "../assets/a.png"},
+		{".", "a.png", "a.png"},
+		{"a/b", "c/d.png", "../../c/d.png"},
+	}
+	for _, tc := range cases {
+		if got := relativeSlashPath(tc.from, tc.to); got != tc.want {
+			t.Errorf("relativeSlashPath(%q, %q) = %q, want %q", tc.from, tc.to, got, tc.want)
+		}
+	}
+}
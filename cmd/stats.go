@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+
+	"github.com/logicossoftware/go-mdocx"
+)
+
+// wordsPerMinute is the reading speed used for reading time estimates.
+const wordsPerMinute = 200
+
+// markdownStats are content metrics of one markdown file, or of all of them.
+// Words count prose only: code blocks, code spans and front matter are left
+// out.
+type markdownStats struct {
+	Path           string            `json:"path,omitempty"`
+	Words          int               `json:"words"`
+	ReadingMinutes int               `json:"reading_minutes"`
+	Headings       int               `json:"headings"`
+	HeadingLevels  [6]int            `json:"heading_levels"` // count of h1..h6
+	Links          int               `json:"links"`
+	Images         int               `json:"images"`
+	CodeBlocks     int               `json:"code_blocks"`
+	Tables         int               `json:"tables"`
+	Outline        []markdownHeading `json:"outline,omitempty"`
+}
+
+// contentStats is what inspect --stats reports.
+type contentStats struct {
+	Files []markdownStats `json:"files"`
+	Total markdownStats   `json:"total"`
+}
+
+// buildContentStats computes statistics for every markdown file in doc, in
+// path order, and their totals.
+func buildContentStats(doc *mdocx.Document) *contentStats {
+	files := make([]mdocx.MarkdownFile, len(doc.Markdown.Files))
+	copy(files, doc.Markdown.Files)
+	sort.SliceStable(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+
+	cs := &contentStats{Files: make([]markdownStats, 0, len(files))}
+	for _, mf := range files {
+		s := markdownFileStats(mf.Path, mf.Content)
+		cs.Files = append(cs.Files, s)
+
+		t := &cs.Total
+		t.Words += s.Words
+		t.Headings += s.Headings
+		for i, n := range s.HeadingLevels {
+			t.HeadingLevels[i] += n
+		}
+		t.Links += s.Links
+		t.Images += s.Images
+		t.CodeBlocks += s.CodeBlocks
+		t.Tables += s.Tables
+	}
+	cs.Total.ReadingMinutes = readingMinutes(cs.Total.Words)
+	return cs
+}
+
+func markdownFileStats(p string, content []byte) markdownStats {
+	d := parseMarkdown(content)
+	s := markdownStats{
+		Path:       p,
+		Words:      countWords(string(d.masked)),
+		Headings:   len(d.Headings),
+		CodeBlocks: len(d.Fences),
+		Tables:     countTables(d),
+		Outline:    d.Headings,
+	}
+	s.ReadingMinutes = readingMinutes(s.Words)
+	for _, h := range d.Headings {
+		if h.Level >= 1 && h.Level <= 6 {
+			s.HeadingLevels[h.Level-1]++
+		}
+	}
+	for _, l := range d.Links {
+		switch {
+		case l.Image:
+			s.Images++
+		case l.Kind != "definition":
+			s.Links++
+		}
+	}
+	return s
+}
+
+// countWords counts whitespace-separated tokens that contain a letter or
+// digit, so markdown punctuation like "#", "-" or "|" is not counted.
+func countWords(s string) int {
+	n := 0
+	for _, f := range strings.Fields(s) {
+		if strings.IndexFunc(f, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// readingMinutes rounds up, so any prose takes at least a minute.
+func readingMinutes(words int) int {
+	return (words + wordsPerMinute - 1) / wordsPerMinute
+}
+
+var tableDelimiterRow = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+
+// countTables counts GFM tables: a row containing "|" directly followed by
+// a delimiter row such as "| --- | :-: |". Requiring a pipe in the delimiter
+// row keeps setext underlines from counting. Code blocks are ignored.
+func countTables(d *markdownDoc) int {
+	n := 0
+	lines := d.lineCount()
+	for i := 1; i < lines; i++ {
+		header, delim := d.line(d.masked, i), d.line(d.masked, i+1)
+		if bytes.IndexByte(header, '|') < 0 || bytes.IndexByte(delim, '|') < 0 || !tableDelimiterRow.Match(delim) {
+			continue
+		}
+		n++
+		i++
+	}
+	return n
+}
+
+// writeContentStats prints per-file metrics, the totals, and each file's
+// heading outline.
+func writeContentStats(w io.Writer, cs *contentStats) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "\nFILE\tWORDS\tREADING\tHEADINGS\tLINKS\tIMAGES\tCODE BLOCKS\tTABLES")
+	row := func(name string, s markdownStats) {
+		fmt.Fprintf(tw, "%s\t%d\t%d min\t%d\t%d\t%d\t%d\t%d\n", name, s.Words, s.ReadingMinutes, s.Headings, s.Links, s.Images, s.CodeBlocks, s.Tables)
+	}
+	for _, s := range cs.Files {
+		row(s.Path, s)
+	}
+	row("TOTAL", cs.Total)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, s := range cs.Files {
+		if len(s.Outline) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\nOutline of %s:\n", s.Path)
+		for _, h := range s.Outline {
+			fmt.Fprintf(w, "  %s%s %s\n", strings.Repeat("  ", h.Level-1), strings.Repeat("#", h.Level), h.Text)
+		}
+	}
+	return nil
+}
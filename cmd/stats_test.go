@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/logicossoftware/go-mdocx"
+)
+
+const statsSample = `---
+title: Ignored front matter words
+---
+# Guide
+
+Read the [intro](intro.md) and see ![diagram](d.png).
+
+## Setup
+
+` + "```sh\nmake install now please\n```" + `
+
+| Option | Default |
+| ------ | :-----: |
+| jobs   | 4       |
+
+Setext Heading
+--------------
+
+Visit <https://example.com> or ` + "`inline code here`" + `.
+`
+
+func TestMarkdownFileStats(t *testing.T) {
+	s := markdownFileStats("guide.md", []byte(statsSample))
+
+	if s.Headings != 3 || s.HeadingLevels[0] != 1 || s.HeadingLevels[1] != 2 {
+		t.Errorf("unexpected headings: %d %v", s.Headings, s.HeadingLevels)
+	}
+	if s.Links != 2 || s.Images != 1 {
+		t.Errorf("expected 2 links and 1 image, got %d and %d", s.Links, s.Images)
+	}
+	if s.CodeBlocks != 1 || s.Tables != 1 {
+		t.Errorf("expected 1 code block and 1 table, got %d and %d", s.CodeBlocks, s.Tables)
+	}
+	// Front matter, the code block and the code span don't count.
+	if s.Words != 17 {
+		t.Errorf("expected 17 words, got %d", s.Words)
+	}
+	if s.ReadingMinutes != 1 {
+		t.Errorf("expected 1 minute, got %d", s.ReadingMinutes)
+	}
+	if len(s.Outline) != 3 || s.Outline[2].Text != "Setext Heading" {
+		t.Errorf("unexpected outline: %+v", s.Outline)
+	}
+}
+
+func TestBuildContentStats_Totals(t *testing.T) {
+	doc := &mdocx.Document{Markdown: mdocx.MarkdownBundle{Files: []mdocx.MarkdownFile{
+		{Path: "b.md", Content: []byte(strings.Repeat("word ", 150))},
+		{Path: "a.md", Content: []byte("# T\n\n" + strings.Repeat("word ", 150))},
+	}}}
+	cs := buildContentStats(doc)
+	if len(cs.Files) != 2 || cs.Files[0].Path != "a.md" {
+		t.Fatalf("expected files sorted by path, got %+v", cs.Files)
+	}
+	if cs.Total.Words != 301 || cs.Total.Headings != 1 {
+		t.Errorf("unexpected totals: %+v", cs.Total)
+	}
+	// Reading time is computed from total words, not summed per file.
+	if cs.Files[0].ReadingMinutes != 1 || cs.Total.ReadingMinutes != 2 {
+		t.Errorf("unexpected reading time: file %d, total %d", cs.Files[0].ReadingMinutes, cs.Total.ReadingMinutes)
+	}
+}
+
+func TestCountTables_IgnoresSetextAndCode(t *testing.T) {
+	d := parseMarkdown([]byte("Title | x\n---\n\n```\n| a | b |\n|---|---|\n```\n"))
+	if n := countTables(d); n != 0 {
+		t.Errorf("expected no tables, got %d", n)
+	}
+	d = parseMarkdown([]byte("a | b\n--|--\n1 | 2\n"))
+	if n := countTables(d); n != 1 {
+		t.Errorf("expected one table without outer pipes, got %d", n)
+	}
+}
+
+func TestInspectCommand_Stats(t *testing.T) {
+	tmp := t.TempDir()
+	p := createTestMDOCX(t, filepath.Join(tmp, "test.mdocx"), nil)
+
+	out, err := executeCommand(rootCmd, "inspect", "--stats", p)
+	if err != nil {
+		t.Fatalf("inspect --stats failed: %v", err)
+	}
+	for _, want := range []string{"Markdown files (2", "WORDS", "readme.md", "TOTAL", "Outline of docs/guide.md:", "# Guide"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+
+	out, err = executeCommand(rootCmd, "inspect", "--stats", "--json", p)
+	if err != nil {
+		t.Fatalf("inspect --stats --json failed: %v", err)
+	}
+	var summary inspectSummary
+	if err := json.Unmarshal([]byte(out), &summary); err != nil {
+		t.Fatal(err)
+	}
+	if summary.Stats == nil || len(summary.Stats.Files) != 2 || summary.Stats.Total.Headings != 2 {
+		t.Errorf("unexpected stats: %+v", summary.Stats)
+	}
+	if _, err := executeCommand(rootCmd, "inspect", "--stats", "--format", "csv", p); err == nil {
+		t.Error("expected error for --stats with csv")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -24,92 +25,172 @@ var unpackCmd = &cobra.Command{
 		outDir, _ := cmd.Flags().GetString("output")
 		strict, _ := cmd.Flags().GetBool("strict")
 		force, _ := cmd.Flags().GetBool("force")
-
-		input := args[0]
-		f, err := os.Open(input)
+		manifest, _ := cmd.Flags().GetBool("manifest")
+		preserveAttrs, _ := cmd.Flags().GetBool("preserve-attrs")
+		format, _ := cmd.Flags().GetString("format")
+		layout, _ := cmd.Flags().GetString("layout")
+		salvage, _ := cmd.Flags().GetBool("salvage")
+		limits, err := limitsFromFlags(cmd)
 		if err != nil {
-			return fmt.Errorf("open: %w", err)
+			return err
+		}
+
+		format = strings.ToLower(strings.TrimSpace(format))
+		switch format {
+		case "", "dir":
+		case "tar", "tgz", "zip":
+			if !cmd.Flags().Changed("output") {
+				return fmt.Errorf("--output is required with --format %s (use - for stdout)", format)
+			}
+		default:
+			return fmt.Errorf("unknown format: %s", format)
 		}
-		defer f.Close()
 
-		opts := []mdocx.ReadOption{}
-		if !strict {
-			opts = append(opts, mdocx.WithVerifyHashes(false))
+		var doc *mdocx.Document
+		var report *salvageReport
+		if salvage {
+			doc, report, err = salvageContainer(args[0], strict, limits)
+			if err == nil {
+				writeSalvageReport(cmd.ErrOrStderr(), report)
+			}
+		} else {
+			doc, err = decodeContainerFile(args[0], strict, limits)
 		}
-		doc, err := mdocx.Decode(f, opts...)
 		if err != nil {
-			return fmt.Errorf("decode: %w", err)
+			return err
 		}
 
-		return writeUnpacked(doc, outDir, force, cmd.OutOrStdout())
+		entries, err := layoutEntries(doc, layout)
+		if err != nil {
+			return err
+		}
+		opts := archiveOptions{Force: force, Manifest: manifest, PreserveAttrs: preserveAttrs}
+		if err := writeUnpackOutput(entries, format, outDir, opts, cmd.OutOrStdout()); err != nil {
+			return err
+		}
+		if report != nil && !report.complete() {
+			return fmt.Errorf("salvage: %d parts or entries could not be recovered", len(report.Lost))
+		}
+		return nil
 	},
 }
 
-func writeUnpacked(doc *mdocx.Document, outDir string, force bool, out io.Writer) error {
-	if err := os.MkdirAll(outDir, 0o755); err != nil {
-		return fmt.Errorf("mkdir: %w", err)
+// writeUnpackOutput writes entries to the directory or archive outDir.
+func writeUnpackOutput(entries []unpackEntry, format, outDir string, opts archiveOptions, out io.Writer) error {
+	if opts.PreserveAttrs {
+		if err := resolveAttributes(entries); err != nil {
+			return err
+		}
+	}
+	if format != "" && format != "dir" {
+		return writeArchiveOutput(entries, format, outDir, opts, out)
 	}
 
-	// Helper to check for existing files when --force is not set.
-	checkOverwrite := func(path string) error {
-		if force {
-			return nil
-		}
-		if _, err := os.Stat(path); err == nil {
-			return fmt.Errorf("file already exists: %s (use --force to overwrite)", path)
+	if err := writeEntries(entries, outDir, opts.Force, out); err != nil {
+		return err
+	}
+	if opts.PreserveAttrs {
+		if err := restoreAttributes(entries, outDir); err != nil {
+			return err
 		}
-		return nil
 	}
+	if opts.Manifest {
+		return writeManifest(entries, outDir, opts.Force, out)
+	}
+	return nil
+}
+
+// metadataFileName is the file that holds container metadata after unpacking.
+const metadataFileName = "metadata.json"
+
+// unpackEntry is one file produced by unpacking a container, addressed by a
+// sanitized slash-separated path relative to the output directory.
+// Attributes are the entry's recorded file attributes; ModTime and Mode are
+// filled in from them by resolveAttributes.
+type unpackEntry struct {
+	Kind       string // "metadata", "markdown" or "media"
+	Path       string
+	Data       []byte
+	Attributes map[string]string
+	fileAttrs
+}
 
+// unpackEntries lists the files that unpacking doc produces, in write order.
+// Media items without a path are placed under media/<ID>.
+func unpackEntries(doc *mdocx.Document) ([]unpackEntry, error) {
+	var entries []unpackEntry
 	if doc.Metadata != nil {
 		b, err := json.MarshalIndent(doc.Metadata, "", "  ")
 		if err != nil {
-			return fmt.Errorf("metadata json: %w", err)
+			return nil, fmt.Errorf("metadata json: %w", err)
 		}
-		p := filepath.Join(outDir, "metadata.json")
-		if err := checkOverwrite(p); err != nil {
-			return err
-		}
-		if err := os.WriteFile(p, b, 0o644); err != nil {
-			return fmt.Errorf("write metadata: %w", err)
-		}
-		fmt.Fprintf(out, "wrote %s\n", p)
+		entries = append(entries, unpackEntry{Kind: "metadata", Path: metadataFileName, Data: b})
 	}
 
 	for _, mf := range doc.Markdown.Files {
-		p, err := safeJoinOutput(outDir, mf.Path)
+		p, err := sanitizeContainerPath(mf.Path)
 		if err != nil {
-			return err
-		}
-		if err := checkOverwrite(p); err != nil {
-			return err
-		}
-		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
-			return fmt.Errorf("mkdir: %w", err)
+			return nil, err
 		}
-		if err := os.WriteFile(p, mf.Content, 0o644); err != nil {
-			return fmt.Errorf("write markdown: %w", err)
-		}
-		fmt.Fprintf(out, "wrote %s\n", p)
+		entries = append(entries, unpackEntry{Kind: "markdown", Path: p, Data: mf.Content, Attributes: mf.Attributes})
 	}
 
 	for _, mi := range doc.Media.Items {
 		containerPath := mi.Path
 		if strings.TrimSpace(containerPath) == "" {
-			containerPath = filepath.ToSlash(filepath.Join("media", mi.ID))
+			containerPath = path.Join("media", mi.ID)
 		}
-		p, err := safeJoinOutput(outDir, containerPath)
+		p, err := sanitizeContainerPath(containerPath)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, unpackEntry{Kind: "media", Path: p, Data: mi.Data, Attributes: mi.Attributes})
+	}
+	return entries, nil
+}
+
+// layoutEntries returns the files to unpack for the named site layout, or
+// the container's own layout when layout is empty.
+func layoutEntries(doc *mdocx.Document, layout string) ([]unpackEntry, error) {
+	layout = strings.ToLower(strings.TrimSpace(layout))
+	if layout == "" || layout == "none" {
+		return unpackEntries(doc)
+	}
+	gen, ok := siteGenerators[layout]
+	if !ok {
+		return nil, fmt.Errorf("unknown layout: %s", layout)
+	}
+	return siteEntries(doc, gen)
+}
+
+func writeUnpacked(doc *mdocx.Document, outDir string, force bool, out io.Writer) error {
+	entries, err := unpackEntries(doc)
+	if err != nil {
+		return err
+	}
+	return writeEntries(entries, outDir, force, out)
+}
+
+// writeEntries writes entries beneath outDir, refusing to overwrite existing
+// files unless force is set.
+func writeEntries(entries []unpackEntry, outDir string, force bool, out io.Writer) error {
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return fmt.Errorf("mkdir: %w", err)
+	}
+
+	for _, e := range entries {
+		p, err := safeJoinOutput(outDir, e.Path)
 		if err != nil {
 			return err
 		}
-		if err := checkOverwrite(p); err != nil {
+		if err := checkOverwrite(p, force); err != nil {
 			return err
 		}
 		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
 			return fmt.Errorf("mkdir: %w", err)
 		}
-		if err := os.WriteFile(p, mi.Data, 0o644); err != nil {
-			return fmt.Errorf("write media: %w", err)
+		if err := os.WriteFile(p, e.Data, 0o644); err != nil {
+			return fmt.Errorf("write %s: %w", e.Kind, err)
 		}
 		fmt.Fprintf(out, "wrote %s\n", p)
 	}
@@ -117,10 +198,27 @@ func writeUnpacked(doc *mdocx.Document, outDir string, force bool, out io.Writer
 	return nil
 }
 
+// checkOverwrite refuses to replace an existing file unless force is set.
+func checkOverwrite(path string, force bool) error {
+	if force {
+		return nil
+	}
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("file already exists: %s (use --force to overwrite)", path)
+	}
+	return nil
+}
+
 func init() {
 	rootCmd.AddCommand(unpackCmd)
 
-	unpackCmd.Flags().StringP("output", "o", "out", "output directory")
+	unpackCmd.Flags().StringP("output", "o", "out", "output directory, or archive file (- for stdout) with --format")
+	unpackCmd.Flags().String("format", "dir", "output format (dir|tar|tgz|zip)")
+	unpackCmd.Flags().String("layout", "", "arrange output for a static site generator (mkdocs|hugo|mdbook)")
 	unpackCmd.Flags().Bool("strict", true, "fail on any spec violation")
 	unpackCmd.Flags().BoolP("force", "f", false, "overwrite existing files")
+	unpackCmd.Flags().Bool("manifest", false, "write a "+manifestFileName+" file covering every unpacked file")
+	unpackCmd.Flags().Bool("preserve-attrs", false, "restore recorded file modification times and permissions")
+	unpackCmd.Flags().Bool("salvage", false, "extract whatever can be decoded from a damaged bundle and report what was lost")
+	addLimitFlags(unpackCmd)
 }
@@ -0,0 +1,390 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/logicossoftware/go-mdocx"
+	"github.com/microcosm-cc/bluemonday"
+	"golang.org/x/net/html"
+)
+
+// htmlAttr is one attribute of a tag as written. Start and End bound it,
+// with its leading whitespace, in the enclosing content; Value is unescaped.
+type htmlAttr struct {
+	Name       string
+	Value      string
+	Start, End int
+}
+
+var htmlAttrPattern = regexp.MustCompile(`\s+([^\s"'<>/=]+)(?:\s*=\s*("[^"]*"|'[^']*'|[^\s"'<>]+))?`)
+
+// parseTagAttrs lexes the attributes of the tag raw, which starts at offset
+// start. Names keep their case, unlike the html tokenizer's, so that SVG
+// tags can be rewritten without changing them.
+func parseTagAttrs(raw []byte, start int) []htmlAttr {
+	nameEnd := bytes.IndexAny(raw, " \t\r\n\f/>")
+	if nameEnd < 0 {
+		return nil
+	}
+	var attrs []htmlAttr
+	for _, m := range htmlAttrPattern.FindAllSubmatchIndex(raw[nameEnd:], -1) {
+		a := htmlAttr{Name: string(raw[nameEnd+m[2] : nameEnd+m[3]]), Start: start + nameEnd + m[0], End: start + nameEnd + m[1]}
+		if m[4] >= 0 {
+			a.Value = html.UnescapeString(strings.Trim(string(raw[nameEnd+m[4]:nameEnd+m[5]]), `"'`))
+		}
+		attrs = append(attrs, a)
+	}
+	return attrs
+}
+
+// unsafeIssue is a script, event handler, script URL or remote resource in
+// HTML or SVG.
+type unsafeIssue struct {
+	Rule    ruleID
+	Offset  int
+	Message string
+	// Start and End bound the tag; ElemEnd is the end of its element for
+	// elements whose content the tokenizer reads as raw text, such as
+	// <script> and <style>, and End otherwise. Attr is the offending
+	// attribute, or nil when the whole element is the problem.
+	Start, End, ElemEnd int
+	Attr                *htmlAttr
+}
+
+// resourceAttrs maps elements to the attributes through which they load a
+// resource when the page renders.
+var resourceAttrs = map[string][]string{
+	"img":     {"src", "srcset"},
+	"source":  {"src", "srcset"},
+	"iframe":  {"src"},
+	"frame":   {"src"},
+	"embed":   {"src"},
+	"audio":   {"src"},
+	"video":   {"src", "poster"},
+	"track":   {"src"},
+	"input":   {"src"},
+	"object":  {"data"},
+	"link":    {"href"},
+	"body":    {"background"},
+	"table":   {"background"},
+	"td":      {"background"},
+	"th":      {"background"},
+	"image":   {"href"},
+	"use":     {"href"},
+	"feimage": {"href"},
+}
+
+// localName strips the namespace prefix from an element or attribute name,
+// so that <s:script> in an SVG that binds s to the SVG namespace, or
+// xlink:href, is checked as what it is.
+func localName(name string) string {
+	return name[strings.LastIndexByte(name, ':')+1:]
+}
+
+var cssURLPattern = regexp.MustCompile(`(?i)(?:url\(\s*['"]?|@import\s+['"])\s*([^'")\s]+)`)
+
+// isScriptURL reports whether v is a javascript: or vbscript: URL, ignoring
+// the case, whitespace and control characters browsers ignore.
+func isScriptURL(v string) bool {
+	v = strings.ToLower(strings.Map(func(r rune) rune {
+		if r <= ' ' {
+			return -1
+		}
+		return r
+	}, v))
+	return strings.HasPrefix(v, "javascript:") || strings.HasPrefix(v, "vbscript:")
+}
+
+// isExternalURL reports whether loading v reaches outside the container.
+func isExternalURL(v string) bool {
+	v = strings.TrimSpace(v)
+	if strings.HasPrefix(v, "//") {
+		return true
+	}
+	u, err := url.Parse(v)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "ftp", "file":
+		return true
+	}
+	return false
+}
+
+// externalCSSURL returns the first remote url() or @import in css.
+func externalCSSURL(css string) (string, bool) {
+	for _, m := range cssURLPattern.FindAllStringSubmatch(css, -1) {
+		if isExternalURL(m[1]) {
+			return m[1], true
+		}
+	}
+	return "", false
+}
+
+// findUnsafeHTML finds the unsafe constructs in the HTML tags of content,
+// ordered by offset.
+func findUnsafeHTML(content []byte) []unsafeIssue {
+	var issues []unsafeIssue
+	z := html.NewTokenizer(bytes.NewReader(content))
+	offset := 0
+	next := func() html.TokenType {
+		tt := z.Next()
+		offset += len(z.Raw())
+		return tt
+	}
+	for {
+		start := offset
+		tt := next()
+		if tt == html.ErrorToken {
+			break
+		}
+		if tt == html.CommentToken {
+			issues = append(issues, checkProcessingInstruction(content[start:offset], start)...)
+			continue
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+		name, _ := z.TagName()
+		tag := string(name)
+		local := localName(tag)
+		raw := content[start:offset]
+		end, elemEnd := offset, offset
+		var text []byte
+		// The tokenizer only reads unprefixed elements as raw text; a
+		// prefixed <s:script> or <s:style> is read up to its end tag here.
+		if tt == html.StartTagToken && (rawTextElements[tag] || (tag != local && (local == "script" || local == "style"))) {
+			for t := next(); t != html.ErrorToken; t = next() {
+				if t == html.EndTagToken {
+					if n, _ := z.TagName(); string(n) == tag {
+						break
+					}
+				}
+				text = append(text, z.Raw()...)
+			}
+			elemEnd = offset
+		}
+		add := func(rule ruleID, at int, attr *htmlAttr, format string, args ...any) {
+			issues = append(issues, unsafeIssue{Rule: rule, Offset: at, Message: fmt.Sprintf(format, args...), Start: start, End: end, ElemEnd: elemEnd, Attr: attr})
+		}
+
+		attrs := parseTagAttrs(raw, start)
+		switch local {
+		case "script":
+			if i := slices.IndexFunc(attrs, func(a htmlAttr) bool {
+				n := localName(strings.ToLower(a.Name))
+				return n == "src" || n == "href"
+			}); i >= 0 {
+				add(ruleHTMLScript, start, nil, "<%s> element loading %q", tag, attrs[i].Value)
+			} else {
+				add(ruleHTMLScript, start, nil, "<%s> element", tag)
+			}
+			continue
+		case "style":
+			if u, ok := externalCSSURL(string(text)); ok {
+				add(ruleExternalResource, start, nil, "<%s> element loads external resource %q", tag, u)
+			}
+		}
+		for i := range attrs {
+			a := &attrs[i]
+			n := localName(strings.ToLower(a.Name))
+			at := a.End - len(bytes.TrimLeft(content[a.Start:a.End], " \t\r\n\f"))
+			switch {
+			case len(n) > 2 && strings.HasPrefix(n, "on"):
+				add(ruleEventHandler, at, a, "event handler attribute %s on <%s>", a.Name, tag)
+			case isScriptURL(a.Value) || (n == "values" && slices.ContainsFunc(strings.Split(a.Value, ";"), isScriptURL)):
+				add(ruleScriptURL, at, a, "script URL in %s of <%s>", a.Name, tag)
+			case n == "style":
+				if u, ok := externalCSSURL(a.Value); ok {
+					add(ruleExternalResource, at, a, "style of <%s> loads external resource %q", tag, u)
+				}
+			case slices.Contains(resourceAttrs[local], n):
+				for _, candidate := range strings.Split(a.Value, ",") {
+					if n != "srcset" {
+						candidate = a.Value
+					}
+					if f := strings.Fields(candidate); len(f) > 0 && isExternalURL(f[0]) {
+						add(ruleExternalResource, at, a, "<%s %s> loads external resource %q", tag, a.Name, f[0])
+						break
+					}
+				}
+			}
+		}
+	}
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Offset < issues[j].Offset })
+	return issues
+}
+
+// checkProcessingInstruction reports an <?xml-stylesheet?> processing
+// instruction, which the html tokenizer reads as a comment, that loads a
+// stylesheet from another host or a script URL. The whole instruction is
+// the problem.
+func checkProcessingInstruction(raw []byte, start int) []unsafeIssue {
+	if !bytes.HasPrefix(raw, []byte("<?xml-stylesheet")) {
+		return nil
+	}
+	is := unsafeIssue{Offset: start, Start: start, End: start + len(raw), ElemEnd: start + len(raw)}
+	for _, a := range parseTagAttrs(raw, start) {
+		if a.Name != "href" {
+			continue
+		}
+		switch {
+		case isScriptURL(a.Value):
+			is.Rule, is.Message = ruleScriptURL, "script URL in href of <?xml-stylesheet?>"
+		case isExternalURL(a.Value):
+			is.Rule, is.Message = ruleExternalResource, fmt.Sprintf("<?xml-stylesheet?> loads external resource %q", a.Value)
+		default:
+			continue
+		}
+		return []unsafeIssue{is}
+	}
+	return nil
+}
+
+// rawTextElements are the elements whose content the html tokenizer reads
+// as text up to the matching end tag.
+var rawTextElements = map[string]bool{
+	"iframe": true, "noembed": true, "noframes": true, "noscript": true, "plaintext": true,
+	"script": true, "style": true, "textarea": true, "title": true, "xmp": true,
+}
+
+// isSVGMedia reports whether mi is an SVG image.
+func isSVGMedia(mi mdocx.MediaItem) bool {
+	return baseMIME(mi.MIMEType) == "image/svg+xml" || strings.EqualFold(path.Ext(mi.Path), ".svg")
+}
+
+// checkUnsafeContent reports scripts, event handlers, script URLs and remote
+// resources in the inline HTML of every markdown file and in SVG media.
+func checkUnsafeContent(doc *mdocx.Document, r *findingReporter) {
+	for _, mf := range doc.Markdown.Files {
+		d := parseMarkdown(mf.Content)
+		for _, is := range findUnsafeHTML(d.masked) {
+			line, col := d.position(is.Offset)
+			r.report(is.Rule, finding{Message: is.Message, Path: mf.Path, Offset: offsetPtr(int64(is.Offset)), Line: line, Column: col})
+		}
+	}
+	for _, mi := range doc.Media.Items {
+		if !isSVGMedia(mi) {
+			continue
+		}
+		p := mi.Path
+		if p == "" {
+			p = path.Join("media", mi.ID)
+		}
+		for _, is := range findUnsafeHTML(mi.Data) {
+			line, col := textPosition(mi.Data, is.Offset)
+			r.report(is.Rule, finding{Message: "SVG " + is.Message, Path: p, Offset: offsetPtr(int64(is.Offset)), Line: line, Column: col})
+		}
+	}
+}
+
+// localURLPattern matches relative, mdocx: and data:image/ URLs, which
+// load nothing from outside the container.
+var localURLPattern = regexp.MustCompile(`^(?:mdocx:|data:image/|/[^/]|[^:/?#]+(?:[/?#]|$)|[?#])`)
+
+// markdownHTMLPolicy is the sanitizer pack --sanitize runs over unsafe
+// inline HTML in markdown. It keeps common formatting elements, links and
+// local media, and drops scripts, event handlers, script URLs and remote
+// resources.
+var markdownHTMLPolicy = func() *bluemonday.Policy {
+	elements := []string{
+		"a", "abbr", "audio", "b", "blockquote", "br", "caption", "center", "code", "dd", "del", "details", "div",
+		"dl", "dt", "em", "figcaption", "figure", "h1", "h2", "h3", "h4", "h5", "h6", "hr", "i", "img", "ins", "kbd",
+		"li", "mark", "ol", "p", "picture", "pre", "q", "s", "samp", "small", "source", "span", "strong", "sub",
+		"summary", "sup", "table", "tbody", "td", "tfoot", "th", "thead", "tr", "track", "u", "ul", "var", "video",
+	}
+	p := bluemonday.NewPolicy()
+	p.AllowElements(elements...)
+	p.AllowNoAttrs().OnElements(elements...)
+	p.AllowStandardAttributes()
+	p.AllowAttrs("align", "class", "width", "height").Globally()
+	p.AllowAttrs("href", "name").OnElements("a")
+	p.AllowAttrs("alt").OnElements("img")
+	p.AllowAttrs("open").OnElements("details")
+	p.AllowAttrs("controls").OnElements("audio", "video")
+	p.AllowAttrs("colspan", "rowspan").OnElements("td", "th")
+	p.AllowAttrs("src").Matching(localURLPattern).OnElements("img", "source", "audio", "video", "track")
+	p.AllowURLSchemes("http", "https", "mailto", "mdocx")
+	p.AllowRelativeURLs(true)
+	p.RequireParseableURLs(true)
+	return p
+}()
+
+// sanitizeMarkdownHTML runs each tag or raw-text element of content that
+// has an unsafe construct through markdownHTMLPolicy, leaving the markdown
+// around it untouched. It returns the new content and the number of issues
+// removed.
+func sanitizeMarkdownHTML(content []byte) ([]byte, int) {
+	issues := findUnsafeHTML(parseMarkdown(content).masked)
+	var edits []textEdit
+	for _, is := range issues {
+		if len(edits) > 0 && edits[len(edits)-1].Start == is.Start {
+			continue
+		}
+		edits = append(edits, textEdit{Start: is.Start, End: is.ElemEnd, Text: markdownHTMLPolicy.Sanitize(string(content[is.Start:is.ElemEnd]))})
+	}
+	sort.Slice(edits, func(i, j int) bool { return edits[i].Start < edits[j].Start })
+	return applyTextEdits(content, edits), len(issues)
+}
+
+// sanitizeSVG removes unsafe elements and attributes from an SVG in place.
+// An HTML sanitizer would lowercase SVG's case-sensitive names (viewBox,
+// linearGradient), so the markup around them is kept byte for byte.
+func sanitizeSVG(data []byte) ([]byte, int) {
+	issues := findUnsafeHTML(data)
+	byTag := make(map[int][]unsafeIssue)
+	for _, is := range issues {
+		byTag[is.Start] = append(byTag[is.Start], is)
+	}
+	var edits []textEdit
+	for start, tagIssues := range byTag {
+		is := tagIssues[0]
+		if slices.ContainsFunc(tagIssues, func(is unsafeIssue) bool { return is.Attr == nil }) {
+			edits = append(edits, textEdit{Start: start, End: is.ElemEnd})
+			continue
+		}
+		var attrEdits []textEdit
+		for _, is := range tagIssues {
+			attrEdits = append(attrEdits, textEdit{Start: is.Attr.Start - start, End: is.Attr.End - start})
+		}
+		sort.Slice(attrEdits, func(i, j int) bool { return attrEdits[i].Start < attrEdits[j].Start })
+		attrEdits = slices.CompactFunc(attrEdits, func(a, b textEdit) bool { return a.Start == b.Start })
+		edits = append(edits, textEdit{Start: start, End: is.End, Text: string(applyTextEdits(data[start:is.End], attrEdits))})
+	}
+	sort.Slice(edits, func(i, j int) bool { return edits[i].Start < edits[j].Start })
+	return applyTextEdits(data, edits), len(issues)
+}
+
+// sanitizeDocument strips unsafe content from the inline HTML of doc's
+// markdown and from its SVG media, in place, updating media hashes. It
+// describes each entry it changed.
+func sanitizeDocument(doc *mdocx.Document) []string {
+	var changed []string
+	for i := range doc.Markdown.Files {
+		mf := &doc.Markdown.Files[i]
+		if content, n := sanitizeMarkdownHTML(mf.Content); n > 0 {
+			mf.Content = content
+			changed = append(changed, fmt.Sprintf("%s: removed %d unsafe constructs", mf.Path, n))
+		}
+	}
+	for i := range doc.Media.Items {
+		mi := &doc.Media.Items[i]
+		if !isSVGMedia(*mi) {
+			continue
+		}
+		if data, n := sanitizeSVG(mi.Data); n > 0 {
+			mi.Data, mi.SHA256 = data, sha256.Sum256(data)
+			changed = append(changed, fmt.Sprintf("media item %q: removed %d unsafe constructs", mi.ID, n))
+		}
+	}
+	return changed
+}
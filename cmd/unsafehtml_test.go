@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/logicossoftware/go-mdocx"
+)
+
+const unsafeTestMarkdown = "# Page\n" +
+	"\n" +
+	"<div onclick=\"go()\" class=\"box\">Hi</div>\n" +
+	"\n" +
+	"<a href=\"javascript:alert(1)\">x</a> and <a href=\"https://example.com\">ok</a>\n" +
+	"\n" +
+	"<img src=\"https://cdn.example.com/a.png\" alt=\"A\"> <img src=\"local.png\" alt=\"L\">\n" +
+	"\n" +
+	"<script>alert('x')</script>\n" +
+	"\n" +
+	"```html\n" +
+	"<script>ok()</script>\n" +
+	"```\n" +
+	"\n" +
+	"<p style=\"background: url(//evil.example/x.png)\">s</p>\n" +
+	"<iframe src=\"https://evil.example\">fallback</iframe>\n"
+
+const unsafeTestSVG = `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" viewBox="0 0 10 10" onload="boom()">` +
+	`<linearGradient id="g"/><script type="text/javascript">alert(1)</script>` +
+	`<image xlink:href="https://evil.example/t.png" width="1"/>` +
+	`<a xlink:href="javascript:x()"><rect width="1" height="1"/></a>` +
+	`<style>@import url("https://evil.example/a.css");</style></svg>`
+
+func unsafeTestDoc() *mdocx.Document {
+	return &mdocx.Document{
+		Markdown: mdocx.MarkdownBundle{
+			BundleVersion: mdocx.VersionV1,
+			Files:         []mdocx.MarkdownFile{{Path: "page.md", Content: []byte(unsafeTestMarkdown)}},
+		},
+		Media: mdocx.MediaBundle{
+			BundleVersion: mdocx.VersionV1,
+			Items: []mdocx.MediaItem{
+				{ID: "icon", Path: "icon.svg", MIMEType: "image/svg+xml", Data: []byte(unsafeTestSVG)},
+				{ID: "logo", Path: "logo.png", MIMEType: "image/png", Data: testPNG(0)},
+			},
+		},
+	}
+}
+
+func TestCheckUnsafeContent(t *testing.T) {
+	r := &findingReporter{}
+	checkUnsafeContent(unsafeTestDoc(), r)
+	var got []string
+	for _, f := range r.findings {
+		got = append(got, fmt.Sprintf("%s:%d:%d %s %s", f.Path, f.Line, f.Column, f.Rule, f.Message))
+	}
+	want := []string{
+		`page.md:3:6 MDX032 event handler attribute onclick on <div>`,
+		`page.md:5:4 MDX033 script URL in href of <a>`,
+		`page.md:7:6 MDX034 <img src> loads external resource "https://cdn.example.com/a.png"`,
+		`page.md:9:1 MDX031 <script> element`,
+		`page.md:15:4 MDX034 style of <p> loads external resource "//evil.example/x.png"`,
+		`page.md:16:9 MDX034 <iframe src> loads external resource "https://evil.example"`,
+		`icon.svg:1:104 MDX032 SVG event handler attribute onload on <svg>`,
+		`icon.svg:1:144 MDX031 SVG <script> element`,
+		`icon.svg:1:199 MDX034 SVG <image xlink:href> loads external resource "https://evil.example/t.png"`,
+		`icon.svg:1:253 MDX033 SVG script URL in xlink:href of <a>`,
+		`icon.svg:1:313 MDX034 SVG <style> element loads external resource "https://evil.example/a.css"`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("findings:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestSanitizeMarkdownHTML(t *testing.T) {
+	out, n := sanitizeMarkdownHTML([]byte(unsafeTestMarkdown))
+	if n != 6 {
+		t.Errorf("expected 6 removals, got %d", n)
+	}
+	for _, want := range []string{
+		"# Page\n",
+		`<div class="box">Hi</div>`,
+		`<a>x</a> and <a href="https://example.com">ok</a>`,
+		`<img alt="A"> <img src="local.png" alt="L">`,
+		"```html\n<script>ok()</script>\n```\n",
+		"<p>s</p>",
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("sanitized markdown missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(string(out), "alert('x')") || strings.Contains(string(out), "iframe") {
+		t.Errorf("unsafe elements left in:\n%s", out)
+	}
+	if _, n := sanitizeMarkdownHTML([]byte("# Safe <b>bold</b>\n")); n != 0 {
+		t.Errorf("safe markdown should be left alone, %d removals", n)
+	}
+}
+
+func TestSanitizeSVG(t *testing.T) {
+	out, n := sanitizeSVG([]byte(unsafeTestSVG))
+	want := `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" viewBox="0 0 10 10">` +
+		`<linearGradient id="g"/><image width="1"/><a><rect width="1" height="1"/></a></svg>`
+	if n != 5 || string(out) != want {
+		t.Errorf("got %d removals:\n%s\nwant:\n%s", n, out, want)
+	}
+
+	doc := unsafeTestDoc()
+	changes := sanitizeDocument(doc)
+	if len(changes) != 2 || changes[0] != "page.md: removed 6 unsafe constructs" || changes[1] != `media item "icon": removed 5 unsafe constructs` {
+		t.Errorf("unexpected changes: %v", changes)
+	}
+	r := &findingReporter{}
+	checkUnsafeContent(doc, r)
+	if len(r.findings) != 0 {
+		t.Errorf("sanitized document still has findings: %+v", r.findings)
+	}
+}
+
+func TestUnsafeHTML_PrefixedSVG(t *testing.T) {
+	svg := `<?xml version="1.0"?>` +
+		`<?xml-stylesheet type="text/css" href="https://evil.example/s.css"?>` +
+		`<s:svg xmlns:s="http://www.w3.org/2000/svg" xmlns:x="http://www.w3.org/1999/xlink" s:onload="boom()">` +
+		`<s:script>if (a < b) alert(1)</s:script>` +
+		`<s:image x:href="https://evil.example/t.png"/>` +
+		`<s:style>@import url("https://evil.example/a.css");</s:style>` +
+		`<s:rect width="1"/></s:svg>`
+	var got []string
+	for _, is := range findUnsafeHTML([]byte(svg)) {
+		got = append(got, fmt.Sprintf("%d %s %s", is.Offset, is.Rule, is.Message))
+	}
+	want := []string{
+		`21 MDX034 <?xml-stylesheet?> loads external resource "https://evil.example/s.css"`,
+		`172 MDX032 event handler attribute s:onload on <s:svg>`,
+		`190 MDX031 <s:script> element`,
+		`239 MDX034 <s:image x:href> loads external resource "https://evil.example/t.png"`,
+		`276 MDX034 <s:style> element loads external resource "https://evil.example/a.css"`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("findings:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	out, n := sanitizeSVG([]byte(svg))
+	wantOut := `<?xml version="1.0"?>` +
+		`<s:svg xmlns:s="http://www.w3.org/2000/svg" xmlns:x="http://www.w3.org/1999/xlink">` +
+		`<s:image/><s:rect width="1"/></s:svg>`
+	if n != 5 || string(out) != wantOut {
+		t.Errorf("got %d removals:\n%s\nwant:\n%s", n, out, wantOut)
+	}
+	if _, n := sanitizeSVG([]byte(`<?xml-stylesheet type="text/css" href="local.css"?><svg/>`)); n != 0 {
+		t.Errorf("local stylesheet should be left alone, %d removals", n)
+	}
+}
+
+func TestPackCommand_Sanitize(t *testing.T) {
+	tmp := t.TempDir()
+	mdFile := filepath.Join(tmp, "page.md")
+	if err := os.WriteFile(mdFile, []byte(unsafeTestMarkdown), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mediaDir := filepath.Join(tmp, "media")
+	if err := os.MkdirAll(mediaDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(mediaDir, "icon.svg"), []byte(unsafeTestSVG), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	plain := filepath.Join(tmp, "plain.mdocx")
+	if out, err := executeCommand(rootCmd, "pack", "--media-dir", mediaDir, "-o", plain, mdFile); err != nil {
+		t.Fatalf("pack failed: %v\n%s", err, out)
+	}
+	out, err := executeCommand(rootCmd, "validate", plain)
+	if err == nil {
+		t.Error("expected validate to fail on unsafe content")
+	}
+	for _, want := range []string{"[MDX031 html-script]", "[MDX032 event-handler]", "[MDX033 script-url]", "[MDX034 external-resource]"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("validate output missing %q:\n%s", want, out)
+		}
+	}
+
+	clean := filepath.Join(tmp, "clean.mdocx")
+	out, err = executeCommand(rootCmd, "pack", "--sanitize", "--media-dir", mediaDir, "-o", clean, mdFile)
+	if err != nil {
+		t.Fatalf("pack --sanitize failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "sanitized page.md: removed 6 unsafe constructs") {
+		t.Errorf("expected sanitize report, got:\n%s", out)
+	}
+	if out, err := executeCommand(rootCmd, "validate", "--disable-rule", "broken-reference,orphaned-media", clean); err != nil || strings.Contains(out, "MDX03") {
+		t.Errorf("sanitized bundle should validate cleanly: %v\n%s", err, out)
+	}
+}
@@ -6,7 +6,10 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
-	"os"
+	"io"
+	"maps"
+	"slices"
+	"strings"
 
 	"github.com/logicossoftware/go-mdocx"
 	"github.com/spf13/cobra"
@@ -14,49 +17,76 @@ import (
 
 // validateCmd represents the validate command
 var validateCmd = &cobra.Command{
-	Use:   "validate <file>",
+	Use:   "validate <file>...",
 	Short: "Validate an .mdocx bundle",
-	Args:  cobra.ExactArgs(1),
+	Args: func(cmd *cobra.Command, args []string) error {
+		if listRules, _ := cmd.Flags().GetBool("list-rules"); listRules {
+			return nil
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		jsonOut, _ := cmd.Flags().GetBool("json")
-		strict, _ := cmd.Flags().GetBool("strict")
-		input := args[0]
-
-		// Validate header first.
-		header, headerErr := readHeaderInfo(input)
-
-		f, err := os.Open(input)
+		recursive, _ := cmd.Flags().GetBool("recursive")
+		jobs, _ := cmd.Flags().GetInt("jobs")
+		o, err := validateOptionsFromFlags(cmd)
 		if err != nil {
-			return fmt.Errorf("open: %w", err)
+			return err
 		}
-		defer f.Close()
-
-		opts := []mdocx.ReadOption{}
-		if !strict {
-			opts = append(opts, mdocx.WithVerifyHashes(false))
+		jsonOut := o.format == "json"
+		if listRules, _ := cmd.Flags().GetBool("list-rules"); listRules {
+			writeRuleList(cmd.OutOrStdout(), o.rules, validationRules)
+			return nil
 		}
-		doc, err := mdocx.Decode(f, opts...)
+		inputs, batch, err := expandInputs(args, recursive)
+		if err != nil {
+			return err
+		}
+		// SARIF and JUnit reports have the same shape for one file or many.
+		if batch || o.format == "sarif" || o.format == "junit" {
+			results := runBatch(inputs, jobs, func(input string) validationResult {
+				result, err := validateFile(input, o)
+				result.File = input
+				if err != nil {
+					result = validationResult{File: input, Valid: false, Error: err.Error()}
+				}
+				return result
+			})
+			switch o.format {
+			case "sarif":
+				return writeValidationReport(cmd.OutOrStdout(), results, o.rules, writeSARIF)
+			case "junit":
+				return writeValidationReport(cmd.OutOrStdout(), results, o.rules, writeJUnit)
+			}
+			return writeValidationBatch(cmd.OutOrStdout(), results, jsonOut)
+		}
+
+		result, err := validateFile(inputs[0], o)
 		if err != nil {
 			if jsonOut {
 				enc := json.NewEncoder(cmd.OutOrStdout())
 				enc.SetIndent("", "  ")
 				_ = enc.Encode(validationResult{Valid: false, Error: err.Error()})
 			}
-			return fmt.Errorf("decode: %w", err)
+			return err
 		}
 
-		result := buildValidationResult(doc, header, headerErr)
 		if jsonOut {
 			enc := json.NewEncoder(cmd.OutOrStdout())
 			enc.SetIndent("", "  ")
-			return enc.Encode(result)
+			if err := enc.Encode(result); err != nil {
+				return err
+			}
+			if !result.Valid {
+				return fmt.Errorf("validation failed: %s", result.findingCounts())
+			}
+			return nil
 		}
 
+		for _, f := range result.Findings {
+			fmt.Fprintln(cmd.OutOrStdout(), f)
+		}
 		if !result.Valid {
-			for _, w := range result.Warnings {
-				fmt.Fprintf(cmd.OutOrStdout(), "WARNING: %s\n", w)
-			}
-			return fmt.Errorf("validation failed: markdown=%d media=%d", result.MarkdownFileCount, result.MediaItemCount)
+			return fmt.Errorf("validation failed: %s", result.findingCounts())
 		}
 		fmt.Fprintf(cmd.OutOrStdout(), "Valid MDOCX: markdown=%d (%s) media=%d (%s)\n",
 			result.MarkdownFileCount, humanSize(result.TotalMarkdownBytes),
@@ -65,18 +95,189 @@ var validateCmd = &cobra.Command{
 	},
 }
 
+// validateOptions are the settings shared by every file validate checks.
+type validateOptions struct {
+	format string // text, json, sarif or junit
+	strict bool
+	limits decodeLimits
+	rules  ruleSet
+	schema *metadataSchema // nil unless --metadata-schema is set
+	lint   bool            // also run the markdown style rules
+	// secrets is nil unless --scan-secrets is set.
+	secrets *secretScanner
+}
+
+func validateOptionsFromFlags(cmd *cobra.Command) (validateOptions, error) {
+	var o validateOptions
+	var err error
+	jsonOut, _ := cmd.Flags().GetBool("json")
+	o.format, _ = cmd.Flags().GetString("format")
+	o.format = strings.ToLower(strings.TrimSpace(o.format))
+	if o.format == "" {
+		o.format = "text"
+		if jsonOut {
+			o.format = "json"
+		}
+	} else if jsonOut && o.format != "json" {
+		return o, fmt.Errorf("--json conflicts with --format %s", o.format)
+	}
+	if !slices.Contains(validateFormats, o.format) {
+		return o, fmt.Errorf("unknown format: %s (want %s)", o.format, strings.Join(validateFormats, "|"))
+	}
+	o.strict, _ = cmd.Flags().GetBool("strict")
+	o.lint, _ = cmd.Flags().GetBool("lint")
+	if o.limits, err = limitsFromFlags(cmd); err != nil {
+		return o, err
+	}
+	if o.rules, err = rulesFromFlags(cmd); err != nil {
+		return o, err
+	}
+	if path, _ := cmd.Flags().GetString("metadata-schema"); path != "" {
+		if o.schema, err = loadMetadataSchema(path); err != nil {
+			return o, err
+		}
+	}
+	allowlist, _ := cmd.Flags().GetString("secrets-allowlist")
+	if scan, _ := cmd.Flags().GetBool("scan-secrets"); scan {
+		if o.secrets, err = newSecretScanner(nil, true, allowlist); err != nil {
+			return o, err
+		}
+	} else if allowlist != "" {
+		return o, fmt.Errorf("--secrets-allowlist requires --scan-secrets")
+	}
+	return o, nil
+}
+
+// validateFile decodes and checks the container at input. It returns an
+// error only if the container can't be decoded at all.
+func validateFile(input string, o validateOptions) (validationResult, error) {
+	// Validate header first.
+	header, headerErr := readHeaderInfo(input)
+
+	// Hashes are checked per item by MDX015 rather than failing the decode;
+	// without --strict they aren't checked at all.
+	doc, err := decodeContainerFile(input, false, o.limits)
+	if err != nil {
+		return validationResult{}, err
+	}
+	rules := o.rules
+	if !o.strict {
+		rules = maps.Clone(rules)
+		if rules == nil {
+			rules = ruleSet{}
+		}
+		rules[ruleSHA256Mismatch] = severityOff
+	}
+	result := buildValidationResult(doc, header, headerErr, rules)
+	if o.schema != nil || o.lint || o.secrets != nil {
+		r := &findingReporter{rules: rules, findings: result.Findings}
+		if o.schema != nil {
+			checkMetadataSchema(doc, o.schema, r)
+		}
+		if o.lint {
+			checkMarkdownStyle(doc, r)
+		}
+		if o.secrets != nil {
+			checkSecrets(doc, o.secrets, r)
+		}
+		result.setFindings(r.findings)
+	}
+	return result, nil
+}
+
+// validationBatchResult is the JSON output of validate over several files.
+type validationBatchResult struct {
+	Files   []validationResult `json:"files"`
+	Summary batchSummary       `json:"summary"`
+}
+
+// writeValidationReport writes results with a report writer such as
+// writeSARIF, and returns an error if any file is invalid.
+func writeValidationReport(out io.Writer, results []validationResult, rules ruleSet, write func(io.Writer, []validationResult, ruleSet) error) error {
+	var agg batchSummary
+	for _, r := range results {
+		agg.add(fileSize(r.File), r.Valid, r.MarkdownFileCount, r.MediaItemCount, r.TotalMarkdownBytes, r.TotalMediaBytes)
+	}
+	if err := write(out, results, rules); err != nil {
+		return err
+	}
+	return agg.err("validation")
+}
+
+// writeValidationBatch reports each file's result and the aggregate, and
+// returns an error if any file is invalid.
+func writeValidationBatch(out io.Writer, results []validationResult, jsonOut bool) error {
+	var agg batchSummary
+	for _, r := range results {
+		agg.add(fileSize(r.File), r.Valid, r.MarkdownFileCount, r.MediaItemCount, r.TotalMarkdownBytes, r.TotalMediaBytes)
+	}
+
+	if jsonOut {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(validationBatchResult{Files: results, Summary: agg}); err != nil {
+			return err
+		}
+		return agg.err("validation")
+	}
+
+	for _, r := range results {
+		switch {
+		case r.Error != "":
+			fmt.Fprintf(out, "FAIL  %s: %s\n", r.File, r.Error)
+		case !r.Valid:
+			fmt.Fprintf(out, "FAIL  %s\n", r.File)
+		default:
+			fmt.Fprintf(out, "OK    %s: markdown=%d (%s) media=%d (%s)\n", r.File,
+				r.MarkdownFileCount, humanSize(r.TotalMarkdownBytes),
+				r.MediaItemCount, humanSize(r.TotalMediaBytes))
+		}
+		for _, f := range r.Findings {
+			fmt.Fprintf(out, "      %s\n", f)
+		}
+	}
+	fmt.Fprintln(out)
+	agg.write(out, "valid", "invalid")
+	return agg.err("validation")
+}
+
 type validationResult struct {
+	File               string   `json:"file,omitempty"`
 	Valid              bool     `json:"valid"`
 	MarkdownFileCount  int      `json:"markdown_file_count"`
 	MediaItemCount     int      `json:"media_item_count"`
 	TotalMarkdownBytes int      `json:"total_markdown_bytes"`
 	TotalMediaBytes    int      `json:"total_media_bytes"`
+	Errors             []string `json:"errors,omitempty"`
 	Warnings           []string `json:"warnings,omitempty"`
 	Error              string   `json:"error,omitempty"`
+
+	Findings         []finding         `json:"findings,omitempty"`
+	BrokenReferences []brokenReference `json:"broken_references,omitempty"`
+	OrphanedMedia    []string          `json:"orphaned_media,omitempty"`
 }
 
-func buildValidationResult(doc *mdocx.Document, header *headerInfo, headerErr error) validationResult {
-	result := validationResult{Valid: true}
+// findingCounts summarizes the findings by severity, e.g. "2 errors, 1 warning".
+func (r validationResult) findingCounts() string {
+	counts := map[severity]int{}
+	for _, f := range r.Findings {
+		counts[f.Severity]++
+	}
+	var parts []string
+	for _, sev := range []severity{severityError, severityWarning, severityInfo} {
+		if n := counts[sev]; n > 0 {
+			word := string(sev)
+			if n > 1 {
+				word += "s"
+			}
+			parts = append(parts, fmt.Sprintf("%d %s", n, word))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func buildValidationResult(doc *mdocx.Document, header *headerInfo, headerErr error, rules ruleSet) validationResult {
+	result := validationResult{}
 	result.MarkdownFileCount = len(doc.Markdown.Files)
 	result.MediaItemCount = len(doc.Media.Items)
 	for _, mf := range doc.Markdown.Files {
@@ -85,46 +286,39 @@ func buildValidationResult(doc *mdocx.Document, header *headerInfo, headerErr er
 	for _, mi := range doc.Media.Items {
 		result.TotalMediaBytes += len(mi.Data)
 	}
+	r := &findingReporter{rules: rules}
 
 	// Validate header
 	if headerErr != nil {
-		result.Valid = false
-		result.Warnings = append(result.Warnings, fmt.Sprintf("header read error: %v", headerErr))
+		r.report(ruleHeaderUnreadable, finding{Message: fmt.Sprintf("header read error: %v", headerErr)})
 	} else if header != nil {
 		if !header.MagicValid {
-			result.Valid = false
-			result.Warnings = append(result.Warnings, "invalid magic bytes")
+			r.report(ruleInvalidMagic, finding{Message: "invalid magic bytes", Offset: offsetPtr(0)})
 		}
 		if header.Version != 1 {
-			result.Valid = false
-			result.Warnings = append(result.Warnings, fmt.Sprintf("header version is %d, expected 1", header.Version))
+			r.report(ruleUnsupportedVersion, finding{Message: fmt.Sprintf("header version is %d, expected 1", header.Version), Offset: offsetPtr(8)})
 		}
 		if header.FixedHdrSize != 32 {
-			result.Valid = false
-			result.Warnings = append(result.Warnings, fmt.Sprintf("fixed header size is %d, expected 32", header.FixedHdrSize))
+			r.report(ruleInvalidHeaderSize, finding{Message: fmt.Sprintf("fixed header size is %d, expected 32", header.FixedHdrSize), Offset: offsetPtr(12)})
 		}
 		if !header.ReservedClean {
-			result.Valid = false
-			result.Warnings = append(result.Warnings, "reserved header bytes are not zero")
+			r.report(ruleReservedBytesNonzero, finding{Message: "reserved header bytes are not zero", Offset: offsetPtr(20)})
 		}
 	}
 
 	// Validate BundleVersion
 	if doc.Markdown.BundleVersion != 1 {
-		result.Valid = false
-		result.Warnings = append(result.Warnings, fmt.Sprintf("markdown BundleVersion is %d, expected 1", doc.Markdown.BundleVersion))
+		r.report(ruleBundleVersion, finding{Message: fmt.Sprintf("markdown BundleVersion is %d, expected 1", doc.Markdown.BundleVersion)})
 	}
 	if doc.Media.BundleVersion != 1 {
-		result.Valid = false
-		result.Warnings = append(result.Warnings, fmt.Sprintf("media BundleVersion is %d, expected 1", doc.Media.BundleVersion))
+		r.report(ruleBundleVersion, finding{Message: fmt.Sprintf("media BundleVersion is %d, expected 1", doc.Media.BundleVersion)})
 	}
 
 	// Check unique Markdown paths
 	seenPaths := make(map[string]struct{})
 	for _, mf := range doc.Markdown.Files {
 		if _, ok := seenPaths[mf.Path]; ok {
-			result.Valid = false
-			result.Warnings = append(result.Warnings, fmt.Sprintf("duplicate markdown path: %q", mf.Path))
+			r.report(ruleDuplicateMarkdownPath, finding{Message: fmt.Sprintf("duplicate markdown path: %q", mf.Path), Path: mf.Path})
 		}
 		seenPaths[mf.Path] = struct{}{}
 	}
@@ -133,18 +327,70 @@ func buildValidationResult(doc *mdocx.Document, header *headerInfo, headerErr er
 	seenIDs := make(map[string]struct{})
 	for _, mi := range doc.Media.Items {
 		if _, ok := seenIDs[mi.ID]; ok {
-			result.Valid = false
-			result.Warnings = append(result.Warnings, fmt.Sprintf("duplicate media ID: %q", mi.ID))
+			r.report(ruleDuplicateMediaID, finding{Message: fmt.Sprintf("duplicate media ID: %q", mi.ID), Path: mi.Path})
 		}
 		seenIDs[mi.ID] = struct{}{}
 	}
 
+	// Check RootPath and references between entries
+	if root := doc.Markdown.RootPath; root != "" {
+		if _, ok := seenPaths[root]; !ok {
+			r.report(ruleMissingRootPath, finding{Message: fmt.Sprintf("RootPath %q does not match any markdown file", root)})
+		}
+	}
+	broken, used := scanReferences(doc)
+	result.BrokenReferences = broken
+	for _, b := range broken {
+		rule := ruleBrokenReference
+		if b.Anchor {
+			rule = ruleBrokenAnchor
+		}
+		r.report(rule, finding{Message: b.String(), Path: b.File, Offset: offsetPtr(int64(b.Offset)), Line: b.Line, Column: b.Column})
+	}
+
+	checkMedia(doc, r)
+	checkConventions(doc, r)
+	checkPortability(doc, r)
+	checkUnsafeContent(doc, r)
+
+	// Orphaned media is wasteful but not invalid by default
+	for _, i := range orphanedMedia(used) {
+		mi := doc.Media.Items[i]
+		result.OrphanedMedia = append(result.OrphanedMedia, mi.ID)
+		r.report(ruleOrphanedMedia, finding{Message: fmt.Sprintf("media item %q is not referenced by any markdown file (%s)", mi.ID, humanSize(len(mi.Data))), Path: mi.Path})
+	}
+
+	result.setFindings(r.findings)
 	return result
 }
 
+// setFindings records findings on r and derives Valid, Errors and Warnings
+// from them. Info-level findings appear only in Findings.
+func (r *validationResult) setFindings(findings []finding) {
+	r.Findings = findings
+	r.Valid = !(&findingReporter{findings: findings}).hasErrors()
+	r.Errors, r.Warnings = nil, nil
+	for _, f := range findings {
+		switch f.Severity {
+		case severityError:
+			r.Errors = append(r.Errors, f.Message)
+		case severityWarning:
+			r.Warnings = append(r.Warnings, f.Message)
+		}
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(validateCmd)
 
 	validateCmd.Flags().Bool("json", false, "output machine-readable JSON")
+	validateCmd.Flags().String("format", "", "output format (text|json|sarif|junit)")
 	validateCmd.Flags().Bool("strict", true, "fail on any spec violation")
+	validateCmd.Flags().Bool("lint", false, "also check markdown style (the rules of mdocx lint)")
+	validateCmd.Flags().String("metadata-schema", "", "JSON Schema file the container metadata must match")
+	validateCmd.Flags().Bool("scan-secrets", false, "also search for secrets (the default patterns of mdocx scan)")
+	validateCmd.Flags().String("secrets-allowlist", "", "allowlist file for --scan-secrets, as for mdocx scan --allowlist")
+	addLimitFlags(validateCmd)
+	addRuleFlags(validateCmd)
+	addBatchFlags(validateCmd)
 }
@@ -31,7 +31,7 @@ func TestBuildValidationResult_ValidDoc(t *testing.T) {
 		ReservedClean: true,
 	}
 
-	result := buildValidationResult(doc, header, nil)
+	result := buildValidationResult(doc, header, nil, nil)
 	if !result.Valid {
 		t.Fatalf("expected valid, got warnings: %v", result.Warnings)
 	}
@@ -55,18 +55,18 @@ func TestBuildValidationResult_BadBundleVersion(t *testing.T) {
 		Media:    mdocx.MediaBundle{BundleVersion: 1},
 	}
 
-	result := buildValidationResult(doc, nil, nil)
+	result := buildValidationResult(doc, nil, nil, nil)
 	if result.Valid {
 		t.Fatal("expected invalid for bad bundle version")
 	}
 	found := false
-	for _, w := range result.Warnings {
+	for _, w := range result.Errors {
 		if w == "markdown BundleVersion is 99, expected 1" {
 			found = true
 		}
 	}
 	if !found {
-		t.Errorf("expected warning about markdown BundleVersion, got: %v", result.Warnings)
+		t.Errorf("expected error about markdown BundleVersion, got: %v", result.Errors)
 	}
 }
 
@@ -82,18 +82,18 @@ func TestBuildValidationResult_DuplicateMarkdownPaths(t *testing.T) {
 		Media: mdocx.MediaBundle{BundleVersion: 1},
 	}
 
-	result := buildValidationResult(doc, nil, nil)
+	result := buildValidationResult(doc, nil, nil, nil)
 	if result.Valid {
 		t.Fatal("expected invalid for duplicate markdown paths")
 	}
 	found := false
-	for _, w := range result.Warnings {
+	for _, w := range result.Errors {
 		if w == `duplicate markdown path: "same.md"` {
 			found = true
 		}
 	}
 	if !found {
-		t.Errorf("expected duplicate path warning, got: %v", result.Warnings)
+		t.Errorf("expected duplicate path error, got: %v", result.Errors)
 	}
 }
 
@@ -109,18 +109,18 @@ func TestBuildValidationResult_DuplicateMediaIDs(t *testing.T) {
 		},
 	}
 
-	result := buildValidationResult(doc, nil, nil)
+	result := buildValidationResult(doc, nil, nil, nil)
 	if result.Valid {
 		t.Fatal("expected invalid for duplicate media IDs")
 	}
 	found := false
-	for _, w := range result.Warnings {
+	for _, w := range result.Errors {
 		if w == `duplicate media ID: "dup"` {
 			found = true
 		}
 	}
 	if !found {
-		t.Errorf("expected duplicate ID warning, got: %v", result.Warnings)
+		t.Errorf("expected duplicate ID error, got: %v", result.Errors)
 	}
 }
 
@@ -159,18 +159,18 @@ func TestBuildValidationResult_HeaderChecks(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := buildValidationResult(doc, tt.header, nil)
+			result := buildValidationResult(doc, tt.header, nil, nil)
 			if result.Valid {
 				t.Fatal("expected invalid")
 			}
 			found := false
-			for _, w := range result.Warnings {
+			for _, w := range result.Errors {
 				if w == tt.wantMsg {
 					found = true
 				}
 			}
 			if !found {
-				t.Errorf("expected warning %q, got: %v", tt.wantMsg, result.Warnings)
+				t.Errorf("expected error %q, got: %v", tt.wantMsg, result.Errors)
 			}
 		})
 	}
@@ -182,18 +182,18 @@ func TestBuildValidationResult_HeaderError(t *testing.T) {
 		Media:    mdocx.MediaBundle{BundleVersion: 1},
 	}
 
-	result := buildValidationResult(doc, nil, errForTest("header broke"))
+	result := buildValidationResult(doc, nil, errForTest("header broke"), nil)
 	if result.Valid {
 		t.Fatal("expected invalid when header has error")
 	}
 	found := false
-	for _, w := range result.Warnings {
+	for _, w := range result.Errors {
 		if w == "header read error: header broke" {
 			found = true
 		}
 	}
 	if !found {
-		t.Errorf("expected header read error warning, got: %v", result.Warnings)
+		t.Errorf("expected a header read error, got: %v", result.Errors)
 	}
 }
 
@@ -0,0 +1,309 @@
+package cmd
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// validateFormats are the output formats of validate.
+var validateFormats = []string{"text", "json", "sarif", "junit"}
+
+// SARIF 2.1.0 types, limited to the properties validate fills in.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool               sarifTool                        `json:"tool"`
+	OriginalURIBaseIDs map[string]sarifArtifactLocation `json:"originalUriBaseIds,omitempty"`
+	Invocations        []sarifInvocation                `json:"invocations"`
+	Results            []sarifResult                    `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	Name                 string             `json:"name"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Enabled bool   `json:"enabled"`
+	Level   string `json:"level"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful        bool                `json:"executionSuccessful"`
+	ToolExecutionNotifications []sarifNotification `json:"toolExecutionNotifications,omitempty"`
+}
+
+type sarifNotification struct {
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifResult struct {
+	RuleID     string          `json:"ruleId"`
+	RuleIndex  int             `json:"ruleIndex"`
+	Level      string          `json:"level"`
+	Message    sarifMessage    `json:"message"`
+	Locations  []sarifLocation `json:"locations"`
+	Properties map[string]any  `json:"properties,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI         string        `json:"uri"`
+	URIBaseID   string        `json:"uriBaseId,omitempty"`
+	Description *sarifMessage `json:"description,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int   `json:"startLine,omitempty"`
+	StartColumn int   `json:"startColumn,omitempty"`
+	ByteOffset  int64 `json:"byteOffset"`
+	ByteLength  int64 `json:"byteLength,omitempty"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// containerBaseURI returns the absolute file URI under which the entries of
+// the container at path are resolved, ending in a slash as SARIF requires
+// of a base URI.
+func containerBaseURI(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	p := filepath.ToSlash(path)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return (&url.URL{Scheme: "file", Path: p + "/"}).String()
+}
+
+// sarifLevel maps a finding severity to a SARIF result level.
+func sarifLevel(sev severity) string {
+	switch sev {
+	case severityError:
+		return "error"
+	case severityWarning:
+		return "warning"
+	case severityOff:
+		return "none"
+	}
+	return "note"
+}
+
+// writeSARIF reports the results of validate as a SARIF 2.1.0 log. Findings
+// about the container are located in its file, with a byte region for
+// header fields. Findings about an entry are located at the entry path
+// relative to a uriBaseId that stands for the container, with the entry's
+// line, column and byte offset as the region. Files that couldn't be decoded
+// are reported as tool notifications.
+func writeSARIF(w io.Writer, results []validationResult, rules ruleSet) error {
+	driver := sarifDriver{Name: "mdocx", Version: Version, InformationURI: "https://github.com/logicossoftware/mdocx-cli"}
+	ruleIndex := make(map[ruleID]int, len(validationRules))
+	for i, r := range validationRules {
+		sev := rules.severity(r)
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:                   string(r.ID),
+			Name:                 r.Name,
+			ShortDescription:     sarifMessage{Text: r.Description},
+			DefaultConfiguration: sarifConfiguration{Enabled: sev != severityOff, Level: sarifLevel(sev)},
+		})
+		ruleIndex[r.ID] = i
+	}
+
+	run := sarifRun{Tool: sarifTool{Driver: driver}, OriginalURIBaseIDs: map[string]sarifArtifactLocation{}, Results: []sarifResult{}}
+	invocation := sarifInvocation{ExecutionSuccessful: true}
+	for i, vr := range results {
+		uri := filepath.ToSlash(vr.File)
+		if vr.Error != "" {
+			invocation.ExecutionSuccessful = false
+			invocation.ToolExecutionNotifications = append(invocation.ToolExecutionNotifications, sarifNotification{
+				Level:     "error",
+				Message:   sarifMessage{Text: vr.Error},
+				Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: uri}}}},
+			})
+			continue
+		}
+		baseID := fmt.Sprintf("BUNDLE%d", i+1)
+		for _, f := range vr.Findings {
+			loc := sarifLocation{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: uri}}}
+			res := sarifResult{
+				RuleID:    string(f.Rule),
+				RuleIndex: ruleIndex[f.Rule],
+				Level:     sarifLevel(f.Severity),
+				Message:   sarifMessage{Text: f.Message},
+			}
+			switch {
+			case f.Path != "":
+				if _, ok := run.OriginalURIBaseIDs[baseID]; !ok {
+					run.OriginalURIBaseIDs[baseID] = sarifArtifactLocation{
+						URI:         containerBaseURI(vr.File),
+						Description: &sarifMessage{Text: "Entries of " + uri},
+					}
+				}
+				loc.PhysicalLocation.ArtifactLocation = sarifArtifactLocation{URI: (&url.URL{Path: f.Path}).EscapedPath(), URIBaseID: baseID}
+				loc.LogicalLocations = []sarifLogicalLocation{{Name: f.Path, FullyQualifiedName: uri + "/" + f.Path, Kind: "resource"}}
+				if f.Offset != nil {
+					loc.PhysicalLocation.Region = &sarifRegion{StartLine: f.Line, StartColumn: f.Column, ByteOffset: *f.Offset}
+				}
+			case f.Pointer != "":
+				res.Properties = map[string]any{"pointer": f.Pointer}
+			case f.Offset != nil:
+				loc.PhysicalLocation.Region = &sarifRegion{ByteOffset: *f.Offset, ByteLength: 1}
+			}
+			res.Locations = []sarifLocation{loc}
+			run.Results = append(run.Results, res)
+		}
+	}
+	run.Invocations = []sarifInvocation{invocation}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
+
+// JUnit XML types, in the common format CI servers read.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// writeJUnit reports the results of validate as JUnit XML: one test suite
+// per file and one test case per rule. Error-level findings fail their rule's
+// test case; warnings and info findings go to its system-out. Disabled rules
+// are skipped, and a file that couldn't be decoded is a single errored case.
+func writeJUnit(w io.Writer, results []validationResult, rules ruleSet) error {
+	doc := junitTestSuites{Name: "mdocx validate"}
+	for _, vr := range results {
+		suite := junitTestSuite{Name: vr.File}
+		if vr.Error != "" {
+			suite.Cases = append(suite.Cases, junitTestCase{
+				Name:      "decode",
+				ClassName: vr.File,
+				File:      vr.File,
+				Error:     &junitProblem{Message: vr.Error, Type: "decode"},
+			})
+			suite.Errors++
+		} else {
+			byRule := make(map[ruleID][]finding)
+			for _, f := range vr.Findings {
+				byRule[f.Rule] = append(byRule[f.Rule], f)
+			}
+			for _, r := range validationRules {
+				tc := junitTestCase{Name: string(r.ID) + " " + r.Name, ClassName: vr.File, File: vr.File}
+				if rules.severity(r) == severityOff {
+					tc.Skipped = &junitSkipped{Message: "rule disabled"}
+					suite.Skipped++
+				}
+				var failed, other []string
+				for _, f := range byRule[r.ID] {
+					if f.Severity == severityError {
+						failed = append(failed, f.Message)
+					} else {
+						other = append(other, f.String())
+					}
+				}
+				if len(failed) > 0 {
+					tc.Failure = &junitProblem{Message: failed[0], Type: string(severityError), Text: strings.Join(failed, "\n")}
+					suite.Failures++
+				}
+				tc.SystemOut = strings.Join(other, "\n")
+				suite.Cases = append(suite.Cases, tc)
+			}
+		}
+		suite.Tests = len(suite.Cases)
+		doc.Tests += suite.Tests
+		doc.Failures += suite.Failures
+		doc.Errors += suite.Errors
+		doc.Skipped += suite.Skipped
+		doc.Suites = append(doc.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
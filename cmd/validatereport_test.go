@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/logicossoftware/go-mdocx"
+)
+
+// reportTestFiles writes a container with broken references and a corrupt
+// file, and returns their paths.
+func reportTestFiles(t *testing.T) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	refs := filepath.Join(dir, "refs.mdocx")
+	f, err := os.Create(refs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := refCheckDoc()
+	doc.Media.Items = append(doc.Media.Items, mdocx.MediaItem{ID: "spare", MIMEType: "image/png", Data: testPNG(0)})
+	if err := mdocx.Encode(f, doc); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	corrupt := filepath.Join(dir, "corrupt.mdocx")
+	if err := os.WriteFile(corrupt, []byte("not an mdocx file at all, just text"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return refs, corrupt
+}
+
+func TestValidateCommand_SARIF(t *testing.T) {
+	refs, corrupt := reportTestFiles(t)
+	out, err := executeCommand(rootCmd, "validate", "--format", "sarif", refs, corrupt)
+	if err == nil || !strings.Contains(err.Error(), "2 of 2 files") {
+		t.Fatalf("expected both files to fail, got %v", err)
+	}
+	var log sarifLog
+	if err := json.NewDecoder(strings.NewReader(out)).Decode(&log); err != nil {
+		t.Fatalf("invalid SARIF: %v\n%s", err, out)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected log: %+v", log)
+	}
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != len(validationRules) {
+		t.Errorf("expected every rule in the driver, got %d", len(run.Tool.Driver.Rules))
+	}
+	if len(run.Results) != 4 {
+		t.Fatalf("expected 3 broken references and 1 orphan, got %+v", run.Results)
+	}
+	first := run.Results[0]
+	if first.RuleID != "MDX010" || first.Level != "error" || run.Tool.Driver.Rules[first.RuleIndex].ID != "MDX010" {
+		t.Errorf("unexpected first result: %+v", first)
+	}
+	loc := first.Locations[0]
+	art := loc.PhysicalLocation.ArtifactLocation
+	if art.URI != "docs/guide.md" || art.URIBaseID != "BUNDLE1" || loc.LogicalLocations[0].Name != "docs/guide.md" {
+		t.Errorf("unexpected location: %+v", loc)
+	}
+	if base := run.OriginalURIBaseIDs["BUNDLE1"].URI; !strings.HasPrefix(base, "file:///") || !strings.HasSuffix(base, "/refs.mdocx/") {
+		t.Errorf("unexpected base URI %q", base)
+	}
+	if r := loc.PhysicalLocation.Region; r == nil || r.StartLine != 5 || r.StartColumn != 29 || r.ByteOffset == 0 {
+		t.Errorf("expected the entry line, column and offset as the region, got %+v", r)
+	}
+	if last := run.Results[3]; last.RuleID != "MDX012" || last.Level != "warning" {
+		t.Errorf("unexpected orphan result: %+v", last)
+	}
+	inv := run.Invocations[0]
+	if inv.ExecutionSuccessful || len(inv.ToolExecutionNotifications) != 1 ||
+		inv.ToolExecutionNotifications[0].Locations[0].PhysicalLocation.ArtifactLocation.URI != filepath.ToSlash(corrupt) {
+		t.Errorf("expected a notification for the corrupt file, got %+v", inv)
+	}
+}
+
+func TestWriteSARIFRegion(t *testing.T) {
+	result := buildValidationResult(refCheckDoc(), &headerInfo{MagicValid: true, Version: 1, FixedHdrSize: 32}, nil,
+		ruleSet{"MDX010": severityOff, "MDX011": severityOff, "MDX012": severityOff})
+	result.File = "dir/refs.mdocx"
+	var out strings.Builder
+	if err := writeSARIF(&out, []validationResult{result}, nil); err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal([]byte(out.String()), &log); err != nil {
+		t.Fatalf("invalid SARIF: %v\n%s", err, out.String())
+	}
+	results := log.Runs[0].Results
+	if len(results) != 1 || results[0].RuleID != "MDX007" {
+		t.Fatalf("expected only the reserved bytes finding, got %+v", results)
+	}
+	loc := results[0].Locations[0]
+	if r := loc.PhysicalLocation.Region; r == nil || r.ByteOffset != 20 {
+		t.Errorf("expected a byte region at offset 20, got %+v", r)
+	}
+	if len(loc.LogicalLocations) != 0 || results[0].Properties != nil {
+		t.Errorf("container-level findings should have no entry location, got %+v", results[0])
+	}
+}
+
+func TestValidateCommand_JUnit(t *testing.T) {
+	refs, corrupt := reportTestFiles(t)
+	out, err := executeCommand(rootCmd, "validate", "--format", "junit", "--disable-rule", "MDX009", refs, corrupt)
+	if err == nil {
+		t.Fatal("expected validation to fail")
+	}
+	if !strings.HasPrefix(out, xml.Header) {
+		t.Errorf("missing XML header:\n%s", out)
+	}
+	var doc junitTestSuites
+	if err := xml.NewDecoder(strings.NewReader(out)).Decode(&doc); err != nil {
+		t.Fatalf("invalid JUnit XML: %v\n%s", err, out)
+	}
+	if len(doc.Suites) != 2 {
+		t.Fatalf("expected a suite per file, got %d", len(doc.Suites))
+	}
+	suite := doc.Suites[0]
+	if suite.Name != refs || suite.Tests != len(validationRules) || suite.Failures != 2 || suite.Skipped != 2 {
+		t.Errorf("unexpected suite: name=%s tests=%d failures=%d skipped=%d", suite.Name, suite.Tests, suite.Failures, suite.Skipped)
+	}
+	cases := map[string]junitTestCase{}
+	for _, tc := range suite.Cases {
+		cases[strings.Fields(tc.Name)[0]] = tc
+	}
+	if tc := cases["MDX010"]; tc.Failure == nil || strings.Count(tc.Failure.Text, "\n") != 1 || tc.File != refs {
+		t.Errorf("expected MDX010 to fail with two findings, got %+v", tc)
+	}
+	if tc := cases["MDX012"]; tc.Failure != nil || !strings.Contains(tc.SystemOut, `WARNING: media item "spare"`) {
+		t.Errorf("expected the orphan warning in system-out, got %+v", tc)
+	}
+	if tc := cases["MDX009"]; tc.Skipped == nil {
+		t.Errorf("expected the disabled rule to be skipped, got %+v", tc)
+	}
+	if bad := doc.Suites[1]; bad.Errors != 1 || len(bad.Cases) != 1 || bad.Cases[0].Error == nil {
+		t.Errorf("expected the corrupt file to be a single errored case, got %+v", bad)
+	}
+	if doc.Tests != len(validationRules)+1 || doc.Failures != 2 || doc.Errors != 1 {
+		t.Errorf("unexpected totals: tests=%d failures=%d errors=%d", doc.Tests, doc.Failures, doc.Errors)
+	}
+}
+
+func TestValidateCommand_FormatFlags(t *testing.T) {
+	refs, _ := reportTestFiles(t)
+	if _, err := executeCommand(rootCmd, "validate", "--json", "--format", "sarif", refs); err == nil || !strings.Contains(err.Error(), "conflicts") {
+		t.Errorf("expected --json/--format conflict, got %v", err)
+	}
+	if _, err := executeCommand(rootCmd, "validate", "--format", "xml", refs); err == nil || !strings.Contains(err.Error(), "unknown format") {
+		t.Errorf("expected unknown format error, got %v", err)
+	}
+}
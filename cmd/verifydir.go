@@ -0,0 +1,72 @@
+/*
+Copyright © 2026 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// verifyDirCmd represents the verify-dir command
+var verifyDirCmd = &cobra.Command{
+	Use:   "verify-dir <file> <dir>",
+	Short: "Check an unpacked directory against its .mdocx bundle",
+	Long:  "Compare the files in a directory with what unpacking the bundle would produce, reporting missing, modified, and extra files.",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jsonOut, _ := cmd.Flags().GetBool("json")
+		strict, _ := cmd.Flags().GetBool("strict")
+		limits, err := limitsFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+
+		doc, err := decodeContainerFile(args[0], strict, limits)
+		if err != nil {
+			return err
+		}
+
+		result, err := verifyUnpackedDir(doc, args[1])
+		if err != nil {
+			return fmt.Errorf("verify: %w", err)
+		}
+
+		if jsonOut {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(result); err != nil {
+				return err
+			}
+		} else {
+			for _, p := range result.Missing {
+				fmt.Fprintf(cmd.OutOrStdout(), "MISSING  %s\n", p)
+			}
+			for _, p := range result.Modified {
+				fmt.Fprintf(cmd.OutOrStdout(), "MODIFIED %s\n", p)
+			}
+			for _, p := range result.Extra {
+				fmt.Fprintf(cmd.OutOrStdout(), "EXTRA    %s\n", p)
+			}
+		}
+
+		if !result.OK {
+			return fmt.Errorf("directory does not match bundle: missing=%d modified=%d extra=%d",
+				len(result.Missing), len(result.Modified), len(result.Extra))
+		}
+		if !jsonOut {
+			fmt.Fprintf(cmd.OutOrStdout(), "Directory matches bundle: %d files verified\n", result.Matched)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifyDirCmd)
+
+	verifyDirCmd.Flags().Bool("json", false, "output machine-readable JSON")
+	verifyDirCmd.Flags().Bool("strict", true, "fail on any spec violation")
+	addLimitFlags(verifyDirCmd)
+}